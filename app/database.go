@@ -1,19 +1,23 @@
 package app
 
 import (
+	"bubblevy/restful-api/config"
 	"bubblevy/restful-api/helper"
 	"database/sql"
-	"time"
 )
 
-func NewDB() *sql.DB {
-	db, err := sql.Open("mysql", "root:@tcp(localhost:3306)/db_golang_restful_api")
+func NewDB(cfg config.DatabaseConfig) *sql.DB {
+	if cfg.Driver == config.DriverSQLite {
+		return NewSQLiteDB(cfg)
+	}
+
+	db, err := sql.Open(cfg.Driver, cfg.DSN)
 	helper.PanicIfError(err)
 
-	db.SetMaxIdleConns(10)
-	db.SetMaxOpenConns(100)
-	db.SetConnMaxIdleTime(10 * time.Minute)
-	db.SetConnMaxLifetime(60 * time.Minute)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
 
 	return db
 }
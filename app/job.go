@@ -0,0 +1,104 @@
+package app
+
+import (
+	"bubblevy/restful-api/auth"
+	"bubblevy/restful-api/config"
+	"bubblevy/restful-api/service"
+	"context"
+	"log"
+	"time"
+)
+
+// Job runs a task in the background, once when it starts and then every
+// Interval, until it is stopped. A failed run is logged and the task is tried
+// again at the next tick.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Task     func(ctx context.Context) error
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewJob(name string, interval time.Duration, task func(ctx context.Context) error) *Job {
+	return &Job{
+		Name:     name,
+		Interval: interval,
+		Task:     task,
+	}
+}
+
+func (job *Job) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	job.cancel = cancel
+	job.done = make(chan struct{})
+
+	go func() {
+		defer close(job.done)
+		ticker := time.NewTicker(job.Interval)
+		defer ticker.Stop()
+
+		for {
+			if err := job.Task(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("%s: %v", job.Name, err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop cancels the run in progress and waits for it to return, or for ctx to
+// end first.
+func (job *Job) Stop(ctx context.Context) error {
+	job.cancel()
+	select {
+	case <-job.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// NewTrashRetentionJob purges the products that have been in the trash longer
+// than the configured retention. It acts under an identity of its own that may
+// do nothing else.
+func NewTrashRetentionJob(productService service.ProductService, cfg config.TrashConfig) *Job {
+	identity := auth.Identity{Subject: "trash-retention", Scopes: []string{auth.ScopeProductsPurge}}
+	return NewJob("trash retention", cfg.PurgeInterval, func(ctx context.Context) error {
+		purged, err := productService.PurgeTrash(auth.WithIdentity(ctx, identity), time.Now().UTC().Add(-cfg.Retention))
+		if purged != 0 {
+			log.Printf("trash retention: purged %d products deleted more than %s ago", purged, cfg.Retention)
+		}
+		return err
+	})
+}
+
+// NewPriceScheduleJob applies the price schedules that have become due.
+func NewPriceScheduleJob(productService service.ProductService, cfg config.PricesConfig) *Job {
+	identity := auth.Identity{Subject: "price-scheduler", Scopes: []string{auth.ScopeProductsWrite}}
+	return NewJob("price schedule", cfg.ScheduleInterval, func(ctx context.Context) error {
+		applied, err := productService.ApplyPriceSchedules(auth.WithIdentity(ctx, identity), time.Now().UTC())
+		if applied != 0 {
+			log.Printf("price schedule: applied the schedules of %d products", applied)
+		}
+		return err
+	})
+}
+
+// NewIdempotencyRetentionJob removes the responses stored for Idempotency-Keys
+// once they have expired.
+func NewIdempotencyRetentionJob(productService service.ProductService, cfg config.ServerConfig) *Job {
+	identity := auth.Identity{Subject: "idempotency-retention", Scopes: []string{auth.ScopeProductsWrite}}
+	return NewJob("idempotency retention", cfg.IdempotencyPurgeInterval, func(ctx context.Context) error {
+		purged, err := productService.PurgeIdempotencyKeys(auth.WithIdentity(ctx, identity), time.Now().UTC())
+		if purged != 0 {
+			log.Printf("idempotency retention: removed %d expired idempotency keys", purged)
+		}
+		return err
+	})
+}
@@ -1,22 +1,67 @@
 package app
 
 import (
+	"bubblevy/restful-api/auth"
 	"bubblevy/restful-api/controller"
 	"bubblevy/restful-api/exception"
+	"bubblevy/restful-api/middleware"
+	"net/http"
 
 	"github.com/julienschmidt/httprouter"
 )
 
-func NewRouter(productController controller.ProductController) *httprouter.Router {
+func NewRouter(productController controller.ProductController, apiKeyController controller.ApiKeyController, auditController controller.AuditController) *httprouter.Router {
 	router := httprouter.New()
 
-	router.GET("/api/products", productController.FindAll)
-	router.GET("/api/products/:productId", productController.FindById)
-	router.POST("/api/products", productController.Create)
-	router.PUT("/api/products/:productId", productController.Update)
-	router.DELETE("/api/products/:productId", productController.Delete)
+	router.GET("/api/products", middleware.RequireScope(auth.ScopeProductsRead, productController.FindAll))
+	router.GET("/api/products/:productId", middleware.RequireScope(auth.ScopeProductsRead, withStaticSegment("productId", "export", productController.Export,
+		withStaticSegment("productId", "trash", productController.FindTrash, productController.FindById))))
+	router.GET("/api/products/:productId/revisions", middleware.RequireScope(auth.ScopeProductsRead, productController.FindRevisions))
+	router.GET("/api/products/:productId/revisions/:revision", middleware.RequireScope(auth.ScopeProductsRead, productController.FindRevision))
+	router.GET("/api/products/:productId/prices", middleware.RequireScope(auth.ScopeProductsRead, productController.FindPriceHistory))
+	router.GET("/api/products/:productId/prices/schedules", middleware.RequireScope(auth.ScopeProductsRead, productController.FindPriceSchedules))
+	router.POST("/api/products", middleware.RequireScope(auth.ScopeProductsWrite, productController.Create))
+	router.POST("/api/products/:productId", withStaticSegment("productId", "bulk", middleware.RequireScope(auth.ScopeProductsWrite, productController.Bulk),
+		withStaticSegment("productId", "import", middleware.RequireScope(auth.ScopeProductsWrite, productController.Import), methodNotAllowed("GET, PUT, PATCH, DELETE"))))
+	router.POST("/api/products/:productId/restore", middleware.RequireScope(auth.ScopeProductsDelete, productController.Restore))
+	router.POST("/api/products/:productId/purge", middleware.RequireScope(auth.ScopeProductsPurge, productController.Purge))
+	router.POST("/api/products/:productId/revert/:revision", middleware.RequireScope(auth.ScopeProductsWrite, productController.Revert))
+	router.POST("/api/products/:productId/prices/schedules", middleware.RequireScope(auth.ScopeProductsWrite, productController.SchedulePrice))
+	router.PUT("/api/products/:productId", middleware.RequireScope(auth.ScopeProductsWrite, productController.Update))
+	router.PATCH("/api/products/:productId", middleware.RequireScope(auth.ScopeProductsWrite, productController.Patch))
+	router.DELETE("/api/products/:productId", middleware.RequireScope(auth.ScopeProductsDelete, productController.Delete))
+	router.DELETE("/api/products/:productId/prices/schedules/:scheduleId", middleware.RequireScope(auth.ScopeProductsWrite, productController.CancelPriceSchedule))
+
+	router.GET("/api/apikeys", middleware.RequireScope(auth.ScopeApiKeysAdmin, apiKeyController.FindAll))
+	router.POST("/api/apikeys", middleware.RequireScope(auth.ScopeApiKeysAdmin, apiKeyController.Create))
+	router.DELETE("/api/apikeys/:apiKeyId", middleware.RequireScope(auth.ScopeApiKeysAdmin, apiKeyController.Revoke))
+
+	router.GET("/api/audit", middleware.RequireScope(auth.ScopeAuditRead, auditController.FindAll))
+	router.GET("/api/audit/export", middleware.RequireScope(auth.ScopeAuditRead, auditController.Export))
 
 	router.PanicHandler = exception.ErrorHandler
 
 	return router
 }
+
+// withStaticSegment serves the path whose wildcard param equals segment with
+// static. httprouter cannot register a static path next to a wildcard at the
+// same position, e.g. /api/products/export beside /api/products/:productId.
+func withStaticSegment(param string, segment string, static httprouter.Handle, wildcard httprouter.Handle) httprouter.Handle {
+	return func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+		if params.ByName(param) == segment {
+			static(writer, request, params)
+			return
+		}
+		wildcard(writer, request, params)
+	}
+}
+
+// methodNotAllowed answers a path that only exists for other methods, as
+// httprouter would if the path were not shared with static segments.
+func methodNotAllowed(allow string) httprouter.Handle {
+	return func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+		writer.Header().Set("Allow", allow)
+		http.Error(writer, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,112 @@
+package app
+
+import (
+	"bubblevy/restful-api/config"
+	"bubblevy/restful-api/helper"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+const (
+	ExitOK              = 0
+	ExitServerError     = 1
+	ExitShutdownTimeout = 2
+)
+
+func NewServer(cfg config.ServerConfig, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:         cfg.Addr,
+		Handler:      handler,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
+	}
+}
+
+// Lifecycle runs the HTTP server and the background jobs until the server
+// fails or the process receives SIGINT or SIGTERM, then shuts everything down
+// within ShutdownTimeout.
+type Lifecycle struct {
+	Server          *http.Server
+	Storage         io.Closer
+	ShutdownTimeout time.Duration
+	Jobs            []*Job
+}
+
+func NewLifecycle(server *http.Server, storage io.Closer, shutdownTimeout time.Duration, jobs ...*Job) *Lifecycle {
+	return &Lifecycle{
+		Server:          server,
+		Storage:         storage,
+		ShutdownTimeout: shutdownTimeout,
+		Jobs:            jobs,
+	}
+}
+
+// Run blocks until the server has stopped and returns the process exit code.
+func (lifecycle *Lifecycle) Run(ctx context.Context) int {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	for _, job := range lifecycle.Jobs {
+		job.Start()
+	}
+
+	serverError := make(chan error, 1)
+	go func() {
+		log.Printf("listening on %s", lifecycle.Server.Addr)
+		serverError <- lifecycle.Server.ListenAndServe()
+	}()
+
+	exitCode := ExitOK
+	select {
+	case err := <-serverError:
+		log.Printf("server stopped: %v", err)
+		exitCode = ExitServerError
+	case <-ctx.Done():
+		log.Printf("shutting down, waiting up to %s for in-flight work", lifecycle.ShutdownTimeout)
+	}
+	stop()
+
+	if code := lifecycle.shutdown(); code != ExitOK && exitCode == ExitOK {
+		exitCode = code
+	}
+	return exitCode
+}
+
+func (lifecycle *Lifecycle) shutdown() int {
+	ctx, cancel := context.WithTimeout(context.Background(), lifecycle.ShutdownTimeout)
+	defer cancel()
+
+	exitCode := ExitOK
+	if err := lifecycle.Server.Shutdown(ctx); err != nil {
+		log.Printf("closing connections: %v", err)
+		exitCode = ExitShutdownTimeout
+	}
+
+	// jobs stop before the drain, which refuses the transactions they open
+	for _, job := range lifecycle.Jobs {
+		if err := job.Stop(ctx); err != nil {
+			log.Printf("stopping %s: %v", job.Name, err)
+			exitCode = ExitShutdownTimeout
+		}
+	}
+
+	if err := helper.DrainTransactions(ctx); err != nil {
+		log.Printf("waiting for open transactions: %v", err)
+		exitCode = ExitShutdownTimeout
+	}
+
+	if err := lifecycle.Storage.Close(); err != nil {
+		log.Printf("closing storage: %v", err)
+		exitCode = ExitServerError
+	}
+
+	log.Printf("shutdown complete")
+	return exitCode
+}
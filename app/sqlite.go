@@ -0,0 +1,39 @@
+package app
+
+import (
+	"bubblevy/restful-api/config"
+	"bubblevy/restful-api/helper"
+	"database/sql"
+	"strings"
+)
+
+// sqliteOptions are appended to every SQLite DSN. SQLite allows one writer at
+// a time: _txlock=immediate takes the write lock when a transaction begins
+// instead of failing with SQLITE_BUSY when a reader later tries to write, and
+// _busy_timeout makes competing transactions wait for the lock. WAL lets
+// readers keep going while a transaction holds it.
+const sqliteOptions = "_txlock=immediate&_busy_timeout=5000&_journal_mode=WAL&_foreign_keys=on"
+
+// NewSQLiteDB opens the database file named by the DSN, creating it when it
+// does not exist yet.
+func NewSQLiteDB(cfg config.DatabaseConfig) *sql.DB {
+	db, err := sql.Open("sqlite3", sqliteDSN(cfg.DSN))
+	helper.PanicIfError(err)
+
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+
+	return db
+}
+
+func sqliteDSN(dsn string) string {
+	if !strings.HasPrefix(dsn, "file:") {
+		dsn = "file:" + dsn
+	}
+	if strings.Contains(dsn, "?") {
+		return dsn + "&" + sqliteOptions
+	}
+	return dsn + "?" + sqliteOptions
+}
@@ -0,0 +1,82 @@
+package app
+
+import (
+	"bubblevy/restful-api/config"
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/migration"
+	"bubblevy/restful-api/repository"
+	"context"
+	"database/sql"
+)
+
+// Storage is the backend chosen by the storage setting: the repositories and
+// the transaction manager they share.
+type Storage struct {
+	TxManager               helper.TxManager
+	ProductRepository       repository.ProductRepository
+	ApiKeyRepository        repository.ApiKeyRepository
+	IdempotencyRepository   repository.IdempotencyRepository
+	AuditRepository         repository.AuditRepository
+	PriceScheduleRepository repository.PriceScheduleRepository
+
+	// DB and Driver are set for SQL storage and Memory for in-memory storage.
+	DB     *sql.DB
+	Driver string
+	Memory *repository.MemoryStore
+}
+
+func NewStorage(cfg config.Config) Storage {
+	if cfg.Storage == config.StorageMemory {
+		store := repository.NewMemoryStore()
+		return Storage{
+			TxManager:               store,
+			ProductRepository:       repository.NewMemoryProductRepository(),
+			ApiKeyRepository:        repository.NewMemoryApiKeyRepository(),
+			IdempotencyRepository:   repository.NewMemoryIdempotencyRepository(),
+			AuditRepository:         repository.NewMemoryAuditRepository(),
+			PriceScheduleRepository: repository.NewMemoryPriceScheduleRepository(),
+			Memory:                  store,
+		}
+	}
+
+	db := NewDB(cfg.Database)
+	storage := Storage{
+		TxManager:               helper.NewSQLTxManager(db),
+		ProductRepository:       repository.NewProductRepository(),
+		ApiKeyRepository:        repository.NewApiKeyRepository(),
+		IdempotencyRepository:   repository.NewIdempotencyRepository(),
+		AuditRepository:         repository.NewAuditRepository(),
+		PriceScheduleRepository: repository.NewPriceScheduleRepository(),
+		DB:                      db,
+		Driver:                  cfg.Database.Driver,
+	}
+	switch cfg.Database.Driver {
+	case config.DriverPostgres:
+		storage.ProductRepository = repository.NewPostgresProductRepository()
+		storage.ApiKeyRepository = repository.NewPostgresApiKeyRepository()
+		storage.IdempotencyRepository = repository.NewPostgresIdempotencyRepository()
+		storage.AuditRepository = repository.NewPostgresAuditRepository()
+		storage.PriceScheduleRepository = repository.NewPostgresPriceScheduleRepository()
+	case config.DriverSQLite:
+		storage.ProductRepository = repository.NewSQLiteProductRepository()
+		storage.ApiKeyRepository = repository.NewSQLiteApiKeyRepository()
+		storage.IdempotencyRepository = repository.NewSQLiteIdempotencyRepository()
+		storage.AuditRepository = repository.NewSQLiteAuditRepository()
+		storage.PriceScheduleRepository = repository.NewSQLitePriceScheduleRepository()
+
+		// an embedded database has no separate setup step, so it is kept
+		// on the latest schema automatically
+		migrator, err := migration.New(db, cfg.Database.Driver)
+		helper.PanicIfError(err)
+		_, err = migrator.Up(context.Background())
+		helper.PanicIfError(err)
+	}
+	return storage
+}
+
+func (storage Storage) Close() error {
+	if storage.DB != nil {
+		return storage.DB.Close()
+	}
+	return storage.Memory.Close()
+}
@@ -0,0 +1,32 @@
+package app
+
+import (
+	"bubblevy/restful-api/money"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// NewValidator reports validation failures under the JSON names clients send,
+// e.g. product_name rather than ProductName. Money is validated by its amount,
+// so a required price must not be zero; one without a known currency counts
+// as missing.
+func NewValidator() *validator.Validate {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			return field.Name
+		}
+		return name
+	})
+	validate.RegisterCustomTypeFunc(func(field reflect.Value) interface{} {
+		price := field.Interface().(money.Money)
+		if !price.Valid() {
+			return nil
+		}
+		return price.Amount
+	}, money.Money{})
+	return validate
+}
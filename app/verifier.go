@@ -0,0 +1,31 @@
+package app
+
+import (
+	"bubblevy/restful-api/auth"
+	"bubblevy/restful-api/config"
+	"time"
+)
+
+// NewVerifier builds the bearer token verifier, or returns nil when neither a
+// shared secret nor a JWKS file is configured.
+func NewVerifier(cfg config.AuthConfig) (auth.Verifier, error) {
+	verifierConfig := auth.JWTVerifierConfig{
+		Issuer:     cfg.JWTIssuer,
+		Audience:   cfg.JWTAudience,
+		HMACSecret: []byte(cfg.JWTHMACSecret),
+		Leeway:     30 * time.Second,
+	}
+
+	if cfg.JWTJWKSFile != "" {
+		keys, err := auth.LoadKeySet(cfg.JWTJWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		verifierConfig.Keys = keys
+	}
+
+	if len(verifierConfig.HMACSecret) == 0 && len(verifierConfig.Keys) == 0 {
+		return nil, nil
+	}
+	return auth.NewJWTVerifier(verifierConfig)
+}
@@ -0,0 +1,35 @@
+package auth
+
+import "context"
+
+// MaxSubjectLength is the longest subject, in characters, an identity may
+// have. The subject is stored as the actor of audit entries and the owner of
+// API keys and idempotency keys, all of them varchar(100) columns.
+const MaxSubjectLength = 100
+
+// Identity is the authenticated caller of a request.
+type Identity struct {
+	Subject string
+	Roles   []string
+	Scopes  []string
+}
+
+type identityKey struct{}
+
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+func FromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(Identity)
+	return identity, ok
+}
+
+func (identity Identity) HasScope(scope string) bool {
+	for _, granted := range identity.Scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
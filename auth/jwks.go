@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+// KeySet holds the public keys of a JSON Web Key Set.
+type KeySet []publicKey
+
+type publicKey struct {
+	kid string
+	alg string
+	key interface{}
+}
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func LoadKeySet(path string) (KeySet, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseKeySet(content)
+}
+
+// ParseKeySet reads the RSA and P-256 signing keys of a JWKS document. Keys of
+// other types or meant for encryption are skipped.
+func ParseKeySet(content []byte) (KeySet, error) {
+	document := struct {
+		Keys []jsonWebKey `json:"keys"`
+	}{}
+	if err := json.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+
+	var keys KeySet
+	for _, jwk := range document.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		var key publicKey
+		var err error
+		switch jwk.Kty {
+		case "RSA":
+			key, err = parseRSAKey(jwk)
+		case "EC":
+			key, err = parseECKey(jwk)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWKS key %q: %w", jwk.Kid, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// Find returns the key for a token header. Without a kid the first key of the
+// right type is used.
+func (keys KeySet) Find(kid string, alg string) (interface{}, bool) {
+	for _, key := range keys {
+		if key.alg != alg {
+			continue
+		}
+		if kid == "" || key.kid == kid {
+			return key.key, true
+		}
+	}
+	return nil, false
+}
+
+func parseRSAKey(jwk jsonWebKey) (publicKey, error) {
+	if jwk.Alg != "" && jwk.Alg != "RS256" {
+		return publicKey{}, errors.New("unsupported alg " + jwk.Alg)
+	}
+
+	n, err := decodeBigInt(jwk.N)
+	if err != nil {
+		return publicKey{}, err
+	}
+	e, err := decodeBigInt(jwk.E)
+	if err != nil {
+		return publicKey{}, err
+	}
+
+	return publicKey{
+		kid: jwk.Kid,
+		alg: "RS256",
+		key: &rsa.PublicKey{N: n, E: int(e.Int64())},
+	}, nil
+}
+
+func parseECKey(jwk jsonWebKey) (publicKey, error) {
+	if jwk.Crv != "P-256" || (jwk.Alg != "" && jwk.Alg != "ES256") {
+		return publicKey{}, errors.New("only P-256 keys for ES256 are supported")
+	}
+
+	x, err := decodeBigInt(jwk.X)
+	if err != nil {
+		return publicKey{}, err
+	}
+	y, err := decodeBigInt(jwk.Y)
+	if err != nil {
+		return publicKey{}, err
+	}
+
+	key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+	if !key.Curve.IsOnCurve(x, y) {
+		return publicKey{}, errors.New("point is not on the P-256 curve")
+	}
+
+	return publicKey{kid: jwk.Kid, alg: "ES256", key: key}, nil
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(data), nil
+}
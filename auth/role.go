@@ -0,0 +1,29 @@
+package auth
+
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+var rolePermissions = map[string][]string{
+	RoleViewer: {ScopeProductsRead},
+	RoleEditor: {ScopeProductsRead, ScopeProductsWrite},
+	RoleAdmin:  AllScopes,
+}
+
+// Can reports whether the identity holds permission, either as a scope of its
+// own or through one of its roles. Unknown roles grant nothing.
+func (identity Identity) Can(permission string) bool {
+	if identity.HasScope(permission) {
+		return true
+	}
+	for _, role := range identity.Roles {
+		for _, granted := range rolePermissions[role] {
+			if granted == permission {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,17 @@
+package auth
+
+// Permissions double as API key scopes: a key is granted permissions
+// directly, while token callers usually receive them through their roles.
+// Deleting used to come with products:write; migration 0010 grants
+// products:delete to the keys issued before the split.
+const (
+	ScopeProductsRead   = "products:read"
+	ScopeProductsWrite  = "products:write"
+	ScopeProductsDelete = "products:delete"
+	ScopeProductsPurge  = "products:purge"
+	ScopeApiKeysAdmin   = "apikeys:admin"
+	ScopeAuditRead      = "audit:read"
+)
+
+// AllScopes lists every scope an API key can be granted.
+var AllScopes = []string{ScopeProductsRead, ScopeProductsWrite, ScopeProductsDelete, ScopeProductsPurge, ScopeApiKeysAdmin, ScopeAuditRead}
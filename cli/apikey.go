@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"bubblevy/restful-api/model/web"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const apiKeyUsage = "apikey create|revoke [flags] [args]"
+
+func (cli *CLI) apiKey(args []string) int {
+	if len(args) == 0 {
+		return cli.usageError(apiKeyUsage)
+	}
+
+	switch args[0] {
+	case "create":
+		return cli.apiKeyCreate(args[1:])
+	case "revoke":
+		return cli.apiKeyRevoke(args[1:])
+	}
+	return cli.usageError(apiKeyUsage)
+}
+
+func (cli *CLI) apiKeyCreate(args []string) int {
+	flags := flag.NewFlagSet("apikey create", flag.ContinueOnError)
+	format := outputFlag(flags)
+	request := web.ApiKeyCreateRequest{}
+	flags.StringVar(&request.Name, "name", "", "name describing what the key is for")
+	flags.StringVar(&request.Owner, "owner", "", "person or team responsible for the key")
+	scopes := flags.String("scopes", "", "comma-separated scopes, e.g. products:read,products:write")
+	flags.Func("expires-at", "RFC 3339 time the key stops working", func(raw string) error {
+		expiresAt, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return err
+		}
+		request.ExpiresAt = &expiresAt
+		return nil
+	})
+	cfg, ok := cli.loadConfig(flags, args)
+	if !ok {
+		return 2
+	}
+	if *scopes != "" {
+		request.Scopes = strings.Split(*scopes, ",")
+	}
+
+	services := newServices(cfg)
+	defer services.storage.Close()
+
+	apiKey, err := services.apiKeyService.Create(operatorContext(), request)
+	if err != nil {
+		return cli.fail(err)
+	}
+
+	fmt.Fprintln(cli.Stderr, "store the key now, it cannot be shown again")
+	return cli.render(*format, apiKey, func(writer io.Writer) {
+		fmt.Fprintln(writer, "ID\tNAME\tOWNER\tSCOPES\tKEY")
+		fmt.Fprintf(writer, "%d\t%s\t%s\t%s\t%s\n", apiKey.Id, apiKey.Name, apiKey.Owner, strings.Join(apiKey.Scopes, ","), apiKey.Key)
+	})
+}
+
+func (cli *CLI) apiKeyRevoke(args []string) int {
+	flags := flag.NewFlagSet("apikey revoke", flag.ContinueOnError)
+	cfg, ok := cli.loadConfig(flags, args)
+	if !ok {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		return cli.usageError("apikey revoke [flags] <id>")
+	}
+	apiKeyId, err := strconv.Atoi(flags.Arg(0))
+	if err != nil {
+		return cli.usageError("apikey revoke [flags] <id>")
+	}
+
+	services := newServices(cfg)
+	defer services.storage.Close()
+
+	err = services.apiKeyService.Revoke(operatorContext(), apiKeyId)
+	if err != nil {
+		return cli.fail(err)
+	}
+	fmt.Fprintf(cli.Stdout, "revoked api key %d\n", apiKeyId)
+	return 0
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"bubblevy/restful-api/model/web"
+	"flag"
+	"time"
+)
+
+const auditUsage = "audit export [flags] [file|-]"
+
+func (cli *CLI) audit(args []string) int {
+	if len(args) == 0 || args[0] != "export" {
+		return cli.usageError(auditUsage)
+	}
+	return cli.auditExport(args[1:])
+}
+
+// auditExport writes the audit log to a file, or to stdout when no file or -
+// is given, for compliance reviews.
+func (cli *CLI) auditExport(args []string) int {
+	flags := flag.NewFlagSet("audit export", flag.ContinueOnError)
+	request := web.AuditExportRequest{}
+	flags.StringVar(&request.Format, "format", web.TransferFormatCSV, "output format, csv or ndjson")
+	flags.Func("product-id", "only entries for this product", intPointerFlag(&request.ProductId))
+	flags.StringVar(&request.Actor, "actor", "", "only entries made by this caller")
+	flags.Func("from", "RFC 3339 time of the first entries to include", timePointerFlag(&request.From))
+	flags.Func("to", "RFC 3339 time the entries stop before", timePointerFlag(&request.To))
+	cfg, ok := cli.loadConfig(flags, args)
+	if !ok {
+		return 2
+	}
+	if flags.NArg() > 1 {
+		return cli.usageError(auditUsage)
+	}
+
+	services := newServices(cfg)
+	defer services.storage.Close()
+
+	writer, err := cli.openOutput(flags.Arg(0))
+	if err != nil {
+		return cli.fail(err)
+	}
+
+	err = services.auditService.Export(operatorContext(), request, writer)
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return cli.fail(err)
+	}
+	return 0
+}
+
+func timePointerFlag(target **time.Time) func(raw string) error {
+	return func(raw string) error {
+		value, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return err
+		}
+		*target = &value
+		return nil
+	}
+}
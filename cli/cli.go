@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"bubblevy/restful-api/auth"
+	"bubblevy/restful-api/config"
+	"bubblevy/restful-api/exception"
+	"bubblevy/restful-api/helper"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// command is one subcommand of the binary. run receives the arguments after
+// the command name and returns the process exit code.
+type command struct {
+	name  string
+	usage string
+	run   func(cli *CLI, args []string) int
+}
+
+var commands = []command{
+	{name: "serve", usage: "serve [flags]", run: (*CLI).serve},
+	{name: "migrate", usage: "migrate [flags] up|down|status|to <version>", run: (*CLI).migrate},
+	{name: "seed", usage: "seed [flags]", run: (*CLI).seed},
+	{name: "products", usage: "products list|get|create|update|delete|import|export [flags] [args]", run: (*CLI).products},
+	{name: "apikey", usage: "apikey create|revoke [flags] [args]", run: (*CLI).apiKey},
+	{name: "audit", usage: auditUsage, run: (*CLI).audit},
+}
+
+// CLI runs the subcommands of the binary, writing results to Stdout and
+// diagnostics to Stderr.
+type CLI struct {
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+func New(stdout io.Writer, stderr io.Writer) *CLI {
+	return &CLI{Stdout: stdout, Stderr: stderr}
+}
+
+// Run dispatches args to a subcommand and returns the process exit code: 0 on
+// success, 1 when the command failed and 2 for a usage error. Without a
+// command, or when args start with a flag, the server is started as before.
+func (cli *CLI) Run(args []string) int {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return cli.serve(args)
+	}
+
+	for _, command := range commands {
+		if command.name == args[0] {
+			return command.run(cli, args[1:])
+		}
+	}
+
+	cli.printUsage()
+	return 2
+}
+
+func (cli *CLI) printUsage() {
+	fmt.Fprintln(cli.Stderr, "usage:")
+	for _, command := range commands {
+		fmt.Fprintln(cli.Stderr, "  "+command.usage)
+	}
+}
+
+func (cli *CLI) usageError(usage string) int {
+	fmt.Fprintln(cli.Stderr, "usage: "+usage)
+	return 2
+}
+
+func (cli *CLI) fail(err error) int {
+	fmt.Fprintln(cli.Stderr, "error: "+errorMessage(err))
+	return 1
+}
+
+// errorMessage spells out validation failures field by field instead of
+// printing the validator's own summary.
+func errorMessage(err error) string {
+	var validationError exception.ValidationError
+	if errors.As(err, &validationError) && len(validationError.Fields) != 0 {
+		return strings.Join(validationError.FieldMessages(), ", ")
+	}
+	return err.Error()
+}
+
+// loadConfig parses the configuration flags together with the flags a
+// command declared on flags.
+func (cli *CLI) loadConfig(flags *flag.FlagSet, args []string) (config.Config, bool) {
+	flags.SetOutput(io.Discard)
+	cfg, err := config.LoadFlags(flags, args)
+	if err == flag.ErrHelp {
+		fmt.Fprintln(cli.Stderr, "usage of "+flags.Name()+":")
+		flags.SetOutput(cli.Stderr)
+		flags.PrintDefaults()
+		return cfg, false
+	}
+	if err != nil {
+		fmt.Fprintln(cli.Stderr, err)
+		return cfg, false
+	}
+	return cfg, true
+}
+
+// operatorContext carries the identity commands act under. Whoever can run the
+// binary against the database already holds every permission, so the services
+// are called as an administrator. Prices are read and written with their
+// currency, as API version 2 clients see them.
+func operatorContext() context.Context {
+	ctx := helper.WithApiVersion(context.Background(), helper.ApiVersion2)
+	return auth.WithIdentity(ctx, auth.Identity{Subject: "cli", Roles: []string{auth.RoleAdmin}})
+}
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"bubblevy/restful-api/app"
+	"bubblevy/restful-api/config"
+	"bubblevy/restful-api/migration"
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"text/tabwriter"
+)
+
+const migrateUsage = "migrate [flags] up|down|status|to <version>"
+
+func (cli *CLI) migrate(args []string) int {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	cfg, ok := cli.loadConfig(flags, args)
+	if !ok {
+		return 2
+	}
+	args = flags.Args()
+	if len(args) == 0 {
+		return cli.usageError(migrateUsage)
+	}
+	if cfg.Storage != config.StorageSQL {
+		fmt.Fprintln(cli.Stderr, "migrations only apply to sql storage")
+		return 2
+	}
+
+	db := app.NewDB(cfg.Database)
+	defer db.Close()
+
+	migrator, err := migration.New(db, cfg.Database.Driver)
+	if err != nil {
+		return cli.fail(err)
+	}
+
+	ctx := context.Background()
+	var steps []migration.Migration
+	switch {
+	case args[0] == "up" && len(args) == 1:
+		steps, err = migrator.Up(ctx)
+	case args[0] == "down" && len(args) == 1:
+		steps, err = migrator.Down(ctx)
+	case args[0] == "to" && len(args) == 2:
+		version, parseErr := strconv.Atoi(args[1])
+		if parseErr != nil {
+			return cli.usageError(migrateUsage)
+		}
+		steps, err = migrator.To(ctx, version)
+	case args[0] == "status" && len(args) == 1:
+		return cli.migrateStatus(ctx, migrator)
+	default:
+		return cli.usageError(migrateUsage)
+	}
+
+	for _, step := range steps {
+		fmt.Fprintf(cli.Stdout, "migrated %d_%s\n", step.Version, step.Name)
+	}
+	if err != nil {
+		return cli.fail(err)
+	}
+	if len(steps) == 0 {
+		fmt.Fprintln(cli.Stdout, "schema is already at the requested version")
+	}
+	return 0
+}
+
+func (cli *CLI) migrateStatus(ctx context.Context, migrator *migration.Migrator) int {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return cli.fail(err)
+	}
+
+	writer := tabwriter.NewWriter(cli.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, status := range statuses {
+		state, appliedAt := "pending", ""
+		if status.Applied {
+			state = "applied"
+			appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		if status.Modified {
+			state = "modified"
+		}
+		fmt.Fprintf(writer, "%d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+	}
+	writer.Flush()
+	return 0
+}
@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"text/tabwriter"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+func outputFlag(flags *flag.FlagSet) *string {
+	format := outputTable
+	flags.Func("output", "output format: table or json (default table)", func(raw string) error {
+		if raw != outputTable && raw != outputJSON {
+			return errors.New("must be table or json")
+		}
+		format = raw
+		return nil
+	})
+	return &format
+}
+
+// render writes value as indented JSON, or as the table that table draws.
+func (cli *CLI) render(format string, value interface{}, table func(writer io.Writer)) int {
+	if format == outputJSON {
+		encoder := json.NewEncoder(cli.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(value); err != nil {
+			return cli.fail(err)
+		}
+		return 0
+	}
+
+	writer := tabwriter.NewWriter(cli.Stdout, 0, 0, 2, ' ', 0)
+	table(writer)
+	writer.Flush()
+	return 0
+}
@@ -0,0 +1,413 @@
+package cli
+
+import (
+	"bubblevy/restful-api/model/web"
+	"bubblevy/restful-api/money"
+	"bubblevy/restful-api/service"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+const productsUsage = "products list|get|create|update|delete|restore|purge|import|export [flags] [args]"
+
+func (cli *CLI) products(args []string) int {
+	if len(args) == 0 {
+		return cli.usageError(productsUsage)
+	}
+
+	switch args[0] {
+	case "list":
+		return cli.productsList(args[1:])
+	case "get":
+		return cli.productsGet(args[1:])
+	case "create":
+		return cli.productsCreate(args[1:])
+	case "update":
+		return cli.productsUpdate(args[1:])
+	case "delete":
+		return cli.productsDelete(args[1:])
+	case "restore":
+		return cli.productsRestore(args[1:])
+	case "purge":
+		return cli.productsPurge(args[1:])
+	case "import":
+		return cli.productsImport(args[1:])
+	case "export":
+		return cli.productsExport(args[1:])
+	}
+	return cli.usageError(productsUsage)
+}
+
+func (cli *CLI) productsList(args []string) int {
+	flags := flag.NewFlagSet("products list", flag.ContinueOnError)
+	format := outputFlag(flags)
+	request := web.ProductFindAllRequest{}
+	flags.IntVar(&request.Page, "page", 0, "page to show, starting at 1")
+	flags.IntVar(&request.PerPage, "per-page", 0, "products per page")
+	flags.StringVar(&request.Sort, "sort", "", "sort fields, e.g. -price,product_name")
+	flags.StringVar(&request.NameContains, "name-contains", "", "only products whose name contains this text")
+	flags.StringVar(&request.MinPrice, "min-price", "", "only products costing at least this much, e.g. 12.50")
+	flags.StringVar(&request.MaxPrice, "max-price", "", "only products costing at most this much, e.g. 12.50")
+	flags.StringVar(&request.Currency, "currency", "", "only products priced in this currency")
+	cfg, ok := cli.loadConfig(flags, args)
+	if !ok {
+		return 2
+	}
+
+	services := newServices(cfg)
+	defer services.storage.Close()
+
+	response, err := services.productService.FindAll(operatorContext(), request)
+	if err != nil {
+		return cli.fail(err)
+	}
+	return cli.render(*format, response, func(writer io.Writer) {
+		writeProductTable(writer, response.Products...)
+		pagination := response.Pagination
+		fmt.Fprintf(writer, "\npage %d of %d, %d products\n", pagination.Page, pagination.TotalPages, pagination.Total)
+	})
+}
+
+func (cli *CLI) productsGet(args []string) int {
+	flags := flag.NewFlagSet("products get", flag.ContinueOnError)
+	format := outputFlag(flags)
+	cfg, ok := cli.loadConfig(flags, args)
+	if !ok {
+		return 2
+	}
+	productId, ok := productIdArgument(flags)
+	if !ok {
+		return cli.usageError("products get [flags] <id>")
+	}
+
+	services := newServices(cfg)
+	defer services.storage.Close()
+
+	product, err := services.productService.FindById(operatorContext(), productId)
+	if err != nil {
+		return cli.fail(err)
+	}
+	return cli.render(*format, product, func(writer io.Writer) {
+		writeProductTable(writer, product)
+	})
+}
+
+func (cli *CLI) productsCreate(args []string) int {
+	flags := flag.NewFlagSet("products create", flag.ContinueOnError)
+	format := outputFlag(flags)
+	request := web.ProductCreateRequest{}
+	flags.StringVar(&request.ProductName, "name", "", "product name")
+	price := flags.String("price", "", "product price, e.g. 12.50")
+	currency := flags.String("currency", money.DefaultCurrency, "ISO 4217 code of the price currency")
+	cfg, ok := cli.loadConfig(flags, args)
+	if !ok {
+		return 2
+	}
+	if *price != "" {
+		var err error
+		request.Price, err = money.Parse(*price, *currency)
+		if err != nil {
+			return cli.fail(err)
+		}
+	}
+
+	services := newServices(cfg)
+	defer services.storage.Close()
+
+	product, err := services.productService.Create(operatorContext(), request)
+	if err != nil {
+		return cli.fail(err)
+	}
+	return cli.render(*format, product, func(writer io.Writer) {
+		writeProductTable(writer, product)
+	})
+}
+
+// productsUpdate sends the fields given as flags as a merge patch, so the
+// others are kept as they are.
+func (cli *CLI) productsUpdate(args []string) int {
+	flags := flag.NewFlagSet("products update", flag.ContinueOnError)
+	format := outputFlag(flags)
+	name := flags.String("name", "", "new product name")
+	price := flags.String("price", "", "new product price, e.g. 12.50")
+	currency := flags.String("currency", money.DefaultCurrency, "ISO 4217 code of the new price currency")
+	ifVersion := flags.Int("if-version", 0, "only update the product while it is at this version")
+	cfg, ok := cli.loadConfig(flags, args)
+	if !ok {
+		return 2
+	}
+	productId, ok := productIdArgument(flags)
+	if !ok {
+		return cli.usageError("products update [flags] <id>")
+	}
+
+	changes := map[string]interface{}{}
+	var err error
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "name":
+			changes["product_name"] = *name
+		case "price":
+			var newPrice money.Money
+			newPrice, err = money.Parse(*price, *currency)
+			changes["price"] = newPrice
+		}
+	})
+	if err != nil {
+		return cli.fail(err)
+	}
+	patch, err := json.Marshal(changes)
+	if err != nil {
+		return cli.fail(err)
+	}
+
+	services := newServices(cfg)
+	defer services.storage.Close()
+
+	request := web.ProductPatchRequest{Id: productId, Type: web.PatchTypeMerge, Patch: patch, ExpectedVersions: expectedVersions(*ifVersion)}
+	product, err := services.productService.Patch(operatorContext(), request)
+	if err != nil {
+		return cli.fail(err)
+	}
+	return cli.render(*format, product, func(writer io.Writer) {
+		writeProductTable(writer, product)
+	})
+}
+
+func (cli *CLI) productsDelete(args []string) int {
+	flags := flag.NewFlagSet("products delete", flag.ContinueOnError)
+	ifVersion := flags.Int("if-version", 0, "only delete the product while it is at this version")
+	cfg, ok := cli.loadConfig(flags, args)
+	if !ok {
+		return 2
+	}
+	productId, ok := productIdArgument(flags)
+	if !ok {
+		return cli.usageError("products delete [flags] <id>")
+	}
+
+	services := newServices(cfg)
+	defer services.storage.Close()
+
+	request := web.ProductDeleteRequest{Id: productId, ExpectedVersions: expectedVersions(*ifVersion)}
+	err := services.productService.Delete(operatorContext(), request)
+	if err != nil {
+		return cli.fail(err)
+	}
+	fmt.Fprintf(cli.Stdout, "deleted product %d\n", productId)
+	return 0
+}
+
+func (cli *CLI) productsRestore(args []string) int {
+	flags := flag.NewFlagSet("products restore", flag.ContinueOnError)
+	format := outputFlag(flags)
+	cfg, ok := cli.loadConfig(flags, args)
+	if !ok {
+		return 2
+	}
+	productId, ok := productIdArgument(flags)
+	if !ok {
+		return cli.usageError("products restore [flags] <id>")
+	}
+
+	services := newServices(cfg)
+	defer services.storage.Close()
+
+	product, err := services.productService.Restore(operatorContext(), web.ProductRestoreRequest{Id: productId})
+	if err != nil {
+		return cli.fail(err)
+	}
+	return cli.render(*format, product, func(writer io.Writer) {
+		writeProductTable(writer, product)
+	})
+}
+
+// productsPurge removes a product from the trash for good.
+func (cli *CLI) productsPurge(args []string) int {
+	flags := flag.NewFlagSet("products purge", flag.ContinueOnError)
+	cfg, ok := cli.loadConfig(flags, args)
+	if !ok {
+		return 2
+	}
+	productId, ok := productIdArgument(flags)
+	if !ok {
+		return cli.usageError("products purge [flags] <id>")
+	}
+
+	services := newServices(cfg)
+	defer services.storage.Close()
+
+	err := services.productService.Purge(operatorContext(), web.ProductPurgeRequest{Id: productId})
+	if err != nil {
+		return cli.fail(err)
+	}
+	fmt.Fprintf(cli.Stdout, "purged product %d\n", productId)
+	return 0
+}
+
+// productsImport creates the products of a JSON array read from a file, or
+// from stdin when the file is -. Products that fail validation are reported
+// and skipped.
+func (cli *CLI) productsImport(args []string) int {
+	flags := flag.NewFlagSet("products import", flag.ContinueOnError)
+	cfg, ok := cli.loadConfig(flags, args)
+	if !ok {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		return cli.usageError("products import [flags] <file|->")
+	}
+
+	reader, err := openInput(flags.Arg(0))
+	if err != nil {
+		return cli.fail(err)
+	}
+	defer reader.Close()
+
+	requests := []web.ProductCreateRequest{}
+	if err := json.NewDecoder(reader).Decode(&requests); err != nil {
+		return cli.fail(fmt.Errorf("%s: %w", flags.Arg(0), err))
+	}
+
+	services := newServices(cfg)
+	defer services.storage.Close()
+
+	ctx := operatorContext()
+	failed := 0
+	for i, request := range requests {
+		_, err := services.productService.Create(ctx, request)
+		if err != nil {
+			fmt.Fprintf(cli.Stderr, "product %d: %s\n", i+1, errorMessage(err))
+			failed++
+		}
+	}
+
+	fmt.Fprintf(cli.Stdout, "imported %d of %d products\n", len(requests)-failed, len(requests))
+	if failed != 0 {
+		return 1
+	}
+	return 0
+}
+
+// productsExport writes every product as a JSON array to a file, or to stdout
+// when no file or - is given. It pages through the catalogue with cursors and
+// writes each page as it arrives, so large tables are never held at once.
+func (cli *CLI) productsExport(args []string) int {
+	flags := flag.NewFlagSet("products export", flag.ContinueOnError)
+	cfg, ok := cli.loadConfig(flags, args)
+	if !ok {
+		return 2
+	}
+	if flags.NArg() > 1 {
+		return cli.usageError("products export [flags] [file|-]")
+	}
+
+	services := newServices(cfg)
+	defer services.storage.Close()
+
+	writer, err := cli.openOutput(flags.Arg(0))
+	if err != nil {
+		return cli.fail(err)
+	}
+
+	err = exportProducts(services.productService, writer)
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return cli.fail(err)
+	}
+	return 0
+}
+
+func exportProducts(productService service.ProductService, writer io.Writer) error {
+	ctx := operatorContext()
+	request := web.ProductFindAllRequest{Mode: "cursor", Limit: 100}
+	separator := "[\n  "
+	for {
+		response, err := productService.FindAll(ctx, request)
+		if err != nil {
+			return err
+		}
+		for _, product := range response.Products {
+			data, err := json.Marshal(product)
+			if err != nil {
+				return err
+			}
+			if _, err := io.WriteString(writer, separator+string(data)); err != nil {
+				return err
+			}
+			separator = ",\n  "
+		}
+		if response.NextCursor == "" {
+			break
+		}
+		request.Cursor = response.NextCursor
+	}
+
+	if separator == "[\n  " {
+		_, err := io.WriteString(writer, "[]\n")
+		return err
+	}
+	_, err := io.WriteString(writer, "\n]\n")
+	return err
+}
+
+func writeProductTable(writer io.Writer, products ...web.ProductResponse) {
+	fmt.Fprintln(writer, "ID\tNAME\tPRICE\tVERSION")
+	for _, product := range products {
+		fmt.Fprintf(writer, "%d\t%s\t%s\t%d\n", product.Id, product.ProductName, product.Price, product.Version)
+	}
+}
+
+func productIdArgument(flags *flag.FlagSet) (int, bool) {
+	if flags.NArg() != 1 {
+		return 0, false
+	}
+	productId, err := strconv.Atoi(flags.Arg(0))
+	return productId, err == nil
+}
+
+func expectedVersions(version int) []int {
+	if version == 0 {
+		return nil
+	}
+	return []int{version}
+}
+
+func intPointerFlag(target **int) func(raw string) error {
+	return func(raw string) error {
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		*target = &value
+		return nil
+	}
+}
+
+func openInput(name string) (io.ReadCloser, error) {
+	if name == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(name)
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+func (cli *CLI) openOutput(name string) (io.WriteCloser, error) {
+	if name == "" || name == "-" {
+		return nopWriteCloser{cli.Stdout}, nil
+	}
+	return os.Create(name)
+}
@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"bubblevy/restful-api/app"
+	"bubblevy/restful-api/fixture"
+	"bubblevy/restful-api/model/domain"
+	"bubblevy/restful-api/model/web"
+	"flag"
+	"fmt"
+)
+
+// seedBatchSize bounds how many products one transaction inserts, so seeding
+// a load-test database does not hold a single huge transaction.
+const seedBatchSize = 500
+
+// seed loads products into the catalogue: the sample catalogue by default, a
+// fixture file with -file, or generated products with -fake. It leaves a
+// catalogue that already has products alone unless -force is given.
+func (cli *CLI) seed(args []string) int {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	file := flags.String("file", "", "YAML or JSON fixture file to load instead of the sample catalogue")
+	fake := flags.Int("fake", 0, "number of generated products to load instead of the sample catalogue")
+	seed := flags.Uint64("seed", 1, "seed of the generated products")
+	force := flags.Bool("force", false, "load even when the catalogue already has products")
+	cfg, ok := cli.loadConfig(flags, args)
+	if !ok {
+		return 2
+	}
+	if *file != "" && *fake != 0 {
+		return cli.usageError("seed [flags], with at most one of -file and -fake")
+	}
+
+	var products []domain.Product
+	switch {
+	case *file != "":
+		fixtures, err := fixture.ReadFile(*file)
+		if err != nil {
+			return cli.fail(err)
+		}
+		products = fixtures.DomainProducts()
+	case *fake != 0:
+		products = fixture.NewGenerator(*seed).Products(*fake)
+	default:
+		products = fixture.Sample().DomainProducts()
+	}
+
+	services := newServices(cfg)
+	defer services.storage.Close()
+
+	ctx := operatorContext()
+	if !*force {
+		existing, err := services.productService.FindAll(ctx, web.ProductFindAllRequest{PerPage: 1})
+		if err != nil {
+			return cli.fail(err)
+		}
+		if existing.Pagination.Total != 0 {
+			fmt.Fprintf(cli.Stdout, "catalogue already has %d products, nothing seeded\n", existing.Pagination.Total)
+			return 0
+		}
+	}
+
+	loader := fixture.NewLoader(services.storage.TxManager, services.storage.ProductRepository, app.NewValidator())
+	if err := loader.CheckProducts(products); err != nil {
+		return cli.fail(err)
+	}
+	for start := 0; start < len(products); start += seedBatchSize {
+		end := min(start+seedBatchSize, len(products))
+		if _, err := loader.LoadProducts(ctx, products[start:end]); err != nil {
+			return cli.fail(fmt.Errorf("after %d products: %w", start, err))
+		}
+	}
+	fmt.Fprintf(cli.Stdout, "seeded %d products\n", len(products))
+	return 0
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"bubblevy/restful-api/app"
+	"bubblevy/restful-api/config"
+	"bubblevy/restful-api/controller"
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/middleware"
+	"bubblevy/restful-api/service"
+	"context"
+	"crypto/rand"
+	"errors"
+	"flag"
+	"log"
+)
+
+// services are the application services a command works with.
+type services struct {
+	storage        app.Storage
+	productService service.ProductService
+	apiKeyService  service.ApiKeyService
+	auditService   service.AuditService
+}
+
+func newServices(cfg config.Config) services {
+	storage := app.NewStorage(cfg)
+	validate := app.NewValidator()
+	return services{
+		storage:        storage,
+		productService: service.NewProductService(storage.ProductRepository, storage.IdempotencyRepository, storage.AuditRepository, storage.PriceScheduleRepository, storage.TxManager, validate, helper.NewCursorCodec(cursorSecret(cfg.Auth)), cfg.Server.IdempotencyTTL),
+		apiKeyService:  service.NewApiKeyService(storage.ApiKeyRepository, storage.TxManager, validate),
+		auditService:   service.NewAuditService(storage.AuditRepository, storage.TxManager, validate),
+	}
+}
+
+// cursorSecret is the configured cursor secret, or a random one when none is
+// set.
+func cursorSecret(cfg config.AuthConfig) []byte {
+	if cfg.CursorSecret != "" {
+		return []byte(cfg.CursorSecret)
+	}
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	helper.PanicIfError(err)
+	return secret
+}
+
+func (cli *CLI) serve(args []string) int {
+	cfg, ok := cli.loadConfig(flag.NewFlagSet("serve", flag.ContinueOnError), args)
+	if !ok {
+		return 2
+	}
+	if cfg.Auth.APIKey == config.PublicAPIKey {
+		return cli.fail(errors.New("auth.api_key is the published key " + config.PublicAPIKey + ", set a secret key or leave it empty"))
+	}
+	log.Printf("effective config:\n%s", cfg)
+	if cfg.Auth.CursorSecret == "" {
+		log.Printf("auth.cursor_secret is not set, cursors are signed with a random secret and stop working on restart")
+	}
+
+	services := newServices(cfg)
+	productController := controller.NewProductController(services.productService)
+	apiKeyController := controller.NewApiKeyController(services.apiKeyService)
+	auditController := controller.NewAuditController(services.auditService)
+	router := app.NewRouter(productController, apiKeyController, auditController)
+
+	verifier, err := app.NewVerifier(cfg.Auth)
+	if err != nil {
+		services.storage.Close()
+		return cli.fail(err)
+	}
+
+	handler := middleware.NewRequestIdMiddleware(middleware.NewApiVersionMiddleware(middleware.NewAuthMiddleware(router, services.apiKeyService, cfg.Auth.APIKey, verifier)))
+	server := app.NewServer(cfg.Server, handler)
+	jobs := []*app.Job{app.NewPriceScheduleJob(services.productService, cfg.Prices), app.NewIdempotencyRetentionJob(services.productService, cfg.Server)}
+	if cfg.Trash.Retention != 0 {
+		jobs = append(jobs, app.NewTrashRetentionJob(services.productService, cfg.Trash))
+	}
+	lifecycle := app.NewLifecycle(server, services.storage, cfg.Server.ShutdownTimeout, jobs...)
+
+	return lifecycle.Run(context.Background())
+}
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	StorageSQL    = "sql"
+	StorageMemory = "memory"
+
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// PublicAPIKey is the bootstrap key earlier releases shipped as the default.
+// Anyone who has read the docs knows it, so serve refuses to start with it.
+const PublicAPIKey = "BUBBLEKEY"
+
+type Config struct {
+	Storage  string         `validate:"oneof=sql memory" yaml:"storage" toml:"storage"`
+	Server   ServerConfig   `yaml:"server" toml:"server"`
+	Database DatabaseConfig `yaml:"database" toml:"database"`
+	Auth     AuthConfig     `yaml:"auth" toml:"auth"`
+	Trash    TrashConfig    `yaml:"trash" toml:"trash"`
+	Prices   PricesConfig   `yaml:"prices" toml:"prices"`
+}
+
+type ServerConfig struct {
+	Addr            string        `validate:"required,hostname_port" yaml:"addr" toml:"addr"`
+	ReadTimeout     time.Duration `validate:"min=0" yaml:"read_timeout" toml:"read_timeout"`
+	WriteTimeout    time.Duration `validate:"min=0" yaml:"write_timeout" toml:"write_timeout"`
+	IdleTimeout     time.Duration `validate:"min=0" yaml:"idle_timeout" toml:"idle_timeout"`
+	ShutdownTimeout time.Duration `validate:"min=0" yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+	IdempotencyTTL  time.Duration `validate:"min=1s" yaml:"idempotency_ttl" toml:"idempotency_ttl"`
+	// IdempotencyPurgeInterval is how often the responses stored past their
+	// IdempotencyTTL are removed.
+	IdempotencyPurgeInterval time.Duration `validate:"min=1s" yaml:"idempotency_purge_interval" toml:"idempotency_purge_interval"`
+}
+
+type DatabaseConfig struct {
+	Driver          string        `validate:"oneof=mysql postgres sqlite" yaml:"driver" toml:"driver"`
+	DSN             string        `validate:"required" yaml:"dsn" toml:"dsn"`
+	MaxIdleConns    int           `validate:"min=0,ltefield=MaxOpenConns" yaml:"max_idle_conns" toml:"max_idle_conns"`
+	MaxOpenConns    int           `validate:"min=1" yaml:"max_open_conns" toml:"max_open_conns"`
+	ConnMaxIdleTime time.Duration `validate:"min=0" yaml:"conn_max_idle_time" toml:"conn_max_idle_time"`
+	ConnMaxLifetime time.Duration `validate:"min=0" yaml:"conn_max_lifetime" toml:"conn_max_lifetime"`
+}
+
+type AuthConfig struct {
+	APIKey string `validate:"omitempty,min=8" yaml:"api_key" toml:"api_key"`
+	// CursorSecret signs pagination cursors. Left empty, a random secret is
+	// drawn at startup, so cursors do not survive a restart and are not
+	// accepted by other instances.
+	CursorSecret  string `validate:"omitempty,min=8" yaml:"cursor_secret" toml:"cursor_secret"`
+	JWTIssuer     string `yaml:"jwt_issuer" toml:"jwt_issuer"`
+	JWTAudience   string `yaml:"jwt_audience" toml:"jwt_audience"`
+	JWTHMACSecret string `validate:"omitempty,min=32" yaml:"jwt_hmac_secret" toml:"jwt_hmac_secret"`
+	JWTJWKSFile   string `validate:"omitempty,file" yaml:"jwt_jwks_file" toml:"jwt_jwks_file"`
+}
+
+type TrashConfig struct {
+	// Retention is how long deleted products stay restorable; zero keeps
+	// them until they are purged by hand.
+	Retention     time.Duration `validate:"min=0" yaml:"retention" toml:"retention"`
+	PurgeInterval time.Duration `validate:"min=1s" yaml:"purge_interval" toml:"purge_interval"`
+}
+
+type PricesConfig struct {
+	// ScheduleInterval is how often due price schedules are applied. Reads
+	// resolve the price in effect regardless, but list filters and sorting
+	// by price use the stored price, so a product can fall outside the
+	// min_price, max_price or sort=price it is listed under for up to this
+	// long after a schedule falls due. Keep it short.
+	ScheduleInterval time.Duration `validate:"min=1s" yaml:"schedule_interval" toml:"schedule_interval"`
+}
+
+// Default returns the settings used for local development, matching the values
+// that used to be hard-coded in the application.
+func Default() Config {
+	return Config{
+		Storage: StorageSQL,
+		Server: ServerConfig{
+			Addr:                     "localhost:3000",
+			ReadTimeout:              15 * time.Second,
+			WriteTimeout:             15 * time.Second,
+			IdleTimeout:              60 * time.Second,
+			ShutdownTimeout:          30 * time.Second,
+			IdempotencyTTL:           24 * time.Hour,
+			IdempotencyPurgeInterval: time.Hour,
+		},
+		Database: DatabaseConfig{
+			Driver:          DriverMySQL,
+			DSN:             "root:@tcp(localhost:3306)/db_golang_restful_api?parseTime=true",
+			MaxIdleConns:    10,
+			MaxOpenConns:    100,
+			ConnMaxIdleTime: 10 * time.Minute,
+			ConnMaxLifetime: 60 * time.Minute,
+		},
+		Trash: TrashConfig{
+			Retention:     30 * 24 * time.Hour,
+			PurgeInterval: time.Hour,
+		},
+		Prices: PricesConfig{
+			ScheduleInterval: time.Minute,
+		},
+	}
+}
+
+func (cfg Config) Validate() error {
+	err := validator.New().Struct(cfg)
+	if err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	if _, _, err := net.SplitHostPort(cfg.Server.Addr); err != nil {
+		return fmt.Errorf("invalid config: server.addr: %w", err)
+	}
+	return nil
+}
+
+// String renders the effective configuration one key per line with every
+// secret redacted, so it is safe to print at startup.
+func (cfg Config) String() string {
+	var builder strings.Builder
+	for _, setting := range cfg.settings() {
+		value := setting.value.String()
+		if setting.secret && value != "" {
+			value = "******"
+		}
+		if setting.key == "database.dsn" {
+			value = redactDSN(value)
+		}
+		builder.WriteString(setting.key + " = " + value + "\n")
+	}
+	return builder.String()
+}
+
+var (
+	dsnPassword     = regexp.MustCompile(`^([^:/@]*):([^@]+)@`)
+	urlPassword     = regexp.MustCompile(`^([a-z]+://[^:/@]*):([^@]+)@`)
+	keywordPassword = regexp.MustCompile(`password=('[^']*'|\S+)`)
+)
+
+// redactDSN hides the password of a MySQL DSN or of a Postgres connection URL
+// or keyword/value string.
+func redactDSN(dsn string) string {
+	if strings.Contains(dsn, "://") {
+		return urlPassword.ReplaceAllString(dsn, "$1:******@")
+	}
+	dsn = keywordPassword.ReplaceAllString(dsn, "password=******")
+	return dsnPassword.ReplaceAllString(dsn, "$1:******@")
+}
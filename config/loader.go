@@ -0,0 +1,143 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Loader resolves a Config from, in increasing order of precedence, the
+// defaults, an optional YAML or TOML file, environment variables and
+// command-line flags.
+type Loader struct {
+	Defaults  Config
+	EnvPrefix string
+	LookupEnv func(key string) (string, bool)
+}
+
+// Load reads the application configuration using the BUBBLE_ environment prefix.
+func Load(args []string) (Config, error) {
+	loader := Loader{Defaults: Default(), EnvPrefix: "BUBBLE_"}
+	return loader.Load(args)
+}
+
+// LoadFlags is Load for a subcommand that declares flags of its own on flags.
+// The arguments left after the flags are available from flags.Args().
+func LoadFlags(flags *flag.FlagSet, args []string) (Config, error) {
+	loader := Loader{Defaults: Default(), EnvPrefix: "BUBBLE_"}
+	return loader.LoadFlags(flags, args)
+}
+
+func (loader Loader) Load(args []string) (Config, error) {
+	return loader.LoadFlags(flag.NewFlagSet("bubblego", flag.ContinueOnError), args)
+}
+
+func (loader Loader) LoadFlags(flags *flag.FlagSet, args []string) (Config, error) {
+	cfg := loader.Defaults
+	settings := cfg.settings()
+
+	lookupEnv := loader.LookupEnv
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+
+	configFile := flags.String("config", "", "path to a YAML or TOML config file")
+	flagValues := map[string]*string{}
+	for _, setting := range settings {
+		flagValues[setting.flag] = flags.String(setting.flag, "", setting.usage)
+	}
+	if err := flags.Parse(args); err != nil {
+		return cfg, err
+	}
+
+	path := *configFile
+	if path == "" {
+		path, _ = lookupEnv(loader.EnvPrefix + "CONFIG")
+	}
+	if path != "" {
+		if err := loadFile(path, settings); err != nil {
+			return cfg, err
+		}
+	}
+
+	for _, setting := range settings {
+		raw, ok := lookupEnv(loader.EnvPrefix + setting.env)
+		if !ok {
+			continue
+		}
+		if err := setting.value.Set(raw); err != nil {
+			return cfg, fmt.Errorf("%s%s: %w", loader.EnvPrefix, setting.env, err)
+		}
+	}
+
+	var flagErr error
+	flags.Visit(func(f *flag.Flag) {
+		for _, setting := range settings {
+			if setting.flag == f.Name && flagErr == nil {
+				if err := setting.value.Set(*flagValues[f.Name]); err != nil {
+					flagErr = fmt.Errorf("-%s: %w", f.Name, err)
+				}
+			}
+		}
+	})
+	if flagErr != nil {
+		return cfg, flagErr
+	}
+
+	return cfg, cfg.Validate()
+}
+
+func loadFile(path string, settings []setting) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	values := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &values)
+	case ".toml":
+		err = toml.Unmarshal(content, &values)
+	default:
+		return errors.New("unsupported config file format: " + path)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	flattened := map[string]interface{}{}
+	flatten("", values, flattened)
+
+	for key, raw := range flattened {
+		found := false
+		for _, setting := range settings {
+			if setting.key == key {
+				found = true
+				if err := setting.value.Set(fmt.Sprint(raw)); err != nil {
+					return fmt.Errorf("%s: %s: %w", path, key, err)
+				}
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: unknown key %s", path, key)
+		}
+	}
+	return nil
+}
+
+func flatten(prefix string, values map[string]interface{}, result map[string]interface{}) {
+	for key, value := range values {
+		if nested, ok := value.(map[string]interface{}); ok {
+			flatten(prefix+key+".", nested, result)
+		} else {
+			result[prefix+key] = value
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"strconv"
+	"time"
+)
+
+// setting binds one configuration key to its environment variable suffix and
+// command-line flag name.
+type setting struct {
+	key    string
+	env    string
+	flag   string
+	usage  string
+	secret bool
+	value  value
+}
+
+type value interface {
+	String() string
+	Set(raw string) error
+}
+
+func (cfg *Config) settings() []setting {
+	return []setting{
+		{key: "storage", env: "STORAGE", flag: "storage", usage: "storage backend: sql or memory", value: (*stringValue)(&cfg.Storage)},
+		{key: "server.addr", env: "SERVER_ADDR", flag: "addr", usage: "HTTP listen address", value: (*stringValue)(&cfg.Server.Addr)},
+		{key: "server.read_timeout", env: "SERVER_READ_TIMEOUT", flag: "read-timeout", usage: "maximum duration for reading a request", value: (*durationValue)(&cfg.Server.ReadTimeout)},
+		{key: "server.write_timeout", env: "SERVER_WRITE_TIMEOUT", flag: "write-timeout", usage: "maximum duration for writing a response", value: (*durationValue)(&cfg.Server.WriteTimeout)},
+		{key: "server.idle_timeout", env: "SERVER_IDLE_TIMEOUT", flag: "idle-timeout", usage: "maximum duration a keep-alive connection may stay idle", value: (*durationValue)(&cfg.Server.IdleTimeout)},
+		{key: "server.shutdown_timeout", env: "SERVER_SHUTDOWN_TIMEOUT", flag: "shutdown-timeout", usage: "deadline for draining connections and transactions on shutdown", value: (*durationValue)(&cfg.Server.ShutdownTimeout)},
+		{key: "server.idempotency_ttl", env: "SERVER_IDEMPOTENCY_TTL", flag: "idempotency-ttl", usage: "how long a response stored for an Idempotency-Key is replayed", value: (*durationValue)(&cfg.Server.IdempotencyTTL)},
+		{key: "server.idempotency_purge_interval", env: "SERVER_IDEMPOTENCY_PURGE_INTERVAL", flag: "idempotency-purge-interval", usage: "how often expired Idempotency-Key responses are removed", value: (*durationValue)(&cfg.Server.IdempotencyPurgeInterval)},
+		{key: "database.driver", env: "DATABASE_DRIVER", flag: "db-driver", usage: "database driver: mysql, postgres or sqlite", value: (*stringValue)(&cfg.Database.Driver)},
+		{key: "database.dsn", env: "DATABASE_DSN", flag: "db-dsn", usage: "database data source name", value: (*stringValue)(&cfg.Database.DSN)},
+		{key: "database.max_idle_conns", env: "DATABASE_MAX_IDLE_CONNS", flag: "db-max-idle-conns", usage: "maximum idle database connections", value: (*intValue)(&cfg.Database.MaxIdleConns)},
+		{key: "database.max_open_conns", env: "DATABASE_MAX_OPEN_CONNS", flag: "db-max-open-conns", usage: "maximum open database connections", value: (*intValue)(&cfg.Database.MaxOpenConns)},
+		{key: "database.conn_max_idle_time", env: "DATABASE_CONN_MAX_IDLE_TIME", flag: "db-conn-max-idle-time", usage: "maximum time a connection may stay idle", value: (*durationValue)(&cfg.Database.ConnMaxIdleTime)},
+		{key: "database.conn_max_lifetime", env: "DATABASE_CONN_MAX_LIFETIME", flag: "db-conn-max-lifetime", usage: "maximum lifetime of a connection", value: (*durationValue)(&cfg.Database.ConnMaxLifetime)},
+		{key: "auth.api_key", env: "AUTH_API_KEY", flag: "api-key", usage: "bootstrap API key granted every scope, empty to disable", secret: true, value: (*stringValue)(&cfg.Auth.APIKey)},
+		{key: "auth.jwt_issuer", env: "AUTH_JWT_ISSUER", flag: "jwt-issuer", usage: "required iss claim of bearer tokens", value: (*stringValue)(&cfg.Auth.JWTIssuer)},
+		{key: "auth.jwt_audience", env: "AUTH_JWT_AUDIENCE", flag: "jwt-audience", usage: "required aud claim of bearer tokens", value: (*stringValue)(&cfg.Auth.JWTAudience)},
+		{key: "auth.jwt_hmac_secret", env: "AUTH_JWT_HMAC_SECRET", flag: "jwt-hmac-secret", usage: "shared secret for HS256 bearer tokens", secret: true, value: (*stringValue)(&cfg.Auth.JWTHMACSecret)},
+		{key: "auth.jwt_jwks_file", env: "AUTH_JWT_JWKS_FILE", flag: "jwt-jwks-file", usage: "JWKS file with RS256/ES256 public keys for bearer tokens", value: (*stringValue)(&cfg.Auth.JWTJWKSFile)},
+		{key: "auth.cursor_secret", env: "AUTH_CURSOR_SECRET", flag: "cursor-secret", usage: "secret used to sign pagination cursors", secret: true, value: (*stringValue)(&cfg.Auth.CursorSecret)},
+		{key: "trash.retention", env: "TRASH_RETENTION", flag: "trash-retention", usage: "how long deleted products stay in the trash before they are purged, 0 to keep them", value: (*durationValue)(&cfg.Trash.Retention)},
+		{key: "trash.purge_interval", env: "TRASH_PURGE_INTERVAL", flag: "trash-purge-interval", usage: "how often the trash is checked for products past their retention", value: (*durationValue)(&cfg.Trash.PurgeInterval)},
+		{key: "prices.schedule_interval", env: "PRICES_SCHEDULE_INTERVAL", flag: "prices-schedule-interval", usage: "how often due price schedules are applied to the stored prices", value: (*durationValue)(&cfg.Prices.ScheduleInterval)},
+	}
+}
+
+type stringValue string
+
+func (v *stringValue) String() string { return string(*v) }
+
+func (v *stringValue) Set(raw string) error {
+	*v = stringValue(raw)
+	return nil
+}
+
+type intValue int
+
+func (v *intValue) String() string { return strconv.Itoa(int(*v)) }
+
+func (v *intValue) Set(raw string) error {
+	parsed, err := strconv.Atoi(raw)
+	if err != nil {
+		return err
+	}
+	*v = intValue(parsed)
+	return nil
+}
+
+type durationValue time.Duration
+
+func (v *durationValue) String() string { return time.Duration(*v).String() }
+
+func (v *durationValue) Set(raw string) error {
+	parsed, err := time.ParseDuration(raw)
+	if err != nil {
+		return err
+	}
+	*v = durationValue(parsed)
+	return nil
+}
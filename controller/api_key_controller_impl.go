@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"bubblevy/restful-api/exception"
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/model/web"
+	"bubblevy/restful-api/service"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+type apiKeyControllerImpl struct {
+	ApiKeyService service.ApiKeyService
+}
+
+func NewApiKeyController(apiKeyService service.ApiKeyService) ApiKeyController {
+	return &apiKeyControllerImpl{
+		ApiKeyService: apiKeyService,
+	}
+}
+
+func (controller *apiKeyControllerImpl) Create(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	apiKeyCreateRequest := web.ApiKeyCreateRequest{}
+	err := helper.ReadFromRequestBody(request, &apiKeyCreateRequest)
+	if err != nil {
+		exception.WriteError(writer, request, exception.NewValidationError("Malformed JSON request body"))
+		return
+	}
+
+	apiKeyResponse, err := controller.ApiKeyService.Create(request.Context(), apiKeyCreateRequest)
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+
+	webResponse := web.WebResponse{
+		Code:    http.StatusCreated,
+		Error:   false,
+		Message: "Create API key successfully. Store the key now, it will not be shown again",
+		Data:    apiKeyResponse,
+	}
+
+	writer.Header().Set("Cache-Control", "no-store")
+	writer.WriteHeader(http.StatusCreated)
+
+	helper.WriteToResponseBody(writer, webResponse)
+}
+
+func (controller *apiKeyControllerImpl) Revoke(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	id, err := strconv.Atoi(params.ByName("apiKeyId"))
+	if err != nil {
+		exception.WriteError(writer, request, exception.NewValidationError("apiKeyId must be an integer"))
+		return
+	}
+
+	err = controller.ApiKeyService.Revoke(request.Context(), id)
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+
+	webResponse := web.WebResponse{
+		Code:    http.StatusOK,
+		Error:   false,
+		Message: "Revoke API key successfully",
+	}
+
+	helper.WriteToResponseBody(writer, webResponse)
+}
+
+func (controller *apiKeyControllerImpl) FindAll(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	apiKeyResponses, err := controller.ApiKeyService.FindAll(request.Context())
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+
+	webResponse := web.WebResponse{
+		Code:    http.StatusOK,
+		Error:   false,
+		Message: "Successfully retrieved all API keys",
+		Data:    apiKeyResponses,
+	}
+
+	helper.WriteToResponseBody(writer, webResponse)
+}
@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/model/web"
+	"net/http"
+)
+
+// versioned renders the product data of a response for the API version the
+// request asked for. Version 2 is what the services return; version 1 clients
+// get their integer prices back.
+func versioned(request *http.Request, data interface{}) interface{} {
+	if helper.ApiVersion(request.Context()) != helper.ApiVersion1 {
+		return data
+	}
+
+	switch data := data.(type) {
+	case web.ProductResponse:
+		return helper.ToProductResponseV1(data)
+	case []web.ProductResponse:
+		return helper.ToProductResponsesV1(data)
+	case web.ProductRevisionResponse:
+		return web.ProductRevisionResponseV1{ProductResponseV1: helper.ToProductResponseV1(data.ProductResponse), RecordedAt: data.RecordedAt}
+	case []web.ProductRevisionResponse:
+		return helper.ToProductRevisionResponsesV1(data)
+	case web.ProductBulkResponse:
+		return helper.ToProductBulkResponseV1(data)
+	case web.PriceScheduleResponse:
+		return helper.ToPriceScheduleResponseV1(data)
+	case []web.PriceScheduleResponse:
+		return helper.ToPriceScheduleResponsesV1(data)
+	case []web.PricePointResponse:
+		return helper.ToPricePointResponsesV1(data)
+	}
+	return data
+}
@@ -0,0 +1,12 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+type AuditController interface {
+	FindAll(writer http.ResponseWriter, request *http.Request, params httprouter.Params)
+	Export(writer http.ResponseWriter, request *http.Request, params httprouter.Params)
+}
@@ -0,0 +1,120 @@
+package controller
+
+import (
+	"bubblevy/restful-api/exception"
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/model/web"
+	"bubblevy/restful-api/service"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+type auditControllerImpl struct {
+	AuditService service.AuditService
+}
+
+func NewAuditController(auditService service.AuditService) AuditController {
+	return &auditControllerImpl{
+		AuditService: auditService,
+	}
+}
+
+func (controller *auditControllerImpl) FindAll(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	auditFindAllRequest := web.AuditFindAllRequest{}
+	query := request.URL.Query()
+	err := readAuditFilterRequest(query, &auditFindAllRequest.AuditFilterRequest)
+	if err == nil {
+		auditFindAllRequest.Page, err = queryInt(query, "page")
+	}
+	if err == nil {
+		auditFindAllRequest.PerPage, err = queryInt(query, "per_page")
+	}
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+
+	auditListResponse, err := controller.AuditService.FindAll(request.Context(), auditFindAllRequest)
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+
+	helper.PaginationLinks(request.URL, auditListResponse.Pagination)
+
+	webResponse := web.WebResponse{
+		Code:       http.StatusOK,
+		Error:      false,
+		Message:    "Successfully retrieved audit entries",
+		Data:       auditListResponse.Entries,
+		Pagination: auditListResponse.Pagination,
+	}
+
+	helper.WriteToResponseBody(writer, webResponse)
+}
+
+// Export streams the matching entries as a file, like the product export.
+func (controller *auditControllerImpl) Export(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	auditExportRequest := web.AuditExportRequest{Format: request.URL.Query().Get("format")}
+	if auditExportRequest.Format == "" {
+		auditExportRequest.Format = web.TransferFormatCSV
+	}
+	err := readAuditFilterRequest(request.URL.Query(), &auditExportRequest.AuditFilterRequest)
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+
+	stream := &streamWriter{writer: writer, prepare: func(header http.Header) {
+		header.Set("Content-Type", transferContentTypes[auditExportRequest.Format])
+		header.Set("Content-Disposition", `attachment; filename="audit.`+auditExportRequest.Format+`"`)
+	}}
+
+	err = controller.AuditService.Export(request.Context(), auditExportRequest, stream)
+	if err != nil && !stream.started {
+		exception.WriteError(writer, request, err)
+		return
+	}
+	if err != nil {
+		log.Printf("audit export aborted serving %s %s (request %s): %v", request.Method, request.URL.Path, helper.RequestId(request.Context()), err)
+		panic(http.ErrAbortHandler)
+	}
+
+	if !stream.started {
+		stream.prepare(writer.Header())
+		writer.WriteHeader(http.StatusOK)
+	}
+}
+
+func readAuditFilterRequest(query url.Values, request *web.AuditFilterRequest) error {
+	request.Actor = query.Get("actor")
+
+	var err error
+	if request.ProductId, err = queryIntPointer(query, "product_id"); err != nil {
+		return err
+	}
+	if request.From, err = queryTime(query, "from"); err != nil {
+		return err
+	}
+	if request.To, err = queryTime(query, "to"); err != nil {
+		return err
+	}
+	return nil
+}
+
+func queryTime(query url.Values, key string) (*time.Time, error) {
+	raw := query.Get(key)
+	if raw == "" {
+		return nil, nil
+	}
+
+	value, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, exception.NewValidationError(key + " must be an RFC 3339 time, e.g. 2024-01-31T00:00:00Z")
+	}
+	return &value, nil
+}
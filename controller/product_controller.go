@@ -9,7 +9,21 @@ import (
 type ProductController interface {
 	Create(writer http.ResponseWriter, request *http.Request, params httprouter.Params)
 	Update(writer http.ResponseWriter, request *http.Request, params httprouter.Params)
+	Patch(writer http.ResponseWriter, request *http.Request, params httprouter.Params)
 	Delete(writer http.ResponseWriter, request *http.Request, params httprouter.Params)
 	FindById(writer http.ResponseWriter, request *http.Request, params httprouter.Params)
 	FindAll(writer http.ResponseWriter, request *http.Request, params httprouter.Params)
+	Bulk(writer http.ResponseWriter, request *http.Request, params httprouter.Params)
+	Import(writer http.ResponseWriter, request *http.Request, params httprouter.Params)
+	Export(writer http.ResponseWriter, request *http.Request, params httprouter.Params)
+	FindTrash(writer http.ResponseWriter, request *http.Request, params httprouter.Params)
+	Restore(writer http.ResponseWriter, request *http.Request, params httprouter.Params)
+	Purge(writer http.ResponseWriter, request *http.Request, params httprouter.Params)
+	FindRevisions(writer http.ResponseWriter, request *http.Request, params httprouter.Params)
+	FindRevision(writer http.ResponseWriter, request *http.Request, params httprouter.Params)
+	Revert(writer http.ResponseWriter, request *http.Request, params httprouter.Params)
+	FindPriceHistory(writer http.ResponseWriter, request *http.Request, params httprouter.Params)
+	FindPriceSchedules(writer http.ResponseWriter, request *http.Request, params httprouter.Params)
+	SchedulePrice(writer http.ResponseWriter, request *http.Request, params httprouter.Params)
+	CancelPriceSchedule(writer http.ResponseWriter, request *http.Request, params httprouter.Params)
 }
@@ -1,10 +1,13 @@
 package controller
 
 import (
+	"bubblevy/restful-api/exception"
 	"bubblevy/restful-api/helper"
 	"bubblevy/restful-api/model/web"
 	"bubblevy/restful-api/service"
+	"mime"
 	"net/http"
+	"net/url"
 	"strconv"
 
 	"github.com/julienschmidt/httprouter"
@@ -22,16 +25,27 @@ func NewProductController(productService service.ProductService) ProductControll
 
 func (controller *productControllerImpl) Create(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
 	productCreateRequest := web.ProductCreateRequest{}
-	helper.ReadFromRequestBody(request, &productCreateRequest)
+	err := helper.ReadFromRequestBody(request, &productCreateRequest)
+	if err != nil {
+		exception.WriteError(writer, request, exception.NewValidationError("Malformed JSON request body"))
+		return
+	}
+	productCreateRequest.IdempotencyKey = request.Header.Get("Idempotency-Key")
+
+	productResponse, err := controller.ProductService.Create(request.Context(), productCreateRequest)
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
 
-	productResponse := controller.ProductService.Create(request.Context(), productCreateRequest)
 	webResponse := web.WebResponse{
 		Code:    http.StatusCreated,
 		Error:   false,
 		Message: "Create product successfully",
-		Data:    productResponse,
+		Data:    versioned(request, productResponse),
 	}
 
+	writer.Header().Set("ETag", productETag(request, productResponse))
 	writer.WriteHeader(http.StatusCreated)
 
 	helper.WriteToResponseBody(writer, webResponse)
@@ -39,31 +53,94 @@ func (controller *productControllerImpl) Create(writer http.ResponseWriter, requ
 
 func (controller *productControllerImpl) Update(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
 	productUpdateRequest := web.ProductUpdateRequest{}
-	helper.ReadFromRequestBody(request, &productUpdateRequest)
+	err := helper.ReadFromRequestBody(request, &productUpdateRequest)
+	if err != nil {
+		exception.WriteError(writer, request, exception.NewValidationError("Malformed JSON request body"))
+		return
+	}
 
-	productId := params.ByName("productId")
-	id, err := strconv.Atoi(productId)
-	helper.PanicIfError(err)
+	id, err := readProductId(params)
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
 
 	productUpdateRequest.Id = id
+	productUpdateRequest.ExpectedVersions = readIfMatch(request)
+
+	productResponse, err := controller.ProductService.Update(request.Context(), productUpdateRequest)
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+
+	webResponse := web.WebResponse{
+		Code:    http.StatusOK,
+		Error:   false,
+		Message: "Update product successfully",
+		Data:    versioned(request, productResponse),
+	}
+
+	writer.Header().Set("ETag", productETag(request, productResponse))
+
+	helper.WriteToResponseBody(writer, webResponse)
+}
+
+// Patch takes a JSON Merge Patch or a JSON Patch, told apart by the content
+// type. Other content types are refused with the ones accepted.
+func (controller *productControllerImpl) Patch(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	mediaType, _, _ := mime.ParseMediaType(request.Header.Get("Content-Type"))
+	if mediaType != web.PatchTypeMerge && mediaType != web.PatchTypeJSON {
+		writer.Header().Set("Accept-Patch", web.PatchTypeMerge+", "+web.PatchTypeJSON)
+		exception.WriteError(writer, request, exception.NewUnsupportedMediaTypeError("Content-Type must be "+web.PatchTypeMerge+" or "+web.PatchTypeJSON))
+		return
+	}
+
+	id, err := readProductId(params)
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+
+	productPatchRequest := web.ProductPatchRequest{Id: id, Type: mediaType, ExpectedVersions: readIfMatch(request)}
+	err = helper.ReadFromRequestBody(request, &productPatchRequest.Patch)
+	if err != nil {
+		exception.WriteError(writer, request, exception.NewValidationError("Malformed JSON request body"))
+		return
+	}
+
+	productResponse, err := controller.ProductService.Patch(request.Context(), productPatchRequest)
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
 
-	productResponse := controller.ProductService.Update(request.Context(), productUpdateRequest)
 	webResponse := web.WebResponse{
 		Code:    http.StatusOK,
 		Error:   false,
 		Message: "Update product successfully",
-		Data:    productResponse,
+		Data:    versioned(request, productResponse),
 	}
 
+	writer.Header().Set("ETag", productETag(request, productResponse))
+
 	helper.WriteToResponseBody(writer, webResponse)
 }
 
 func (controller *productControllerImpl) Delete(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
-	productId := params.ByName("productId")
-	id, err := strconv.Atoi(productId)
-	helper.PanicIfError(err)
+	id, err := readProductId(params)
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+
+	productDeleteRequest := web.ProductDeleteRequest{Id: id, ExpectedVersions: readIfMatch(request)}
+	err = controller.ProductService.Delete(request.Context(), productDeleteRequest)
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
 
-	controller.ProductService.Delete(request.Context(), id)
 	webResponse := web.WebResponse{
 		Code:    http.StatusOK,
 		Error:   false,
@@ -74,29 +151,206 @@ func (controller *productControllerImpl) Delete(writer http.ResponseWriter, requ
 }
 
 func (controller *productControllerImpl) FindById(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
-	productId := params.ByName("productId")
-	id, err := strconv.Atoi(productId)
-	helper.PanicIfError(err)
+	id, err := readProductId(params)
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+	if request.URL.Query().Has("as_of") {
+		controller.findByIdAsOf(writer, request, id)
+		return
+	}
+
+	productResponse, err := controller.ProductService.FindById(request.Context(), id)
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+
+	etag := productETag(request, productResponse)
+	writer.Header().Set("ETag", etag)
+	if helper.MatchesETag(request.Header.Get("If-None-Match"), etag) {
+		writer.WriteHeader(http.StatusNotModified)
+		return
+	}
 
-	productResponse := controller.ProductService.FindById(request.Context(), id)
 	webResponse := web.WebResponse{
 		Code:    http.StatusOK,
 		Error:   false,
 		Message: "Successfully retrieved a single product",
-		Data:    productResponse,
+		Data:    versioned(request, productResponse),
 	}
 
 	helper.WriteToResponseBody(writer, webResponse)
 }
 
 func (controller *productControllerImpl) FindAll(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
-	productResponse := controller.ProductService.FindAll(request.Context())
+	productFindAllRequest, err := readProductFindAllRequest(request.URL.Query())
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+
+	productListResponse, err := controller.ProductService.FindAll(request.Context(), productFindAllRequest)
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+
+	if productListResponse.Pagination != nil {
+		helper.PaginationLinks(request.URL, productListResponse.Pagination)
+	}
+
+	webResponse := web.WebResponse{
+		Code:       http.StatusOK,
+		Error:      false,
+		Message:    "Successfully retrieved all products",
+		Data:       versioned(request, productListResponse.Products),
+		Pagination: productListResponse.Pagination,
+		NextCursor: productListResponse.NextCursor,
+	}
+
+	helper.WriteToResponseBody(writer, webResponse)
+}
+
+// Bulk answers 200 when every operation was applied, 207 when a best-effort
+// batch skipped some and 422 when an all-or-nothing batch was rolled back.
+// Each result carries the status its operation would have had on its own.
+func (controller *productControllerImpl) Bulk(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	productBulkRequest := web.ProductBulkRequest{}
+	err := helper.ReadFromRequestBody(request, &productBulkRequest)
+	if err != nil {
+		exception.WriteError(writer, request, exception.NewValidationError("Malformed JSON request body"))
+		return
+	}
+
+	productBulkResponse, err := controller.ProductService.Bulk(request.Context(), productBulkRequest)
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+
+	failed := 0
+	for i := range productBulkResponse.Results {
+		result := &productBulkResponse.Results[i]
+		switch {
+		case result.Err != nil:
+			problem := exception.Problem(request, result.Err)
+			result.Status = problem.Status
+			result.Error = &problem
+			failed++
+		case !productBulkResponse.Committed:
+			result.Status = http.StatusFailedDependency
+		case result.Op == web.BulkOpCreate:
+			result.Status = http.StatusCreated
+		default:
+			result.Status = http.StatusOK
+		}
+	}
+
 	webResponse := web.WebResponse{
 		Code:    http.StatusOK,
 		Error:   false,
-		Message: "Successfully retrieved all products",
-		Data:    productResponse,
+		Message: "Bulk operations applied successfully",
+		Data:    versioned(request, productBulkResponse),
 	}
+	switch {
+	case !productBulkResponse.Committed:
+		webResponse.Code = http.StatusUnprocessableEntity
+		webResponse.Error = true
+		webResponse.Message = "Bulk operations rolled back, no operation was applied"
+	case failed != 0:
+		webResponse.Code = http.StatusMultiStatus
+		webResponse.Message = "Bulk operations applied partially"
+	}
+
+	writer.WriteHeader(webResponse.Code)
 
 	helper.WriteToResponseBody(writer, webResponse)
 }
+
+func readProductId(params httprouter.Params) (int, error) {
+	id, err := strconv.Atoi(params.ByName("productId"))
+	if err != nil {
+		return 0, exception.NewValidationError("productId must be an integer")
+	}
+	return id, nil
+}
+
+// readIfMatch returns the versions an If-Match header accepts, or nil when
+// any version will do. Tags that name no version are kept as version 0, which
+// no product has, so the precondition still fails.
+func readIfMatch(request *http.Request) []int {
+	header := request.Header.Get("If-Match")
+	if header == "" {
+		return nil
+	}
+
+	versions, any := helper.ParseETags(header)
+	if any {
+		return nil
+	}
+	if len(versions) == 0 {
+		return []int{0}
+	}
+	return versions
+}
+
+// productETag tags a product as sent to request. A price schedule falling due
+// changes the price shown without a new version, so the tag carries the price
+// too, as the API version renders it: v1 bodies differ from v2 ones and get
+// tags of their own.
+func productETag(request *http.Request, productResponse web.ProductResponse) string {
+	if helper.ApiVersion(request.Context()) == helper.ApiVersion1 {
+		return helper.ETag(productResponse.Version, strconv.FormatInt(productResponse.Price.Major(), 10)+"-v1")
+	}
+	return helper.ETag(productResponse.Version, productResponse.Price.Decimal()+"-"+productResponse.Price.Currency)
+}
+
+func readProductFindAllRequest(query url.Values) (web.ProductFindAllRequest, error) {
+	request := web.ProductFindAllRequest{
+		Sort:         query.Get("sort"),
+		MinPrice:     query.Get("min_price"),
+		MaxPrice:     query.Get("max_price"),
+		Currency:     query.Get("currency"),
+		NameContains: query.Get("name_contains"),
+		Mode:         query.Get("mode"),
+		Cursor:       query.Get("cursor"),
+	}
+
+	var err error
+	if request.Page, err = queryInt(query, "page"); err != nil {
+		return request, err
+	}
+	if request.PerPage, err = queryInt(query, "per_page"); err != nil {
+		return request, err
+	}
+	if request.Limit, err = queryInt(query, "limit"); err != nil {
+		return request, err
+	}
+	if request.Offset, err = queryInt(query, "offset"); err != nil {
+		return request, err
+	}
+	return request, nil
+}
+
+func queryInt(query url.Values, key string) (int, error) {
+	value, err := queryIntPointer(query, key)
+	if err != nil || value == nil {
+		return 0, err
+	}
+	return *value, nil
+}
+
+func queryIntPointer(query url.Values, key string) (*int, error) {
+	raw := query.Get(key)
+	if raw == "" {
+		return nil, nil
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return nil, exception.NewValidationError(key + " must be an integer")
+	}
+	return &value, nil
+}
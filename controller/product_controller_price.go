@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"bubblevy/restful-api/exception"
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/model/web"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func (controller *productControllerImpl) FindPriceHistory(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	id, err := readProductId(params)
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+
+	pricePointResponses, err := controller.ProductService.FindPriceHistory(request.Context(), id)
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+
+	webResponse := web.WebResponse{
+		Code:    http.StatusOK,
+		Error:   false,
+		Message: "Successfully retrieved the price history",
+		Data:    versioned(request, pricePointResponses),
+	}
+
+	helper.WriteToResponseBody(writer, webResponse)
+}
+
+func (controller *productControllerImpl) FindPriceSchedules(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	id, err := readProductId(params)
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+
+	priceScheduleResponses, err := controller.ProductService.FindPriceSchedules(request.Context(), id)
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+
+	webResponse := web.WebResponse{
+		Code:    http.StatusOK,
+		Error:   false,
+		Message: "Successfully retrieved the price schedules",
+		Data:    versioned(request, priceScheduleResponses),
+	}
+
+	helper.WriteToResponseBody(writer, webResponse)
+}
+
+func (controller *productControllerImpl) SchedulePrice(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	priceScheduleCreateRequest := web.PriceScheduleCreateRequest{}
+	err := helper.ReadFromRequestBody(request, &priceScheduleCreateRequest)
+	if err != nil {
+		exception.WriteError(writer, request, exception.NewValidationError("Malformed JSON request body"))
+		return
+	}
+
+	priceScheduleCreateRequest.ProductId, err = readProductId(params)
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+
+	priceScheduleResponse, err := controller.ProductService.SchedulePrice(request.Context(), priceScheduleCreateRequest)
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+
+	webResponse := web.WebResponse{
+		Code:    http.StatusCreated,
+		Error:   false,
+		Message: "Schedule price successfully",
+		Data:    versioned(request, priceScheduleResponse),
+	}
+
+	writer.WriteHeader(http.StatusCreated)
+
+	helper.WriteToResponseBody(writer, webResponse)
+}
+
+func (controller *productControllerImpl) CancelPriceSchedule(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	id, err := readProductId(params)
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+	scheduleId, err := readScheduleId(params)
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+
+	priceScheduleResponse, err := controller.ProductService.CancelPriceSchedule(request.Context(), id, scheduleId)
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+
+	webResponse := web.WebResponse{
+		Code:    http.StatusOK,
+		Error:   false,
+		Message: "Cancel price schedule successfully",
+		Data:    versioned(request, priceScheduleResponse),
+	}
+
+	helper.WriteToResponseBody(writer, webResponse)
+}
+
+func readScheduleId(params httprouter.Params) (int, error) {
+	scheduleId, err := strconv.Atoi(params.ByName("scheduleId"))
+	if err != nil {
+		return 0, exception.NewValidationError("scheduleId must be an integer")
+	}
+	return scheduleId, nil
+}
@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"bubblevy/restful-api/exception"
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/model/web"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func (controller *productControllerImpl) FindRevisions(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	id, err := readProductId(params)
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+
+	productRevisionFindAllRequest := web.ProductRevisionFindAllRequest{Id: id}
+	query := request.URL.Query()
+	productRevisionFindAllRequest.Page, err = queryInt(query, "page")
+	if err == nil {
+		productRevisionFindAllRequest.PerPage, err = queryInt(query, "per_page")
+	}
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+
+	productRevisionListResponse, err := controller.ProductService.FindRevisions(request.Context(), productRevisionFindAllRequest)
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+
+	helper.PaginationLinks(request.URL, productRevisionListResponse.Pagination)
+
+	webResponse := web.WebResponse{
+		Code:       http.StatusOK,
+		Error:      false,
+		Message:    "Successfully retrieved the product revisions",
+		Data:       versioned(request, productRevisionListResponse.Revisions),
+		Pagination: productRevisionListResponse.Pagination,
+	}
+
+	helper.WriteToResponseBody(writer, webResponse)
+}
+
+func (controller *productControllerImpl) FindRevision(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	id, err := readProductId(params)
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+	revision, err := readRevision(params)
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+
+	productRevisionResponse, err := controller.ProductService.FindRevision(request.Context(), id, revision)
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+
+	webResponse := web.WebResponse{
+		Code:    http.StatusOK,
+		Error:   false,
+		Message: "Successfully retrieved a product revision",
+		Data:    versioned(request, productRevisionResponse),
+	}
+
+	helper.WriteToResponseBody(writer, webResponse)
+}
+
+func (controller *productControllerImpl) Revert(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	id, err := readProductId(params)
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+	revision, err := readRevision(params)
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+
+	productRevertRequest := web.ProductRevertRequest{Id: id, Revision: revision, ExpectedVersions: readIfMatch(request)}
+	productResponse, err := controller.ProductService.Revert(request.Context(), productRevertRequest)
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+
+	webResponse := web.WebResponse{
+		Code:    http.StatusOK,
+		Error:   false,
+		Message: "Revert product successfully",
+		Data:    versioned(request, productResponse),
+	}
+
+	writer.Header().Set("ETag", productETag(request, productResponse))
+
+	helper.WriteToResponseBody(writer, webResponse)
+}
+
+// findByIdAsOf answers GET /api/products/:productId?as_of=. A past state is
+// not something a write can be conditional on, so it carries no ETag.
+func (controller *productControllerImpl) findByIdAsOf(writer http.ResponseWriter, request *http.Request, id int) {
+	asOf, err := queryTime(request.URL.Query(), "as_of")
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+
+	productResponse, err := controller.ProductService.FindByIdAsOf(request.Context(), id, *asOf)
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+
+	webResponse := web.WebResponse{
+		Code:    http.StatusOK,
+		Error:   false,
+		Message: "Successfully retrieved a single product",
+		Data:    versioned(request, productResponse),
+	}
+
+	helper.WriteToResponseBody(writer, webResponse)
+}
+
+func readRevision(params httprouter.Params) (int, error) {
+	revision, err := strconv.Atoi(params.ByName("revision"))
+	if err != nil {
+		return 0, exception.NewValidationError("revision must be an integer")
+	}
+	return revision, nil
+}
@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"bubblevy/restful-api/exception"
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/model/web"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// transferContentTypes maps each import and export format to its media type.
+var transferContentTypes = map[string]string{
+	web.TransferFormatCSV:    "text/csv; charset=utf-8",
+	web.TransferFormatNDJSON: "application/x-ndjson",
+}
+
+// Import reads the upload from the request body, or from the file part of a
+// multipart form. The format comes from the format parameter, else from the
+// content type or file name of the upload. It answers 200 when every row was
+// imported and 207 when some were skipped.
+func (controller *productControllerImpl) Import(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	productImportRequest := web.ProductImportRequest{
+		Format: request.URL.Query().Get("format"),
+		Key:    request.URL.Query().Get("key"),
+		Body:   request.Body,
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(request.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		part, err := readUploadPart(request)
+		if err != nil {
+			exception.WriteError(writer, request, err)
+			return
+		}
+		defer part.Close()
+
+		productImportRequest.Body = part
+		mediaType, _, _ = mime.ParseMediaType(part.Header.Get("Content-Type"))
+		if productImportRequest.Format == "" {
+			productImportRequest.Format = formatOfFileName(part.FileName())
+		}
+	}
+	if productImportRequest.Format == "" {
+		productImportRequest.Format = formatOfMediaType(mediaType)
+	}
+
+	productImportResponse, err := controller.ProductService.Import(request.Context(), productImportRequest)
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+
+	for i := range productImportResponse.Errors {
+		rowError := &productImportResponse.Errors[i]
+		problem := exception.Problem(request, rowError.Err)
+		rowError.Status = problem.Status
+		rowError.Error = &problem
+	}
+
+	webResponse := web.WebResponse{
+		Code:    http.StatusOK,
+		Error:   false,
+		Message: "Import products successfully",
+		Data:    productImportResponse,
+	}
+	if productImportResponse.Failed != 0 {
+		webResponse.Code = http.StatusMultiStatus
+		webResponse.Message = "Import products partially, some rows were skipped"
+	}
+
+	writer.WriteHeader(webResponse.Code)
+
+	helper.WriteToResponseBody(writer, webResponse)
+}
+
+// Export streams the catalog as it is read. Errors found before the first
+// byte are answered as usual; later ones can only cut the response short.
+func (controller *productControllerImpl) Export(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	productExportRequest := web.ProductExportRequest{Format: request.URL.Query().Get("format")}
+	if productExportRequest.Format == "" {
+		productExportRequest.Format = web.TransferFormatCSV
+	}
+
+	stream := &streamWriter{writer: writer, prepare: func(header http.Header) {
+		header.Set("Content-Type", transferContentTypes[productExportRequest.Format])
+		header.Set("Content-Disposition", `attachment; filename="products.`+productExportRequest.Format+`"`)
+	}}
+
+	err := controller.ProductService.Export(request.Context(), productExportRequest, stream)
+	if err != nil && !stream.started {
+		exception.WriteError(writer, request, err)
+		return
+	}
+	if err != nil {
+		log.Printf("export aborted serving %s %s (request %s): %v", request.Method, request.URL.Path, helper.RequestId(request.Context()), err)
+		// drops the connection, so the client cannot take the partial body for a complete one
+		panic(http.ErrAbortHandler)
+	}
+
+	if !stream.started {
+		stream.prepare(writer.Header())
+		writer.WriteHeader(http.StatusOK)
+	}
+}
+
+// streamWriter sets the response headers on the first write, so a request
+// that fails before producing output can still be answered with an error.
+type streamWriter struct {
+	writer  http.ResponseWriter
+	prepare func(header http.Header)
+	started bool
+}
+
+func (stream *streamWriter) Write(data []byte) (int, error) {
+	if !stream.started {
+		stream.started = true
+		stream.prepare(stream.writer.Header())
+	}
+	return stream.writer.Write(data)
+}
+
+// readUploadPart returns the file part of a multipart upload without
+// buffering it.
+func readUploadPart(request *http.Request) (*multipart.Part, error) {
+	reader, err := request.MultipartReader()
+	if err != nil {
+		return nil, exception.NewValidationError("Malformed multipart request body")
+	}
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, exception.NewValidationError("the multipart upload has no file part")
+		}
+		if err != nil {
+			return nil, exception.NewValidationError("Malformed multipart request body")
+		}
+		if part.FileName() != "" {
+			return part, nil
+		}
+		part.Close()
+	}
+}
+
+func formatOfFileName(fileName string) string {
+	switch strings.ToLower(path.Ext(fileName)) {
+	case ".csv":
+		return web.TransferFormatCSV
+	case ".ndjson", ".jsonl":
+		return web.TransferFormatNDJSON
+	}
+	return ""
+}
+
+func formatOfMediaType(mediaType string) string {
+	switch mediaType {
+	case "text/csv":
+		return web.TransferFormatCSV
+	case "application/x-ndjson", "application/ndjson", "application/jsonl":
+		return web.TransferFormatNDJSON
+	}
+	return ""
+}
@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"bubblevy/restful-api/exception"
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/model/web"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func (controller *productControllerImpl) FindTrash(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	productFindAllRequest, err := readProductFindAllRequest(request.URL.Query())
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+
+	productListResponse, err := controller.ProductService.FindTrash(request.Context(), productFindAllRequest)
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+
+	if productListResponse.Pagination != nil {
+		helper.PaginationLinks(request.URL, productListResponse.Pagination)
+	}
+
+	webResponse := web.WebResponse{
+		Code:       http.StatusOK,
+		Error:      false,
+		Message:    "Successfully retrieved the deleted products",
+		Data:       versioned(request, productListResponse.Products),
+		Pagination: productListResponse.Pagination,
+		NextCursor: productListResponse.NextCursor,
+	}
+
+	helper.WriteToResponseBody(writer, webResponse)
+}
+
+func (controller *productControllerImpl) Restore(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	id, err := readProductId(params)
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+
+	productRestoreRequest := web.ProductRestoreRequest{Id: id, ExpectedVersions: readIfMatch(request)}
+	productResponse, err := controller.ProductService.Restore(request.Context(), productRestoreRequest)
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+
+	webResponse := web.WebResponse{
+		Code:    http.StatusOK,
+		Error:   false,
+		Message: "Restore product successfully",
+		Data:    versioned(request, productResponse),
+	}
+
+	writer.Header().Set("ETag", productETag(request, productResponse))
+
+	helper.WriteToResponseBody(writer, webResponse)
+}
+
+func (controller *productControllerImpl) Purge(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	id, err := readProductId(params)
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+
+	productPurgeRequest := web.ProductPurgeRequest{Id: id, ExpectedVersions: readIfMatch(request)}
+	err = controller.ProductService.Purge(request.Context(), productPurgeRequest)
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
+
+	webResponse := web.WebResponse{
+		Code:    http.StatusOK,
+		Error:   false,
+		Message: "Purge product successfully",
+	}
+
+	helper.WriteToResponseBody(writer, webResponse)
+}
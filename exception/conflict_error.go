@@ -0,0 +1,13 @@
+package exception
+
+type ConflictError struct {
+	Message string
+}
+
+func NewConflictError(message string) ConflictError {
+	return ConflictError{Message: message}
+}
+
+func (e ConflictError) Error() string {
+	return e.Message
+}
@@ -3,73 +3,146 @@ package exception
 import (
 	"bubblevy/restful-api/helper"
 	"bubblevy/restful-api/model/web"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
-
-	"github.com/go-playground/validator/v10"
+	"runtime/debug"
 )
 
+// ErrorHandler is the router's last-resort PanicHandler. Handlers report
+// failures through WriteError, so reaching this means a bug. Only
+// http.ErrAbortHandler is deliberate; it is passed on for net/http to drop the
+// connection of a response that is already under way.
 func ErrorHandler(writer http.ResponseWriter, request *http.Request, err interface{}) {
-	if notFoundError(writer, request, err) {
-		return
+	if err == http.ErrAbortHandler {
+		panic(err)
 	}
 
-	if validaionError(writer, request, err) {
-		return
-	}
+	log.Printf("panic serving %s %s (request %s): %v\n%s", request.Method, request.URL.Path, helper.RequestId(request.Context()), err, debug.Stack())
 
-	internalServerError(writer, request, err)
+	WriteError(writer, request, NewInternalError(fmt.Errorf("%v", err)))
 }
 
-func notFoundError(writer http.ResponseWriter, _ *http.Request, err interface{}) bool {
-	exception, ok := err.(NotFoundError)
-	if ok {
-		writer.Header().Set("Content-Type", "application/json")
-		writer.WriteHeader(http.StatusNotFound)
+// WriteError maps an error returned by the service layer to its HTTP response,
+// as application/problem+json unless the client asked for the legacy envelope.
+func WriteError(writer http.ResponseWriter, request *http.Request, err error) {
+	problem := toProblem(request, err)
 
-		webResponse := web.WebResponse{
-			Code:    http.StatusNotFound,
-			Error:   true,
-			Message: "Data not found!",
-			Data:    exception.Error,
-		}
+	if !acceptsLegacyEnvelope(request) {
+		writer.Header().Set("Content-Type", "application/problem+json")
+		writer.WriteHeader(problem.Status)
 
-		helper.WriteToResponseBody(writer, webResponse)
-		return true
-	} else {
-		return false
+		encoder := json.NewEncoder(writer)
+		helper.PanicIfError(encoder.Encode(problem))
+		return
 	}
-}
 
-func validaionError(writer http.ResponseWriter, _ *http.Request, err interface{}) bool {
-	exception, ok := err.(validator.ValidationErrors)
-	if ok {
-		writer.Header().Set("Content-Type", "application/json")
-		writer.WriteHeader(http.StatusBadRequest)
+	webResponse := web.WebResponse{
+		Code:    problem.Status,
+		Error:   true,
+		Message: problem.Title,
+		Data:    legacyData(problem, err),
+	}
 
-		webResponse := web.WebResponse{
-			Code:    http.StatusBadRequest,
-			Error:   true,
-			Message: "Invalid data request!",
-			Data:    exception.Error(),
-		}
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(problem.Status)
 
-		helper.WriteToResponseBody(writer, webResponse)
-		return true
-	} else {
-		return false
-	}
+	helper.WriteToResponseBody(writer, webResponse)
 }
 
-func internalServerError(writer http.ResponseWriter, _ *http.Request, err interface{}) {
-	writer.Header().Set("Content-Type", "application/json")
-	writer.WriteHeader(http.StatusInternalServerError)
+// Problem describes err as WriteError would, for responses that report the
+// outcome of several operations at once.
+func Problem(request *http.Request, err error) web.ProblemResponse {
+	return toProblem(request, err)
+}
 
-	webResponse := web.WebResponse{
-		Code:    http.StatusInternalServerError,
-		Error:   true,
-		Message: "Internal server error!",
-		Data:    err,
+func toProblem(request *http.Request, err error) web.ProblemResponse {
+	var notFound NotFoundError
+	var validation ValidationError
+	var conflict ConflictError
+	var unauthorized UnauthorizedError
+	var forbidden ForbiddenError
+	var unsupportedMediaType UnsupportedMediaTypeError
+	var preconditionFailed PreconditionFailedError
+	var unprocessableEntity UnprocessableEntityError
+
+	problem := web.ProblemResponse{Instance: request.URL.RequestURI()}
+	switch {
+	case errors.As(err, &notFound):
+		problem.Type = "/problems/not-found"
+		problem.Title = "Data not found!"
+		problem.Status = http.StatusNotFound
+		problem.Detail = notFound.Message
+	case errors.As(err, &validation):
+		problem.Type = "/problems/validation"
+		problem.Title = "Invalid data request!"
+		problem.Status = http.StatusBadRequest
+		problem.Detail = validation.Message
+		if len(validation.Fields) != 0 {
+			problem.Detail = "One or more fields are invalid."
+			problem.Errors = fieldErrors(validation.Fields)
+		}
+	case errors.As(err, &conflict):
+		problem.Type = "/problems/conflict"
+		problem.Title = "Data conflict!"
+		problem.Status = http.StatusConflict
+		problem.Detail = conflict.Message
+	case errors.As(err, &unauthorized):
+		problem.Type = "/problems/unauthorized"
+		problem.Title = "Unauthorized!"
+		problem.Status = http.StatusUnauthorized
+		problem.Detail = unauthorized.Message
+	case errors.As(err, &forbidden):
+		problem.Type = "/problems/forbidden"
+		problem.Title = "Forbidden!"
+		problem.Status = http.StatusForbidden
+		problem.Detail = forbidden.Message
+	case errors.As(err, &unsupportedMediaType):
+		problem.Type = "/problems/unsupported-media-type"
+		problem.Title = "Unsupported media type!"
+		problem.Status = http.StatusUnsupportedMediaType
+		problem.Detail = unsupportedMediaType.Message
+	case errors.As(err, &preconditionFailed):
+		problem.Type = "/problems/precondition-failed"
+		problem.Title = "Precondition failed!"
+		problem.Status = http.StatusPreconditionFailed
+		problem.Detail = preconditionFailed.Message
+	case errors.As(err, &unprocessableEntity):
+		problem.Type = "/problems/unprocessable-entity"
+		problem.Title = "Unprocessable request!"
+		problem.Status = http.StatusUnprocessableEntity
+		problem.Detail = unprocessableEntity.Message
+	default:
+		// the cause may carry driver messages, so it is only logged under the
+		// correlation id the client can quote
+		problem.Type = "/problems/internal"
+		problem.Title = "Internal server error!"
+		problem.Status = http.StatusInternalServerError
+		problem.CorrelationId = helper.RequestId(request.Context())
+		if problem.CorrelationId == "" {
+			problem.CorrelationId = helper.NewRequestId()
+		}
+		problem.Detail = "An unexpected error occurred. Quote the correlation id when reporting it."
+		log.Printf("internal error serving %s %s (request %s): %v", request.Method, request.URL.Path, problem.CorrelationId, err)
 	}
+	return problem
+}
 
-	helper.WriteToResponseBody(writer, webResponse)
+// legacyData keeps the Data payload older clients parsed before problem+json.
+func legacyData(problem web.ProblemResponse, err error) interface{} {
+	var validation ValidationError
+	switch {
+	case problem.Status == http.StatusUnauthorized:
+		return nil
+	case problem.Status == http.StatusForbidden:
+		return nil
+	case problem.Status == http.StatusInternalServerError:
+		return map[string]string{"correlation_id": problem.CorrelationId}
+	case errors.As(err, &validation):
+		return validation.Message
+	default:
+		return problem.Detail
+	}
 }
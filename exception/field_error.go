@@ -0,0 +1,48 @@
+package exception
+
+import (
+	"bubblevy/restful-api/model/web"
+	"reflect"
+
+	"github.com/go-playground/validator/v10"
+)
+
+func fieldErrors(fields validator.ValidationErrors) []web.FieldErrorResponse {
+	var errors []web.FieldErrorResponse
+	for _, field := range fields {
+		errors = append(errors, web.FieldErrorResponse{
+			Field:   field.Field(),
+			Rule:    field.Tag(),
+			Param:   field.Param(),
+			Message: fieldMessage(field),
+		})
+	}
+	return errors
+}
+
+func fieldMessage(field validator.FieldError) string {
+	name := field.Field()
+	unit := ""
+	if field.Kind() == reflect.String {
+		unit = " characters"
+	}
+
+	switch field.Tag() {
+	case "required":
+		return name + " is required"
+	case "min":
+		return name + " must be at least " + field.Param() + unit
+	case "max":
+		return name + " must be at most " + field.Param() + unit
+	case "oneof":
+		return name + " must be one of: " + field.Param()
+	case "gt":
+		return name + " must be greater than " + field.Param()
+	case "gte":
+		return name + " must be greater than or equal to " + field.Param()
+	case "lte":
+		return name + " must be less than or equal to " + field.Param()
+	default:
+		return name + " failed the " + field.Tag() + " rule"
+	}
+}
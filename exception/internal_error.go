@@ -0,0 +1,19 @@
+package exception
+
+// InternalError wraps a failure the caller cannot act on, such as a database
+// error. The cause is kept for logging and errors.Is/As.
+type InternalError struct {
+	Err error
+}
+
+func NewInternalError(err error) InternalError {
+	return InternalError{Err: err}
+}
+
+func (e InternalError) Error() string {
+	return e.Err.Error()
+}
+
+func (e InternalError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,36 @@
+package exception
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// acceptsLegacyEnvelope reports whether the client prefers plain
+// application/json over application/problem+json. Clients that send no Accept
+// header or a wildcard get problem+json.
+func acceptsLegacyEnvelope(request *http.Request) bool {
+	legacyQuality, problemQuality := 0.0, 0.0
+	for _, part := range strings.Split(request.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+				quality = parsed
+			}
+		}
+
+		switch mediaType {
+		case "application/json":
+			legacyQuality = max(legacyQuality, quality)
+		case "application/problem+json":
+			problemQuality = max(problemQuality, quality)
+		}
+	}
+	return legacyQuality > problemQuality
+}
@@ -0,0 +1,13 @@
+package exception
+
+type PreconditionFailedError struct {
+	Message string
+}
+
+func NewPreconditionFailedError(message string) PreconditionFailedError {
+	return PreconditionFailedError{Message: message}
+}
+
+func (e PreconditionFailedError) Error() string {
+	return e.Message
+}
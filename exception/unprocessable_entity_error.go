@@ -0,0 +1,13 @@
+package exception
+
+type UnprocessableEntityError struct {
+	Message string
+}
+
+func NewUnprocessableEntityError(message string) UnprocessableEntityError {
+	return UnprocessableEntityError{Message: message}
+}
+
+func (e UnprocessableEntityError) Error() string {
+	return e.Message
+}
@@ -0,0 +1,13 @@
+package exception
+
+type UnsupportedMediaTypeError struct {
+	Message string
+}
+
+func NewUnsupportedMediaTypeError(message string) UnsupportedMediaTypeError {
+	return UnsupportedMediaTypeError{Message: message}
+}
+
+func (e UnsupportedMediaTypeError) Error() string {
+	return e.Message
+}
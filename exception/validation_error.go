@@ -0,0 +1,42 @@
+package exception
+
+import "github.com/go-playground/validator/v10"
+
+// ValidationError reports a request the caller has to fix. Fields holds the
+// validator failures when the error comes from struct validation.
+type ValidationError struct {
+	Message string
+	Fields  validator.ValidationErrors
+}
+
+func NewValidationError(message string) ValidationError {
+	return ValidationError{Message: message}
+}
+
+// FromValidator wraps the result of validator.Struct, keeping the per-field
+// failures. Errors that are not validation failures are returned as internal.
+func FromValidator(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	fields, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return NewInternalError(err)
+	}
+	return ValidationError{Message: fields.Error(), Fields: fields}
+}
+
+func (e ValidationError) Error() string {
+	return e.Message
+}
+
+// FieldMessages describes every failed field in the words used for problem
+// details, for callers that report errors outside an HTTP response.
+func (e ValidationError) FieldMessages() []string {
+	var messages []string
+	for _, field := range e.Fields {
+		messages = append(messages, fieldMessage(field))
+	}
+	return messages
+}
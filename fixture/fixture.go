@@ -0,0 +1,87 @@
+package fixture
+
+import (
+	"bubblevy/restful-api/model/domain"
+	"bubblevy/restful-api/money"
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed products.yaml
+var sampleProducts []byte
+
+// File is the content of a fixture file.
+type File struct {
+	Products []Product `yaml:"products" json:"products"`
+}
+
+// Product is a fixture product. Its price is in whole rupiah, as prices were
+// before they had a currency.
+type Product struct {
+	ProductName string `yaml:"product_name" json:"product_name"`
+	Price       int    `yaml:"price" json:"price"`
+}
+
+// Sample returns the sample catalogue shipped with the binary.
+func Sample() File {
+	file, err := Parse(sampleProducts, ".yaml")
+	if err != nil {
+		panic(err)
+	}
+	return file
+}
+
+// ReadFile reads a YAML or JSON fixture file, telling the format by extension.
+func ReadFile(path string) (File, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return File{}, err
+	}
+
+	file, err := Parse(content, filepath.Ext(path))
+	if err != nil {
+		return File{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return file, nil
+}
+
+// Parse decodes fixtures in the format named by extension. Unknown keys are
+// rejected so a typo does not silently load empty values.
+func Parse(content []byte, extension string) (File, error) {
+	file := File{}
+	switch strings.ToLower(extension) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(content))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&file); err != nil {
+			return File{}, err
+		}
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&file); err != nil {
+			return File{}, err
+		}
+	default:
+		return File{}, errors.New("unsupported fixture format: " + extension)
+	}
+	return file, nil
+}
+
+func (file File) DomainProducts() []domain.Product {
+	products := make([]domain.Product, 0, len(file.Products))
+	for _, product := range file.Products {
+		// a price out of range is left zero, which loading rejects
+		price, _ := money.Legacy(int64(product.Price))
+		products = append(products, domain.Product{ProductName: product.ProductName, Price: price})
+	}
+	return products
+}
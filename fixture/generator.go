@@ -0,0 +1,107 @@
+package fixture
+
+import (
+	"bubblevy/restful-api/model/domain"
+	"bubblevy/restful-api/money"
+	"math"
+	"math/rand/v2"
+	"strings"
+)
+
+// category groups products that share name parts and a price range, in rupiah.
+type category struct {
+	brands   []string
+	items    []string
+	variants []string
+	sizes    []string
+	minPrice float64
+	maxPrice float64
+}
+
+var categories = []category{
+	{
+		brands:   []string{"Chitato", "Qtela", "Taro", "Lays", "Potabee"},
+		items:    []string{"Keripik Kentang", "Keripik Singkong", "Snack Jagung"},
+		variants: []string{"Original", "Balado", "Keju", "Rumput Laut", "Sapi Panggang"},
+		sizes:    []string{"35 g", "68 g", "120 g"},
+		minPrice: 3000,
+		maxPrice: 25000,
+	},
+	{
+		brands:   []string{"Ultramilk", "Frisian Flag", "Indomilk", "Teh Pucuk", "Good Day"},
+		items:    []string{"Susu UHT", "Teh Botol", "Kopi Susu", "Minuman Sereal"},
+		variants: []string{"Original", "Cokelat", "Stroberi", "Melati", "Gula Aren"},
+		sizes:    []string{"200 ml", "250 ml", "1 L"},
+		minPrice: 3500,
+		maxPrice: 22000,
+	},
+	{
+		brands:   []string{"Indomie", "Mie Sedaap", "Sarimi", "Pop Mie", "Lemonilo"},
+		items:    []string{"Mie Goreng", "Mie Kuah", "Mie Cup"},
+		variants: []string{"Original", "Rendang", "Soto", "Ayam Bawang", "Kari Ayam"},
+		sizes:    []string{"", "Jumbo"},
+		minPrice: 2500,
+		maxPrice: 8000,
+	},
+	{
+		brands:   []string{"Silverqueen", "Beng-Beng", "Campina", "Walls", "Nabati"},
+		items:    []string{"Cokelat Batang", "Wafer", "Es Krim", "Permen"},
+		variants: []string{"Almond", "Vanila", "Matcha", "Mint", "Kacang"},
+		sizes:    []string{"", "Mini", "Family Pack"},
+		minPrice: 1000,
+		maxPrice: 45000,
+	},
+}
+
+// Generator makes up plausible products. The same seed always yields the same
+// products, so a load test can be repeated against identical data.
+type Generator struct {
+	random *rand.Rand
+}
+
+func NewGenerator(seed uint64) *Generator {
+	return &Generator{random: rand.New(rand.NewPCG(seed, seed))}
+}
+
+func (generator *Generator) Product() domain.Product {
+	category := categories[generator.random.IntN(len(categories))]
+
+	parts := []string{
+		pick(generator.random, category.brands),
+		pick(generator.random, category.items),
+		pick(generator.random, category.variants),
+	}
+	if size := pick(generator.random, category.sizes); size != "" {
+		parts = append(parts, size)
+	}
+
+	return domain.Product{
+		ProductName: strings.Join(parts, " "),
+		Price:       generator.price(category.minPrice, category.maxPrice),
+	}
+}
+
+func (generator *Generator) Products(count int) []domain.Product {
+	products := make([]domain.Product, 0, count)
+	for i := 0; i < count; i++ {
+		products = append(products, generator.Product())
+	}
+	return products
+}
+
+// price draws log-uniformly between min and max, so cheap products are more
+// common than expensive ones as in a real catalogue, and rounds to Rp 500.
+func (generator *Generator) price(min float64, max float64) money.Money {
+	logPrice := math.Log(min) + generator.random.Float64()*(math.Log(max)-math.Log(min))
+	rupiah := int64(math.Round(math.Exp(logPrice)/500)) * 500
+	if rupiah < 500 {
+		rupiah = 500
+	}
+	// at most Rp 45000, far inside the range money.Legacy accepts
+	price, _ := money.Legacy(rupiah)
+	return price
+}
+
+func pick(random *rand.Rand, values []string) string {
+	return values[random.IntN(len(values))]
+}
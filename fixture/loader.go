@@ -0,0 +1,64 @@
+package fixture
+
+import (
+	"bubblevy/restful-api/exception"
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/model/domain"
+	"bubblevy/restful-api/model/web"
+	"bubblevy/restful-api/repository"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Loader writes fixtures straight through the repositories, so tests and load
+// generation can fill a database without going through authorization.
+type Loader struct {
+	TxManager         helper.TxManager
+	ProductRepository repository.ProductRepository
+	Validate          *validator.Validate
+}
+
+func NewLoader(txManager helper.TxManager, productRepository repository.ProductRepository, validate *validator.Validate) *Loader {
+	return &Loader{
+		TxManager:         txManager,
+		ProductRepository: productRepository,
+		Validate:          validate,
+	}
+}
+
+// LoadProducts saves products in one transaction and returns them with their
+// ids. Nothing is saved when one of them fails CheckProducts.
+func (loader *Loader) LoadProducts(ctx context.Context, products []domain.Product) (saved []domain.Product, err error) {
+	err = loader.CheckProducts(products)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := helper.BeginTx(ctx, loader.TxManager)
+	if err != nil {
+		return nil, err
+	}
+	defer helper.CommitOrRollback(tx, &err)
+
+	return loader.ProductRepository.SaveAll(ctx, tx, products)
+}
+
+// CheckProducts applies the rules of web.ProductCreateRequest to products and
+// reports the first one that breaks them by its position.
+func (loader *Loader) CheckProducts(products []domain.Product) error {
+	for i, product := range products {
+		request := web.ProductCreateRequest{ProductName: product.ProductName, Price: product.Price}
+		err := exception.FromValidator(loader.Validate.Struct(request))
+		if validationError, ok := err.(exception.ValidationError); ok {
+			message := strings.Join(validationError.FieldMessages(), ", ")
+			return exception.NewValidationError(fmt.Sprintf("product %d: %s", i+1, message))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
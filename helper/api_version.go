@@ -0,0 +1,30 @@
+package helper
+
+import "context"
+
+const (
+	// ApiVersion1 renders prices as integers in whole units, as the API did
+	// before prices had a currency, and reads them as money.DefaultCurrency.
+	// A product priced in another currency keeps its price when a v1 client
+	// sends back the units it was shown, and refuses any other. It is what
+	// clients that do not ask for a version get.
+	ApiVersion1 = 1
+	// ApiVersion2 renders prices as {"amount":"12.50","currency":"USD"}.
+	ApiVersion2 = 2
+)
+
+type apiVersionKey struct{}
+
+func WithApiVersion(ctx context.Context, version int) context.Context {
+	return context.WithValue(ctx, apiVersionKey{}, version)
+}
+
+// ApiVersion returns the API version the request asked for, ApiVersion1 when
+// it did not ask or outside of an HTTP request.
+func ApiVersion(ctx context.Context) int {
+	version, ok := ctx.Value(apiVersionKey{}).(int)
+	if !ok {
+		return ApiVersion1
+	}
+	return version
+}
@@ -0,0 +1,59 @@
+package helper
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// CursorCodec turns pagination state into opaque tokens signed with HMAC-SHA256,
+// so clients cannot forge or alter the position they resume from.
+type CursorCodec struct {
+	secret []byte
+}
+
+func NewCursorCodec(secret []byte) *CursorCodec {
+	return &CursorCodec{secret: secret}
+}
+
+func (codec *CursorCodec) Encode(payload interface{}) (string, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(data)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(codec.sign(encoded)), nil
+}
+
+func (codec *CursorCodec) Decode(cursor string, payload interface{}) error {
+	encoded, signature, found := strings.Cut(cursor, ".")
+	if !found {
+		return ErrInvalidCursor
+	}
+
+	expected, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, codec.sign(encoded)) {
+		return ErrInvalidCursor
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	if json.Unmarshal(data, payload) != nil {
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+func (codec *CursorCodec) sign(encoded string) []byte {
+	mac := hmac.New(sha256.New, codec.secret)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
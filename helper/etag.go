@@ -0,0 +1,49 @@
+package helper
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ETag renders a product version as a strong entity tag. representation
+// tells apart the bodies one version is sent as, such as the prices a price
+// schedule brings in without a new version; it may not contain '"'.
+func ETag(version int, representation string) string {
+	return `"` + strconv.Itoa(version) + "-" + representation + `"`
+}
+
+// ParseETags reads the versions named by the tags of an If-Match header,
+// which writes compare against the stored version. any reports the *
+// wildcard. If-Match compares strongly, so weak tags are skipped, as are
+// tags that are not versions; they can never match.
+func ParseETags(header string) (versions []int, any bool) {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			any = true
+			continue
+		}
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			continue
+		}
+		version, _, _ := strings.Cut(tag[1:len(tag)-1], "-")
+		number, err := strconv.Atoi(version)
+		if err == nil {
+			versions = append(versions, number)
+		}
+	}
+	return versions, any
+}
+
+// MatchesETag reports whether an If-None-Match header lists tag or the *
+// wildcard. If-None-Match compares weakly, so W/ is ignored on either side.
+func MatchesETag(header string, tag string) bool {
+	tag = strings.TrimPrefix(tag, "W/")
+	for _, listed := range strings.Split(header, ",") {
+		listed = strings.TrimSpace(listed)
+		if listed == "*" || strings.TrimPrefix(listed, "W/") == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -5,10 +5,9 @@ import (
 	"net/http"
 )
 
-func ReadFromRequestBody(request *http.Request, result interface{}) {
+func ReadFromRequestBody(request *http.Request, result interface{}) error {
 	decoder := json.NewDecoder(request.Body)
-	err := decoder.Decode(result)
-	PanicIfError(err)
+	return decoder.Decode(result)
 }
 
 func WriteToResponseBody(writer http.ResponseWriter, response interface{}) {
@@ -3,13 +3,16 @@ package helper
 import (
 	"bubblevy/restful-api/model/domain"
 	"bubblevy/restful-api/model/web"
+	"time"
 )
 
 func ToProductResponse(product domain.Product) web.ProductResponse {
 	return web.ProductResponse{
 		Id:          product.Id,
 		ProductName: product.ProductName,
-		Price:       product.Price,
+		Price:       product.EffectivePrice(time.Now()),
+		Version:     product.Version,
+		DeletedAt:   product.DeletedAt,
 	}
 }
 
@@ -21,3 +24,168 @@ func ToProductResponses(products []domain.Product) []web.ProductResponse {
 
 	return productResponses
 }
+
+func ToProductRevisionResponse(revision domain.ProductRevision) web.ProductRevisionResponse {
+	return web.ProductRevisionResponse{
+		ProductResponse: ToProductResponse(revision.Product),
+		RecordedAt:      revision.RecordedAt,
+	}
+}
+
+func ToProductRevisionResponses(revisions []domain.ProductRevision) []web.ProductRevisionResponse {
+	var productRevisionResponses []web.ProductRevisionResponse
+	for _, revision := range revisions {
+		productRevisionResponses = append(productRevisionResponses, ToProductRevisionResponse(revision))
+	}
+
+	return productRevisionResponses
+}
+
+func ToApiKeyResponse(apiKey domain.ApiKey) web.ApiKeyResponse {
+	return web.ApiKeyResponse{
+		Id:         apiKey.Id,
+		Name:       apiKey.Name,
+		Owner:      apiKey.Owner,
+		KeyPrefix:  apiKey.KeyPrefix,
+		Scopes:     apiKey.Scopes,
+		ExpiresAt:  apiKey.ExpiresAt,
+		LastUsedAt: apiKey.LastUsedAt,
+		RevokedAt:  apiKey.RevokedAt,
+		CreatedAt:  apiKey.CreatedAt,
+	}
+}
+
+func ToApiKeyResponses(apiKeys []domain.ApiKey) []web.ApiKeyResponse {
+	var apiKeyResponses []web.ApiKeyResponse
+	for _, apiKey := range apiKeys {
+		apiKeyResponses = append(apiKeyResponses, ToApiKeyResponse(apiKey))
+	}
+
+	return apiKeyResponses
+}
+
+func ToAuditEntryResponse(entry domain.AuditEntry) web.AuditEntryResponse {
+	return web.AuditEntryResponse{
+		Id:        entry.Id,
+		Actor:     entry.Actor,
+		Action:    entry.Action,
+		ProductId: entry.ProductId,
+		Before:    entry.Before,
+		After:     entry.After,
+		RequestId: entry.RequestId,
+		CreatedAt: entry.CreatedAt,
+	}
+}
+
+func ToAuditEntryResponses(entries []domain.AuditEntry) []web.AuditEntryResponse {
+	var auditEntryResponses []web.AuditEntryResponse
+	for _, entry := range entries {
+		auditEntryResponses = append(auditEntryResponses, ToAuditEntryResponse(entry))
+	}
+
+	return auditEntryResponses
+}
+
+func ToPriceScheduleResponse(schedule domain.PriceSchedule) web.PriceScheduleResponse {
+	return web.PriceScheduleResponse{
+		Id:            schedule.Id,
+		ProductId:     schedule.ProductId,
+		Price:         schedule.Price,
+		StartsAt:      schedule.StartsAt,
+		EndsAt:        schedule.EndsAt,
+		Status:        schedule.Status,
+		PreviousPrice: schedule.PreviousPrice,
+		CreatedAt:     schedule.CreatedAt,
+	}
+}
+
+func ToPriceScheduleResponses(schedules []domain.PriceSchedule) []web.PriceScheduleResponse {
+	var priceScheduleResponses []web.PriceScheduleResponse
+	for _, schedule := range schedules {
+		priceScheduleResponses = append(priceScheduleResponses, ToPriceScheduleResponse(schedule))
+	}
+
+	return priceScheduleResponses
+}
+
+// ToProductResponseV1 renders a product for v1 clients, whose integer price
+// is in whole units, rounded half to even.
+func ToProductResponseV1(product web.ProductResponse) web.ProductResponseV1 {
+	return web.ProductResponseV1{
+		Id:          product.Id,
+		ProductName: product.ProductName,
+		Price:       product.Price.Major(),
+		Version:     product.Version,
+		DeletedAt:   product.DeletedAt,
+	}
+}
+
+func ToProductResponsesV1(products []web.ProductResponse) []web.ProductResponseV1 {
+	var productResponses []web.ProductResponseV1
+	for _, product := range products {
+		productResponses = append(productResponses, ToProductResponseV1(product))
+	}
+
+	return productResponses
+}
+
+func ToProductRevisionResponsesV1(revisions []web.ProductRevisionResponse) []web.ProductRevisionResponseV1 {
+	var productRevisionResponses []web.ProductRevisionResponseV1
+	for _, revision := range revisions {
+		productRevisionResponses = append(productRevisionResponses, web.ProductRevisionResponseV1{
+			ProductResponseV1: ToProductResponseV1(revision.ProductResponse),
+			RecordedAt:        revision.RecordedAt,
+		})
+	}
+
+	return productRevisionResponses
+}
+
+func ToProductBulkResponseV1(bulk web.ProductBulkResponse) web.ProductBulkResponseV1 {
+	response := web.ProductBulkResponseV1{Mode: bulk.Mode, Committed: bulk.Committed}
+	for _, result := range bulk.Results {
+		resultV1 := web.ProductBulkResultV1{Index: result.Index, Op: result.Op, Status: result.Status, Error: result.Error}
+		if result.Data != nil {
+			data := ToProductResponseV1(*result.Data)
+			resultV1.Data = &data
+		}
+		response.Results = append(response.Results, resultV1)
+	}
+
+	return response
+}
+
+func ToPriceScheduleResponseV1(schedule web.PriceScheduleResponse) web.PriceScheduleResponseV1 {
+	response := web.PriceScheduleResponseV1{
+		Id:        schedule.Id,
+		ProductId: schedule.ProductId,
+		Price:     schedule.Price.Major(),
+		StartsAt:  schedule.StartsAt,
+		EndsAt:    schedule.EndsAt,
+		Status:    schedule.Status,
+		CreatedAt: schedule.CreatedAt,
+	}
+	if schedule.PreviousPrice != nil {
+		previousPrice := schedule.PreviousPrice.Major()
+		response.PreviousPrice = &previousPrice
+	}
+	return response
+}
+
+func ToPriceScheduleResponsesV1(schedules []web.PriceScheduleResponse) []web.PriceScheduleResponseV1 {
+	var priceScheduleResponses []web.PriceScheduleResponseV1
+	for _, schedule := range schedules {
+		priceScheduleResponses = append(priceScheduleResponses, ToPriceScheduleResponseV1(schedule))
+	}
+
+	return priceScheduleResponses
+}
+
+func ToPricePointResponsesV1(points []web.PricePointResponse) []web.PricePointResponseV1 {
+	pricePointResponses := []web.PricePointResponseV1{}
+	for _, point := range points {
+		pricePointResponses = append(pricePointResponses, web.PricePointResponseV1{Price: point.Price.Major(), From: point.From, To: point.To, Revision: point.Revision})
+	}
+
+	return pricePointResponses
+}
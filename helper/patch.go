@@ -0,0 +1,367 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidPatch reports a patch that is malformed or does not fit the
+	// document it is applied to.
+	ErrInvalidPatch = errors.New("invalid patch")
+	// ErrPatchTestFailed reports a JSON Patch whose test operation did not hold.
+	ErrPatchTestFailed = errors.New("patch test failed")
+)
+
+// MergePatch applies an RFC 7396 JSON Merge Patch to document.
+func MergePatch(document []byte, patch []byte) ([]byte, error) {
+	target, err := decodeJSON(document)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := decodeJSON(patch)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return json.Marshal(mergePatch(target, changes))
+}
+
+func mergePatch(target interface{}, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergePatch(targetObject[key], value)
+	}
+	return targetObject
+}
+
+type jsonPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  *string         `json:"path"`
+	From  *string         `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// JSONPatch applies an RFC 6902 JSON Patch to document. The operations are
+// applied in order and the patch fails as a whole: a failing test or an
+// operation that does not fit leaves nothing applied.
+func JSONPatch(document []byte, patch []byte) ([]byte, error) {
+	target, err := decodeJSON(document)
+	if err != nil {
+		return nil, err
+	}
+
+	var operations []jsonPatchOperation
+	decoder := json.NewDecoder(bytes.NewReader(patch))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&operations); err != nil {
+		return nil, fmt.Errorf("%w: a JSON Patch is an array of operations: %v", ErrInvalidPatch, err)
+	}
+
+	for i, operation := range operations {
+		target, err = applyJSONPatchOperation(target, operation)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s): %w", i, operation.Op, err)
+		}
+	}
+	return json.Marshal(target)
+}
+
+func applyJSONPatchOperation(document interface{}, operation jsonPatchOperation) (interface{}, error) {
+	if operation.Path == nil {
+		return nil, fmt.Errorf("%w: path is required", ErrInvalidPatch)
+	}
+	path, err := parseJSONPointer(*operation.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch operation.Op {
+	case "add", "replace", "test":
+		if operation.Value == nil {
+			return nil, fmt.Errorf("%w: value is required", ErrInvalidPatch)
+		}
+		value, err := decodeJSON(operation.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		switch operation.Op {
+		case "add":
+			return addJSONValue(document, path, value)
+		case "replace":
+			if len(path) == 0 {
+				return value, nil
+			}
+			document, err = removeJSONValue(document, path)
+			if err != nil {
+				return nil, err
+			}
+			return addJSONValue(document, path, value)
+		}
+
+		current, err := getJSONValue(document, path)
+		if err != nil {
+			return nil, err
+		}
+		if !jsonEqual(current, value) {
+			return nil, fmt.Errorf("%w: %s is %s", ErrPatchTestFailed, *operation.Path, encodeJSON(current))
+		}
+		return document, nil
+	case "remove":
+		return removeJSONValue(document, path)
+	case "move", "copy":
+		if operation.From == nil {
+			return nil, fmt.Errorf("%w: from is required", ErrInvalidPatch)
+		}
+		from, err := parseJSONPointer(*operation.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := getJSONValue(document, from)
+		if err != nil {
+			return nil, err
+		}
+
+		if operation.Op == "copy" {
+			value, _ = decodeJSON([]byte(encodeJSON(value)))
+			return addJSONValue(document, path, value)
+		}
+		if len(from) < len(path) && isJSONPointerPrefix(from, path) {
+			return nil, fmt.Errorf("%w: cannot move a value into itself", ErrInvalidPatch)
+		}
+		document, err = removeJSONValue(document, from)
+		if err != nil {
+			return nil, err
+		}
+		return addJSONValue(document, path, value)
+	default:
+		return nil, fmt.Errorf("%w: op must be one of: add remove replace move copy test", ErrInvalidPatch)
+	}
+}
+
+// parseJSONPointer splits an RFC 6901 JSON Pointer into its unescaped tokens.
+func parseJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: path %q must start with /", ErrInvalidPatch, pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+func isJSONPointerPrefix(prefix []string, path []string) bool {
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func getJSONValue(document interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch container := document.(type) {
+		case map[string]interface{}:
+			value, ok := container[token]
+			if !ok {
+				return nil, missingJSONValue(path)
+			}
+			document = value
+		case []interface{}:
+			index, err := jsonArrayIndex(token, len(container)-1)
+			if err != nil {
+				return nil, err
+			}
+			document = container[index]
+		default:
+			return nil, missingJSONValue(path)
+		}
+	}
+	return document, nil
+}
+
+func addJSONValue(document interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return changeJSONContainer(document, path, path, func(container interface{}, token string) (interface{}, error) {
+		switch container := container.(type) {
+		case map[string]interface{}:
+			container[token] = value
+			return container, nil
+		case []interface{}:
+			if token == "-" {
+				return append(container, value), nil
+			}
+			index, err := jsonArrayIndex(token, len(container))
+			if err != nil {
+				return nil, err
+			}
+			container = append(container, nil)
+			copy(container[index+1:], container[index:])
+			container[index] = value
+			return container, nil
+		}
+		return nil, missingJSONValue(path)
+	})
+}
+
+func removeJSONValue(document interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("%w: the whole document cannot be removed", ErrInvalidPatch)
+	}
+	return changeJSONContainer(document, path, path, func(container interface{}, token string) (interface{}, error) {
+		switch container := container.(type) {
+		case map[string]interface{}:
+			if _, ok := container[token]; !ok {
+				return nil, missingJSONValue(path)
+			}
+			delete(container, token)
+			return container, nil
+		case []interface{}:
+			index, err := jsonArrayIndex(token, len(container)-1)
+			if err != nil {
+				return nil, err
+			}
+			return append(container[:index], container[index+1:]...), nil
+		}
+		return nil, missingJSONValue(path)
+	})
+}
+
+// changeJSONContainer walks to the container holding the last token of rest
+// and returns document with change applied to it. Arrays may be replaced by
+// the change, so every container on the way stores its child back.
+func changeJSONContainer(document interface{}, path []string, rest []string, change func(container interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(rest) == 1 {
+		return change(document, rest[0])
+	}
+
+	switch container := document.(type) {
+	case map[string]interface{}:
+		child, ok := container[rest[0]]
+		if !ok {
+			return nil, missingJSONValue(path)
+		}
+		child, err := changeJSONContainer(child, path, rest[1:], change)
+		if err != nil {
+			return nil, err
+		}
+		container[rest[0]] = child
+		return container, nil
+	case []interface{}:
+		index, err := jsonArrayIndex(rest[0], len(container)-1)
+		if err != nil {
+			return nil, err
+		}
+		child, err := changeJSONContainer(container[index], path, rest[1:], change)
+		if err != nil {
+			return nil, err
+		}
+		container[index] = child
+		return container, nil
+	}
+	return nil, missingJSONValue(path)
+}
+
+// jsonArrayIndex parses an array index token that may be at most last.
+func jsonArrayIndex(token string, last int) (int, error) {
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: %q is not an array index", ErrInvalidPatch, token)
+	}
+	if index > last {
+		return 0, fmt.Errorf("%w: array index %d is out of range", ErrInvalidPatch, index)
+	}
+	return index, nil
+}
+
+func missingJSONValue(path []string) error {
+	tokens := make([]string, len(path))
+	for i, token := range path {
+		tokens[i] = strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+	}
+	return fmt.Errorf("%w: path /%s does not exist", ErrInvalidPatch, strings.Join(tokens, "/"))
+}
+
+// jsonEqual compares decoded JSON values as RFC 6902 test does: numbers by
+// value, objects regardless of member order.
+func jsonEqual(a interface{}, b interface{}) bool {
+	switch a := a.(type) {
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		x, okX := new(big.Rat).SetString(a.String())
+		y, okY := new(big.Rat).SetString(b.String())
+		return okX && okY && x.Cmp(y) == 0
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for key, value := range a {
+			other, ok := b[key]
+			if !ok || !jsonEqual(value, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !jsonEqual(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return a == b
+	}
+}
+
+// decodeJSON decodes a single JSON value, keeping numbers exact.
+func decodeJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, errors.New("unexpected data after the JSON value")
+	}
+	return value, nil
+}
+
+func encodeJSON(value interface{}) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
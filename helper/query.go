@@ -0,0 +1,45 @@
+package helper
+
+import (
+	"bubblevy/restful-api/model/web"
+	"net/url"
+	"strconv"
+)
+
+// PaginationLinks fills the next and prev links of a page, keeping every other
+// query parameter of the original request intact.
+func PaginationLinks(requestUrl *url.URL, pagination *web.Pagination) {
+	link := func(values url.Values) string {
+		next := url.URL{Path: requestUrl.Path, RawQuery: values.Encode()}
+		return next.String()
+	}
+
+	if pagination.Page != 0 {
+		if pagination.Page < pagination.TotalPages {
+			values := requestUrl.Query()
+			values.Set("page", strconv.Itoa(pagination.Page+1))
+			pagination.Next = link(values)
+		}
+		if pagination.Page > 1 {
+			values := requestUrl.Query()
+			values.Set("page", strconv.Itoa(pagination.Page-1))
+			pagination.Prev = link(values)
+		}
+		return
+	}
+
+	if pagination.Offset+pagination.Limit < pagination.Total {
+		values := requestUrl.Query()
+		values.Set("offset", strconv.Itoa(pagination.Offset+pagination.Limit))
+		pagination.Next = link(values)
+	}
+	if pagination.Offset > 0 {
+		prevOffset := pagination.Offset - pagination.Limit
+		if prevOffset < 0 {
+			prevOffset = 0
+		}
+		values := requestUrl.Query()
+		values.Set("offset", strconv.Itoa(prevOffset))
+		pagination.Prev = link(values)
+	}
+}
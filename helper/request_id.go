@@ -0,0 +1,27 @@
+package helper
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+type requestIdKey struct{}
+
+func WithRequestId(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIdKey{}, requestId)
+}
+
+// RequestId returns the id of the request being served, or an empty string
+// outside of an HTTP request.
+func RequestId(ctx context.Context) string {
+	requestId, _ := ctx.Value(requestIdKey{}).(string)
+	return requestId
+}
+
+func NewRequestId() string {
+	data := make([]byte, 16)
+	_, err := rand.Read(data)
+	PanicIfError(err)
+	return hex.EncodeToString(data)
+}
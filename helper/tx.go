@@ -1,15 +1,115 @@
 package helper
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+)
 
-func CommitOrRollback(tx *sql.Tx) {
-	err := recover()
+var ErrShuttingDown = errors.New("server is shutting down, no new transactions are accepted")
+
+// Tx is a unit of work of a storage backend. *sql.Tx satisfies it; other
+// backends hand their own implementation to their repositories.
+type Tx interface {
+	Commit() error
+	Rollback() error
+}
+
+// TxManager opens transactions on a storage backend.
+type TxManager interface {
+	BeginTx(ctx context.Context) (Tx, error)
+}
+
+type sqlTxManager struct {
+	db *sql.DB
+}
+
+func NewSQLTxManager(db *sql.DB) TxManager {
+	return &sqlTxManager{db: db}
+}
+
+func (manager *sqlTxManager) BeginTx(ctx context.Context) (Tx, error) {
+	tx, err := manager.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// transactions counts the transactions opened through BeginTx so a shutdown can
+// wait for them to finish before the database is closed.
+var transactions = struct {
+	sync.Mutex
+	open    int
+	closed  bool
+	drained chan struct{}
+}{}
+
+func BeginTx(ctx context.Context, txManager TxManager) (Tx, error) {
+	transactions.Lock()
+	if transactions.closed {
+		transactions.Unlock()
+		return nil, ErrShuttingDown
+	}
+	if transactions.open == 0 {
+		transactions.drained = make(chan struct{})
+	}
+	transactions.open++
+	transactions.Unlock()
+
+	tx, err := txManager.BeginTx(ctx)
 	if err != nil {
-		errorRollback := tx.Rollback()
-		PanicIfError(errorRollback)
-		panic(err)
-	} else {
-		errorCommit := tx.Commit()
-		PanicIfError(errorCommit)
+		endTx()
+		return nil, err
+	}
+	return tx, nil
+}
+
+// CommitOrRollback ends a transaction opened by BeginTx. It is deferred with a
+// pointer to the caller's named error: the transaction is rolled back when that
+// error is set or the caller panics, and committed otherwise.
+func CommitOrRollback(tx Tx, err *error) {
+	defer endTx()
+
+	if recovered := recover(); recovered != nil {
+		tx.Rollback()
+		panic(recovered)
+	}
+
+	if *err != nil {
+		tx.Rollback()
+		return
+	}
+	*err = tx.Commit()
+}
+
+func endTx() {
+	transactions.Lock()
+	defer transactions.Unlock()
+
+	transactions.open--
+	if transactions.open == 0 {
+		close(transactions.drained)
+	}
+}
+
+// DrainTransactions refuses new transactions and blocks until the open ones
+// have committed or rolled back, or until ctx is done.
+func DrainTransactions(ctx context.Context) error {
+	transactions.Lock()
+	transactions.closed = true
+	if transactions.open == 0 {
+		transactions.Unlock()
+		return nil
+	}
+	drained := transactions.drained
+	transactions.Unlock()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
@@ -1,31 +1,14 @@
 package main
 
 import (
-	"bubblevy/restful-api/app"
-	"bubblevy/restful-api/controller"
-	"bubblevy/restful-api/helper"
-	"bubblevy/restful-api/middleware"
-	"bubblevy/restful-api/repository"
-	"bubblevy/restful-api/service"
-	"net/http"
+	"bubblevy/restful-api/cli"
+	"os"
 
-	"github.com/go-playground/validator/v10"
 	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
 )
 
 func main() {
-	db := app.NewDB()
-	validate := validator.New()
-	productRepository := repository.NewProductRepository()
-	productService := service.NewProductService(productRepository, db, validate)
-	productController := controller.NewProductController(productService)
-	router := app.NewRouter(productController)
-
-	server := http.Server{
-		Addr:    "localhost:3000",
-		Handler: middleware.NewAuthMiddleware(router),
-	}
-
-	err := server.ListenAndServe()
-	helper.PanicIfError(err)
+	os.Exit(cli.New(os.Stdout, os.Stderr).Run(os.Args[1:]))
 }
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"bubblevy/restful-api/exception"
+	"bubblevy/restful-api/helper"
+	"net/http"
+	"strconv"
+)
+
+const ApiVersionHeader = "API-Version"
+
+type apiVersionMiddleware struct {
+	Handler http.Handler
+}
+
+// NewApiVersionMiddleware reads the API version a client asks for from the
+// API-Version header, 1 when it sends none, and echoes the version served.
+func NewApiVersionMiddleware(handler http.Handler) *apiVersionMiddleware {
+	return &apiVersionMiddleware{Handler: handler}
+}
+
+func (middleware *apiVersionMiddleware) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	version := helper.ApiVersion1
+	if header := request.Header.Get(ApiVersionHeader); header != "" {
+		parsed, err := strconv.Atoi(header)
+		if err != nil || parsed < helper.ApiVersion1 || parsed > helper.ApiVersion2 {
+			exception.WriteError(writer, request, exception.NewValidationError("API-Version must be 1 or 2"))
+			return
+		}
+		version = parsed
+	}
+
+	writer.Header().Add("Vary", ApiVersionHeader)
+	writer.Header().Set(ApiVersionHeader, strconv.Itoa(version))
+	middleware.Handler.ServeHTTP(writer, request.WithContext(helper.WithApiVersion(request.Context(), version)))
+}
@@ -1,34 +1,69 @@
 package middleware
 
 import (
-	"bubblevy/restful-api/helper"
-	"bubblevy/restful-api/model/web"
+	"bubblevy/restful-api/auth"
+	"bubblevy/restful-api/exception"
+	"bubblevy/restful-api/service"
+	"crypto/subtle"
+	"log"
 	"net/http"
+	"strings"
 )
 
 type authMiddleware struct {
-	Handler http.Handler
+	Handler       http.Handler
+	ApiKeyService service.ApiKeyService
+	RootKey       string
+	Verifier      auth.Verifier
 }
 
-func NewAuthMiddleware(handler http.Handler) *authMiddleware {
-	return &authMiddleware{Handler: handler}
+// NewAuthMiddleware authenticates either an Authorization: Bearer token, when
+// a verifier is configured, or the API-Key header against the stored keys.
+// rootKey, when set, is a bootstrap key from the config that holds every scope.
+func NewAuthMiddleware(handler http.Handler, apiKeyService service.ApiKeyService, rootKey string, verifier auth.Verifier) *authMiddleware {
+	return &authMiddleware{Handler: handler, ApiKeyService: apiKeyService, RootKey: rootKey, Verifier: verifier}
 }
 
 func (middleware *authMiddleware) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
-	if request.Header.Get("API-Key") == "BUBBLEKEY" {
-		// ok
-		middleware.Handler.ServeHTTP(writer, request)
+	var identity auth.Identity
+	var err error
+
+	authorization := request.Header.Get("Authorization")
+	if middleware.Verifier != nil && len(authorization) > 7 && strings.EqualFold(authorization[:7], "Bearer ") {
+		identity, err = middleware.bearerIdentity(authorization[7:])
 	} else {
-		//error api key
-		writer.Header().Set("Content-Type", "application/json")
-		writer.WriteHeader(http.StatusUnauthorized)
+		identity, err = middleware.apiKeyIdentity(request)
+	}
+	if err != nil {
+		exception.WriteError(writer, request, err)
+		return
+	}
 
-		webResponse := web.WebResponse{
-			Code:    http.StatusUnauthorized,
-			Error:   true,
-			Message: "Invalid API key. Please provide a valid key.",
-		}
+	middleware.Handler.ServeHTTP(writer, request.WithContext(auth.WithIdentity(request.Context(), identity)))
+}
+
+func (middleware *authMiddleware) bearerIdentity(token string) (auth.Identity, error) {
+	identity, err := middleware.Verifier.Verify(strings.TrimSpace(token))
+	if err != nil {
+		log.Printf("rejected bearer token: %v", err)
+		return identity, exception.NewUnauthorizedError("Invalid bearer token. Please provide a valid token.")
+	}
+	return identity, nil
+}
+
+func (middleware *authMiddleware) apiKeyIdentity(request *http.Request) (auth.Identity, error) {
+	key := request.Header.Get("API-Key")
+	if key == "" {
+		return auth.Identity{}, exception.NewUnauthorizedError("Invalid API key. Please provide a valid key.")
+	}
+
+	if middleware.RootKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(middleware.RootKey)) == 1 {
+		return auth.Identity{Subject: "root", Roles: []string{auth.RoleAdmin}}, nil
+	}
 
-		helper.WriteToResponseBody(writer, webResponse)
+	apiKey, err := middleware.ApiKeyService.Authenticate(request.Context(), key)
+	if err != nil {
+		return auth.Identity{}, err
 	}
+	return auth.Identity{Subject: apiKey.Owner, Scopes: apiKey.Scopes}, nil
 }
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"bubblevy/restful-api/helper"
+	"net/http"
+)
+
+const RequestIdHeader = "X-Request-Id"
+
+type requestIdMiddleware struct {
+	Handler http.Handler
+}
+
+// NewRequestIdMiddleware tags every request with a correlation id, reusing the
+// caller's X-Request-Id when present, and echoes it in the response.
+func NewRequestIdMiddleware(handler http.Handler) *requestIdMiddleware {
+	return &requestIdMiddleware{Handler: handler}
+}
+
+func (middleware *requestIdMiddleware) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	requestId := request.Header.Get(RequestIdHeader)
+	if requestId == "" || len(requestId) > 128 {
+		requestId = helper.NewRequestId()
+	}
+
+	writer.Header().Set(RequestIdHeader, requestId)
+	middleware.Handler.ServeHTTP(writer, request.WithContext(helper.WithRequestId(request.Context(), requestId)))
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"bubblevy/restful-api/auth"
+	"bubblevy/restful-api/exception"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// RequireScope guards a route so only callers granted scope, directly or
+// through a role, may reach it.
+func RequireScope(scope string, handle httprouter.Handle) httprouter.Handle {
+	return func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+		identity, ok := auth.FromContext(request.Context())
+		if !ok {
+			exception.WriteError(writer, request, exception.NewUnauthorizedError("Invalid API key. Please provide a valid key."))
+			return
+		}
+		if !identity.Can(scope) {
+			exception.WriteError(writer, request, exception.NewForbiddenError("The caller lacks the "+scope+" permission."))
+			return
+		}
+
+		handle(writer, request, params)
+	}
+}
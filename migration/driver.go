@@ -0,0 +1,78 @@
+package migration
+
+import (
+	"bubblevy/restful-api/repository"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// lockName identifies the migration lock on MySQL and, hashed, on Postgres.
+const lockName = "bubblego_schema_migrations"
+
+const lockTimeout = 60 * time.Second
+
+var ErrLockTimeout = errors.New("timed out waiting for another instance to finish migrating")
+
+// driver holds what differs between databases: the schema_migrations table
+// definition, placeholders and how concurrent migrators are kept apart.
+type driver struct {
+	dialect     repository.Dialect
+	createTable string
+	lock        func(ctx context.Context, conn *sql.Conn) error
+	unlock      func(ctx context.Context, conn *sql.Conn) error
+}
+
+var drivers = map[string]driver{
+	"mysql": {
+		dialect:     repository.DialectMySQL,
+		createTable: "CREATE TABLE IF NOT EXISTS schema_migrations (version bigint NOT NULL PRIMARY KEY, name varchar(255) NOT NULL, checksum char(64) NOT NULL, applied_at datetime NOT NULL)",
+		lock: func(ctx context.Context, conn *sql.Conn) error {
+			var acquired sql.NullInt64
+			err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", lockName, int(lockTimeout.Seconds())).Scan(&acquired)
+			if err != nil {
+				return err
+			}
+			if acquired.Int64 != 1 {
+				return ErrLockTimeout
+			}
+			return nil
+		},
+		unlock: func(ctx context.Context, conn *sql.Conn) error {
+			_, err := conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", lockName)
+			return err
+		},
+	},
+	"postgres": {
+		dialect:     repository.DialectPostgres,
+		createTable: "CREATE TABLE IF NOT EXISTS schema_migrations (version bigint NOT NULL PRIMARY KEY, name varchar(255) NOT NULL, checksum char(64) NOT NULL, applied_at timestamp NOT NULL)",
+		lock: func(ctx context.Context, conn *sql.Conn) error {
+			ctx, cancel := context.WithTimeout(ctx, lockTimeout)
+			defer cancel()
+
+			_, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock(hashtext($1))", lockName)
+			if errors.Is(err, context.DeadlineExceeded) {
+				return ErrLockTimeout
+			}
+			return err
+		},
+		unlock: func(ctx context.Context, conn *sql.Conn) error {
+			_, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock(hashtext($1))", lockName)
+			return err
+		},
+	},
+	// SQLite has no named locks. Every migration runs in a transaction that
+	// takes the database write lock when it begins (_txlock=immediate), and
+	// the migrator checks inside it whether another instance got there first.
+	"sqlite": {
+		dialect:     repository.DialectSQLite,
+		createTable: "CREATE TABLE IF NOT EXISTS schema_migrations (version bigint NOT NULL PRIMARY KEY, name varchar(255) NOT NULL, checksum char(64) NOT NULL, applied_at datetime NOT NULL)",
+		lock: func(ctx context.Context, conn *sql.Conn) error {
+			return nil
+		},
+		unlock: func(ctx context.Context, conn *sql.Conn) error {
+			return nil
+		},
+	},
+}
@@ -0,0 +1,131 @@
+package migration
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed mysql/*.sql postgres/*.sql sqlite/*.sql
+var files embed.FS
+
+// Migration is one versioned schema change, read from a pair of
+// <version>_<name>.up.sql and <version>_<name>.down.sql files.
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// Load returns the migrations embedded for a database driver, ordered by version.
+func Load(driver string) ([]Migration, error) {
+	directory, err := fs.Sub(files, driver)
+	if err != nil {
+		return nil, err
+	}
+	return LoadFS(directory)
+}
+
+// LoadFS reads the migrations found at the root of fsys, ordered by version.
+// Every version needs both an up and a down file.
+func LoadFS(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+
+		version, name, direction, err := parseFileName(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		}
+		if migration.Name != name {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, migration.Name, name)
+		}
+		if direction == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := []Migration{}
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", migration.Version, migration.Name)
+		}
+		sum := sha256.Sum256([]byte(migration.Up))
+		migration.Checksum = hex.EncodeToString(sum[:])
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+func parseFileName(fileName string) (int, string, string, error) {
+	base := strings.TrimSuffix(fileName, ".sql")
+	direction := path.Ext(base)
+	if direction != ".up" && direction != ".down" {
+		return 0, "", "", errors.New("migration file must end in .up.sql or .down.sql: " + fileName)
+	}
+	base = strings.TrimSuffix(base, direction)
+
+	number, name, ok := strings.Cut(base, "_")
+	version, err := strconv.Atoi(number)
+	if !ok || err != nil || version <= 0 {
+		return 0, "", "", errors.New("migration file must start with a positive version: " + fileName)
+	}
+	return version, name, strings.TrimPrefix(direction, "."), nil
+}
+
+// statements splits a migration into the statements it holds, each ending
+// with a semicolon at the end of a line. Not every driver accepts several
+// statements in one Exec.
+func statements(script string) []string {
+	var result []string
+	var current strings.Builder
+	for _, line := range strings.SplitAfter(script, "\n") {
+		current.WriteString(line)
+		if strings.HasSuffix(strings.TrimSpace(line), ";") {
+			result = appendStatement(result, current.String())
+			current.Reset()
+		}
+	}
+	return appendStatement(result, current.String())
+}
+
+func appendStatement(result []string, statement string) []string {
+	for _, line := range strings.Split(statement, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return append(result, strings.TrimSpace(statement))
+		}
+	}
+	return result
+}
@@ -0,0 +1,255 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrChecksumMismatch = errors.New("applied migration was modified after it ran")
+
+// Status reports whether a known migration has been applied. Modified is set
+// when the applied checksum no longer matches the embedded file.
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt *time.Time
+	Modified  bool
+}
+
+// Migrator moves a database between the versions of its migrations, recording
+// the applied ones in the schema_migrations table.
+type Migrator struct {
+	DB         *sql.DB
+	Migrations []Migration
+	driver     driver
+}
+
+// New returns a migrator for the migrations embedded for the driver.
+func New(db *sql.DB, driverName string) (*Migrator, error) {
+	migrations, err := Load(driverName)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithMigrations(db, driverName, migrations)
+}
+
+func NewWithMigrations(db *sql.DB, driverName string, migrations []Migration) (*Migrator, error) {
+	driver, ok := drivers[driverName]
+	if !ok {
+		return nil, errors.New("migrations are not supported for driver " + driverName)
+	}
+	return &Migrator{DB: db, Migrations: migrations, driver: driver}, nil
+}
+
+// Up applies every pending migration.
+func (migrator *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	return migrator.migrate(ctx, func(applied map[int]appliedMigration) (int, error) {
+		if len(migrator.Migrations) == 0 {
+			return 0, nil
+		}
+		return migrator.Migrations[len(migrator.Migrations)-1].Version, nil
+	})
+}
+
+// Down reverts the most recently applied migration.
+func (migrator *Migrator) Down(ctx context.Context) ([]Migration, error) {
+	return migrator.migrate(ctx, func(applied map[int]appliedMigration) (int, error) {
+		target := 0
+		latest := 0
+		for version := range applied {
+			if version > latest {
+				target, latest = latest, version
+			} else if version > target {
+				target = version
+			}
+		}
+		return target, nil
+	})
+}
+
+// To applies or reverts migrations until version is the latest applied one.
+// Version 0 reverts every migration.
+func (migrator *Migrator) To(ctx context.Context, version int) ([]Migration, error) {
+	if version != 0 && migrator.find(version) == nil {
+		return nil, fmt.Errorf("unknown migration version %d", version)
+	}
+	return migrator.migrate(ctx, func(applied map[int]appliedMigration) (int, error) {
+		return version, nil
+	})
+}
+
+func (migrator *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := migrator.DB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, migrator.driver.createTable); err != nil {
+		return nil, err
+	}
+	applied, err := migrator.applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []Status{}
+	for _, migration := range migrator.Migrations {
+		status := Status{Migration: migration}
+		if record, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = &record.AppliedAt
+			status.Modified = record.Checksum != migration.Checksum
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+type appliedMigration struct {
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// migrate holds the migration lock while it compares the applied migrations
+// with the known ones and moves the schema to the version target picks.
+func (migrator *Migrator) migrate(ctx context.Context, target func(applied map[int]appliedMigration) (int, error)) (steps []Migration, err error) {
+	conn, err := migrator.DB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := migrator.driver.lock(ctx, conn); err != nil {
+		return nil, err
+	}
+	defer func() {
+		// a fresh context so a cancelled run still releases the lock
+		unlockErr := migrator.driver.unlock(context.Background(), conn)
+		if err == nil {
+			err = unlockErr
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, migrator.driver.createTable); err != nil {
+		return nil, err
+	}
+	applied, err := migrator.applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	for version, record := range applied {
+		migration := migrator.find(version)
+		if migration == nil {
+			return nil, fmt.Errorf("migration %d is applied but unknown to this build", version)
+		}
+		if migration.Checksum != record.Checksum {
+			return nil, fmt.Errorf("%w: %d_%s", ErrChecksumMismatch, migration.Version, migration.Name)
+		}
+	}
+
+	version, err := target(applied)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, migration := range migrator.Migrations {
+		if _, ok := applied[migration.Version]; !ok && migration.Version <= version {
+			done, err := migrator.apply(ctx, conn, migration, true)
+			if err != nil {
+				return steps, err
+			}
+			if done {
+				steps = append(steps, migration)
+			}
+		}
+	}
+	for i := len(migrator.Migrations) - 1; i >= 0; i-- {
+		migration := migrator.Migrations[i]
+		if _, ok := applied[migration.Version]; ok && migration.Version > version {
+			done, err := migrator.apply(ctx, conn, migration, false)
+			if err != nil {
+				return steps, err
+			}
+			if done {
+				steps = append(steps, migration)
+			}
+		}
+	}
+	return steps, nil
+}
+
+// apply runs one migration in a transaction together with its bookkeeping.
+// MySQL commits DDL implicitly, so a migration failing halfway there leaves
+// its earlier statements in place without recording the version.
+func (migrator *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration, up bool) (done bool, err error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err != nil || !done {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	rebind := migrator.driver.dialect.Rebind
+	var count int
+	err = tx.QueryRowContext(ctx, rebind("SELECT COUNT(*) FROM schema_migrations WHERE version = ?"), migration.Version).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+	if (count == 1) == up {
+		return false, nil
+	}
+
+	script := migration.Down
+	if up {
+		script = migration.Up
+	}
+	for _, statement := range statements(script) {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return false, fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+	}
+
+	if up {
+		_, err = tx.ExecContext(ctx, rebind("INSERT INTO schema_migrations(version, name, checksum, applied_at) VALUES (?, ?, ?, ?)"), migration.Version, migration.Name, migration.Checksum, time.Now().UTC())
+	} else {
+		_, err = tx.ExecContext(ctx, rebind("DELETE FROM schema_migrations WHERE version = ?"), migration.Version)
+	}
+	return err == nil, err
+}
+
+func (migrator *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int]appliedMigration, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, checksum, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]appliedMigration{}
+	for rows.Next() {
+		var version int
+		record := appliedMigration{}
+		if err := rows.Scan(&version, &record.Checksum, &record.AppliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = record
+	}
+	return applied, rows.Err()
+}
+
+func (migrator *Migrator) find(version int) *Migration {
+	for i := range migrator.Migrations {
+		if migrator.Migrations[i].Version == version {
+			return &migrator.Migrations[i]
+		}
+	}
+	return nil
+}
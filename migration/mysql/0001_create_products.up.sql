@@ -0,0 +1,8 @@
+-- IF NOT EXISTS lets databases imported from the old database.sql dump adopt
+-- the migrations without recreating their tables.
+CREATE TABLE IF NOT EXISTS products (
+  id int NOT NULL AUTO_INCREMENT,
+  product_name varchar(255) NOT NULL,
+  price int NOT NULL,
+  PRIMARY KEY (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
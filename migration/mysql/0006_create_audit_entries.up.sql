@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS audit_entries (
+  id int NOT NULL AUTO_INCREMENT,
+  actor varchar(100) NOT NULL,
+  action varchar(32) NOT NULL,
+  product_id int NOT NULL,
+  before_snapshot text DEFAULT NULL,
+  after_snapshot text DEFAULT NULL,
+  request_id varchar(128) NOT NULL,
+  created_at datetime NOT NULL,
+  PRIMARY KEY (id),
+  KEY audit_entries_product_id_index (product_id),
+  KEY audit_entries_actor_index (actor),
+  KEY audit_entries_created_at_index (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,14 @@
+-- every write of a product stores the state it leaves behind as the revision
+-- numbered by its new version. Existing products start their history with
+-- the state they are in when the migration runs.
+CREATE TABLE IF NOT EXISTS product_revisions (
+  product_id int NOT NULL,
+  revision int NOT NULL,
+  product_name varchar(255) NOT NULL,
+  price int NOT NULL,
+  deleted_at datetime DEFAULT NULL,
+  recorded_at datetime NOT NULL,
+  PRIMARY KEY (product_id, revision)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+INSERT INTO product_revisions (product_id, revision, product_name, price, deleted_at, recorded_at)
+SELECT id, version, product_name, price, deleted_at, UTC_TIMESTAMP() FROM products;
@@ -0,0 +1,15 @@
+-- a price schedule changes the price of a product at starts_at, and when it
+-- has an ends_at brings back the price it replaced, kept in previous_price.
+CREATE TABLE IF NOT EXISTS price_schedules (
+  id int NOT NULL AUTO_INCREMENT,
+  product_id int NOT NULL,
+  price int NOT NULL,
+  starts_at datetime NOT NULL,
+  ends_at datetime DEFAULT NULL,
+  status varchar(16) NOT NULL,
+  previous_price int DEFAULT NULL,
+  created_at datetime NOT NULL,
+  PRIMARY KEY (id),
+  KEY price_schedules_product_id_index (product_id, starts_at),
+  KEY price_schedules_status_index (status, starts_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,11 @@
+-- prices become amounts in the minor unit of a currency. Every price so far
+-- was in whole rupiah, so rows are converted to IDR, which counts two digits
+-- of minor unit.
+ALTER TABLE products MODIFY price bigint NOT NULL, ADD COLUMN currency char(3) NOT NULL DEFAULT 'IDR' AFTER price;
+UPDATE products SET price = price * 100;
+ALTER TABLE product_revisions MODIFY price bigint NOT NULL, ADD COLUMN currency char(3) NOT NULL DEFAULT 'IDR' AFTER price;
+UPDATE product_revisions SET price = price * 100;
+ALTER TABLE price_schedules MODIFY price bigint NOT NULL, MODIFY previous_price bigint DEFAULT NULL,
+  ADD COLUMN currency char(3) NOT NULL DEFAULT 'IDR' AFTER price, ADD COLUMN previous_currency char(3) DEFAULT NULL AFTER previous_price;
+UPDATE price_schedules SET price = price * 100, previous_price = previous_price * 100;
+UPDATE price_schedules SET previous_currency = 'IDR' WHERE previous_price IS NOT NULL;
@@ -0,0 +1,2 @@
+-- products:delete is left in place: the keys granted it here cannot be told
+-- apart from those created with it.
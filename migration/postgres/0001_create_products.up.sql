@@ -0,0 +1,7 @@
+-- IF NOT EXISTS lets databases loaded from the old database_postgres.sql adopt
+-- the migrations without recreating their tables.
+CREATE TABLE IF NOT EXISTS products (
+  id serial PRIMARY KEY,
+  product_name varchar(255) NOT NULL,
+  price int NOT NULL
+);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+  id serial PRIMARY KEY,
+  name varchar(100) NOT NULL,
+  owner varchar(100) NOT NULL,
+  key_prefix varchar(16) NOT NULL,
+  key_hash char(64) NOT NULL,
+  scopes varchar(255) NOT NULL,
+  expires_at timestamp DEFAULT NULL,
+  last_used_at timestamp DEFAULT NULL,
+  revoked_at timestamp DEFAULT NULL,
+  created_at timestamp NOT NULL,
+  CONSTRAINT api_keys_key_hash_unique UNIQUE (key_hash)
+);
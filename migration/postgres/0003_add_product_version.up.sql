@@ -0,0 +1,3 @@
+-- version counts the changes of a product; updates and deletes name the
+-- version they read so a concurrent change is detected instead of overwritten.
+ALTER TABLE products ADD COLUMN version int NOT NULL DEFAULT 1;
@@ -0,0 +1,4 @@
+-- deleted products stay in the table, in the trash, until they are restored
+-- or purged; deleted_at is when they were moved there.
+ALTER TABLE products ADD COLUMN deleted_at timestamp DEFAULT NULL;
+CREATE INDEX products_deleted_at_index ON products (deleted_at);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS audit_entries (
+  id serial PRIMARY KEY,
+  actor varchar(100) NOT NULL,
+  action varchar(32) NOT NULL,
+  product_id int NOT NULL,
+  before_snapshot text DEFAULT NULL,
+  after_snapshot text DEFAULT NULL,
+  request_id varchar(128) NOT NULL,
+  created_at timestamp NOT NULL
+);
+CREATE INDEX IF NOT EXISTS audit_entries_product_id_index ON audit_entries (product_id);
+CREATE INDEX IF NOT EXISTS audit_entries_actor_index ON audit_entries (actor);
+CREATE INDEX IF NOT EXISTS audit_entries_created_at_index ON audit_entries (created_at);
@@ -0,0 +1 @@
+DROP TABLE price_schedules;
@@ -0,0 +1,14 @@
+-- a price schedule changes the price of a product at starts_at, and when it
+-- has an ends_at brings back the price it replaced, kept in previous_price.
+CREATE TABLE IF NOT EXISTS price_schedules (
+  id serial PRIMARY KEY,
+  product_id int NOT NULL,
+  price int NOT NULL,
+  starts_at timestamp NOT NULL,
+  ends_at timestamp DEFAULT NULL,
+  status varchar(16) NOT NULL,
+  previous_price int DEFAULT NULL,
+  created_at timestamp NOT NULL
+);
+CREATE INDEX IF NOT EXISTS price_schedules_product_id_index ON price_schedules (product_id, starts_at);
+CREATE INDEX IF NOT EXISTS price_schedules_status_index ON price_schedules (status, starts_at);
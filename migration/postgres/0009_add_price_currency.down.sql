@@ -0,0 +1,7 @@
+-- prices go back to whole units, rounded, as if every row were in IDR.
+UPDATE price_schedules SET price = ROUND(price / 100.0), previous_price = ROUND(previous_price / 100.0);
+ALTER TABLE price_schedules DROP COLUMN currency, DROP COLUMN previous_currency, ALTER COLUMN price TYPE int, ALTER COLUMN previous_price TYPE int;
+UPDATE product_revisions SET price = ROUND(price / 100.0);
+ALTER TABLE product_revisions DROP COLUMN currency, ALTER COLUMN price TYPE int;
+UPDATE products SET price = ROUND(price / 100.0);
+ALTER TABLE products DROP COLUMN currency, ALTER COLUMN price TYPE int;
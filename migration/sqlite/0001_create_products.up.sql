@@ -0,0 +1,7 @@
+-- IF NOT EXISTS lets files created by the earlier built-in schema adopt the
+-- migrations without recreating their tables.
+CREATE TABLE IF NOT EXISTS products (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  product_name varchar(255) NOT NULL COLLATE NOCASE,
+  price int NOT NULL
+);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  name varchar(100) NOT NULL,
+  owner varchar(100) NOT NULL,
+  key_prefix varchar(16) NOT NULL,
+  key_hash char(64) NOT NULL UNIQUE,
+  scopes varchar(255) NOT NULL,
+  expires_at datetime DEFAULT NULL,
+  last_used_at datetime DEFAULT NULL,
+  revoked_at datetime DEFAULT NULL,
+  created_at datetime NOT NULL
+);
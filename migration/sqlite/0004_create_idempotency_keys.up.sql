@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+  owner varchar(100) NOT NULL,
+  idempotency_key varchar(255) NOT NULL,
+  request_hash char(64) NOT NULL,
+  status int NOT NULL,
+  body text NOT NULL,
+  created_at datetime NOT NULL,
+  expires_at datetime NOT NULL,
+  PRIMARY KEY (owner, idempotency_key)
+);
+CREATE INDEX IF NOT EXISTS idempotency_keys_expires_at_index ON idempotency_keys (expires_at);
@@ -0,0 +1,8 @@
+-- prices go back to whole units, rounded, as if every row were in IDR.
+UPDATE price_schedules SET price = ROUND(price / 100.0), previous_price = ROUND(previous_price / 100.0);
+ALTER TABLE price_schedules DROP COLUMN currency;
+ALTER TABLE price_schedules DROP COLUMN previous_currency;
+UPDATE product_revisions SET price = ROUND(price / 100.0);
+ALTER TABLE product_revisions DROP COLUMN currency;
+UPDATE products SET price = ROUND(price / 100.0);
+ALTER TABLE products DROP COLUMN currency;
@@ -0,0 +1,12 @@
+-- prices become amounts in the minor unit of a currency. Every price so far
+-- was in whole rupiah, so rows are converted to IDR, which counts two digits
+-- of minor unit. SQLite integers are 64 bits whatever the declared type, so
+-- price keeps its column.
+ALTER TABLE products ADD COLUMN currency char(3) NOT NULL DEFAULT 'IDR';
+UPDATE products SET price = price * 100;
+ALTER TABLE product_revisions ADD COLUMN currency char(3) NOT NULL DEFAULT 'IDR';
+UPDATE product_revisions SET price = price * 100;
+ALTER TABLE price_schedules ADD COLUMN currency char(3) NOT NULL DEFAULT 'IDR';
+ALTER TABLE price_schedules ADD COLUMN previous_currency char(3) DEFAULT NULL;
+UPDATE price_schedules SET price = price * 100, previous_price = previous_price * 100;
+UPDATE price_schedules SET previous_currency = 'IDR' WHERE previous_price IS NOT NULL;
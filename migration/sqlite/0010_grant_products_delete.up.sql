@@ -0,0 +1,6 @@
+-- deleting products used to come with products:write and now needs its own
+-- products:delete scope, so keys holding products:write are granted it and
+-- keep the rights they were issued with.
+UPDATE api_keys SET scopes = scopes || ',products:delete'
+WHERE ',' || scopes || ',' LIKE '%,products:write,%'
+  AND ',' || scopes || ',' NOT LIKE '%,products:delete,%';
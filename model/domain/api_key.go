@@ -0,0 +1,16 @@
+package domain
+
+import "time"
+
+type ApiKey struct {
+	Id         int
+	Name       string
+	Owner      string
+	KeyPrefix  string
+	KeyHash    string
+	Scopes     []string
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionRestore = "restore"
+	AuditActionPurge   = "purge"
+	AuditActionRevert  = "revert"
+)
+
+// AuditEntry records one change of a product: who made it, in which request,
+// and the product as it was before and after. Before is nil for a create and
+// After for a purge.
+type AuditEntry struct {
+	Id        int
+	Actor     string
+	Action    string
+	ProductId int
+	Before    json.RawMessage
+	After     json.RawMessage
+	RequestId string
+	CreatedAt time.Time
+}
+
+type AuditFilter struct {
+	ProductId *int
+	Actor     string
+	// From is inclusive and To exclusive.
+	From *time.Time
+	To   *time.Time
+}
+
+// AuditQuery lists entries oldest first, or newest first with NewestFirst.
+// AfterId continues an oldest-first listing past the entry with that id.
+type AuditQuery struct {
+	Filter      AuditFilter
+	NewestFirst bool
+	AfterId     int
+	Limit       int
+	Offset      int
+}
@@ -0,0 +1,15 @@
+package domain
+
+import "time"
+
+// IdempotencyRecord is the stored outcome of a request sent with an
+// Idempotency-Key. Retries of the same request get it back until it expires.
+type IdempotencyRecord struct {
+	Owner       string
+	Key         string
+	RequestHash string
+	Status      int
+	Body        string
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"bubblevy/restful-api/money"
+	"time"
+)
+
+const (
+	PriceScheduleScheduled = "scheduled"
+	PriceScheduleActive    = "active"
+	PriceScheduleCompleted = "completed"
+	PriceScheduleCancelled = "cancelled"
+)
+
+// PriceSchedule changes the price of a product to Price at StartsAt. With an
+// EndsAt it is temporary, like a promotion: the price it replaced, kept in
+// PreviousPrice, comes back at EndsAt unless the price was changed by other
+// means in the meantime. The schedules of a product never overlap, so they
+// apply one after the other.
+type PriceSchedule struct {
+	Id            int
+	ProductId     int
+	Price         money.Money
+	StartsAt      time.Time
+	EndsAt        *time.Time
+	Status        string
+	PreviousPrice *money.Money
+	CreatedAt     time.Time
+}
+
+// Apply runs the transitions of the schedule that are due at `at` against
+// price, the product's price when they run, and returns the resulting price
+// and the schedule in its new state.
+func (schedule PriceSchedule) Apply(price money.Money, at time.Time) (money.Money, PriceSchedule) {
+	if schedule.Status == PriceScheduleScheduled && !schedule.StartsAt.After(at) {
+		previous := price
+		schedule.PreviousPrice = &previous
+		price = schedule.Price
+		schedule.Status = PriceScheduleActive
+		if schedule.EndsAt == nil {
+			schedule.Status = PriceScheduleCompleted
+		}
+	}
+	if schedule.Status == PriceScheduleActive && schedule.EndsAt != nil && !schedule.EndsAt.After(at) {
+		price = schedule.end(price)
+		schedule.Status = PriceScheduleCompleted
+	}
+	return price, schedule
+}
+
+// Cancel stops the schedule at once. An active schedule ends early, bringing
+// back the price it replaced.
+func (schedule PriceSchedule) Cancel(price money.Money) (money.Money, PriceSchedule) {
+	if schedule.Status == PriceScheduleActive {
+		price = schedule.end(price)
+	}
+	schedule.Status = PriceScheduleCancelled
+	return price, schedule
+}
+
+func (schedule PriceSchedule) end(price money.Money) money.Money {
+	if price == schedule.Price && schedule.PreviousPrice != nil {
+		return *schedule.PreviousPrice
+	}
+	return price
+}
+
+// Overlaps reports whether the two schedules would be in effect at the same
+// time. A schedule without EndsAt takes effect at StartsAt only.
+func (schedule PriceSchedule) Overlaps(other PriceSchedule) bool {
+	return schedule.covers(other.StartsAt) || other.covers(schedule.StartsAt)
+}
+
+func (schedule PriceSchedule) covers(at time.Time) bool {
+	if schedule.EndsAt == nil {
+		return schedule.StartsAt.Equal(at)
+	}
+	return !at.Before(schedule.StartsAt) && at.Before(*schedule.EndsAt)
+}
+
+// Open reports whether the schedule still has a transition to make.
+func (schedule PriceSchedule) Open() bool {
+	return schedule.Status == PriceScheduleScheduled || schedule.Status == PriceScheduleActive
+}
@@ -1,7 +1,30 @@
 package domain
 
+import (
+	"bubblevy/restful-api/money"
+	"time"
+)
+
 type Product struct {
 	Id          int
 	ProductName string
-	Price       int
+	Price       money.Money
+	Version     int
+	// DeletedAt is set while the product is in the trash.
+	DeletedAt *time.Time
+	// PriceSchedules holds the unfinished schedules of the product, ordered
+	// by StartsAt, when a read loaded them for a response. They are never
+	// stored with the product.
+	PriceSchedules []PriceSchedule
+}
+
+// EffectivePrice is the price of the product at `at`, once the loaded
+// schedules due by then have been applied. Price only catches up when the
+// scheduler applies them, so reads resolve the price this way in between.
+func (product Product) EffectivePrice(at time.Time) money.Money {
+	price := product.Price
+	for _, schedule := range product.PriceSchedules {
+		price, _ = schedule.Apply(price, at)
+	}
+	return price
 }
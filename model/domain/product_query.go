@@ -0,0 +1,36 @@
+package domain
+
+import "time"
+
+type ProductFilter struct {
+	NameContains string
+	// Currency narrows the products to those priced in it; MinPrice and
+	// MaxPrice are amounts in its minor unit.
+	Currency string
+	MinPrice *int64
+	MaxPrice *int64
+	// Deleted selects the products in the trash instead of the live ones,
+	// DeletedBefore narrows them to those deleted before a time.
+	Deleted       bool
+	DeletedBefore *time.Time
+}
+
+type ProductSort struct {
+	Column     string
+	Descending bool
+}
+
+// ProductKeyset is the position of the last row of a page: the value of the
+// sort column and the id that breaks ties between equal values.
+type ProductKeyset struct {
+	Value interface{}
+	Id    int
+}
+
+type ProductQuery struct {
+	Filter ProductFilter
+	Sorts  []ProductSort
+	After  *ProductKeyset
+	Limit  int
+	Offset int
+}
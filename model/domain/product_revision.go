@@ -0,0 +1,10 @@
+package domain
+
+import "time"
+
+// ProductRevision is a product as one write left it. The revision number is
+// the Version of the embedded product.
+type ProductRevision struct {
+	Product
+	RecordedAt time.Time
+}
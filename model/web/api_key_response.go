@@ -0,0 +1,21 @@
+package web
+
+import "time"
+
+type ApiKeyResponse struct {
+	Id         int        `json:"id"`
+	Name       string     `json:"name"`
+	Owner      string     `json:"owner"`
+	KeyPrefix  string     `json:"key_prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// ApiKeyCreateResponse is the only response that carries the plaintext key.
+type ApiKeyCreateResponse struct {
+	ApiKeyResponse
+	Key string `json:"key"`
+}
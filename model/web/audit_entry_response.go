@@ -0,0 +1,24 @@
+package web
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditEntryResponse carries the product snapshots as the JSON they were
+// recorded as; a missing snapshot is null.
+type AuditEntryResponse struct {
+	Id        int             `json:"id"`
+	Actor     string          `json:"actor"`
+	Action    string          `json:"action"`
+	ProductId int             `json:"product_id"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	RequestId string          `json:"request_id"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type AuditListResponse struct {
+	Entries    []AuditEntryResponse `json:"entries"`
+	Pagination *Pagination          `json:"pagination,omitempty"`
+}
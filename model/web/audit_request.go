@@ -0,0 +1,23 @@
+package web
+
+import "time"
+
+// AuditFilterRequest narrows the audit log. From is inclusive and To
+// exclusive.
+type AuditFilterRequest struct {
+	ProductId *int       `validate:"omitempty,min=1" json:"product_id"`
+	Actor     string     `validate:"max=100" json:"actor"`
+	From      *time.Time `json:"from"`
+	To        *time.Time `json:"to"`
+}
+
+type AuditFindAllRequest struct {
+	AuditFilterRequest
+	Page    int `validate:"omitempty,min=1" json:"page"`
+	PerPage int `validate:"omitempty,min=1,max=100" json:"per_page"`
+}
+
+type AuditExportRequest struct {
+	AuditFilterRequest
+	Format string `validate:"required,oneof=csv ndjson" json:"format"`
+}
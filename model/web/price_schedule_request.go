@@ -0,0 +1,15 @@
+package web
+
+import (
+	"bubblevy/restful-api/money"
+	"time"
+)
+
+// PriceScheduleCreateRequest schedules Price from StartsAt. Without EndsAt the
+// change is permanent; with it, the price before comes back at EndsAt.
+type PriceScheduleCreateRequest struct {
+	ProductId int         `validate:"required" json:"-"`
+	Price     money.Money `validate:"required" json:"price"`
+	StartsAt  *time.Time  `validate:"required" json:"starts_at"`
+	EndsAt    *time.Time  `json:"ends_at"`
+}
@@ -0,0 +1,44 @@
+package web
+
+import (
+	"bubblevy/restful-api/money"
+	"time"
+)
+
+type PriceScheduleResponse struct {
+	Id            int          `json:"id"`
+	ProductId     int          `json:"product_id"`
+	Price         money.Money  `json:"price"`
+	StartsAt      time.Time    `json:"starts_at"`
+	EndsAt        *time.Time   `json:"ends_at"`
+	Status        string       `json:"status"`
+	PreviousPrice *money.Money `json:"previous_price,omitempty"`
+	CreatedAt     time.Time    `json:"created_at"`
+}
+
+// PricePointResponse is one price in the history of a product: the price it
+// had from From until To, set by Revision. The current price has no To.
+type PricePointResponse struct {
+	Price    money.Money `json:"price"`
+	From     time.Time   `json:"from"`
+	To       *time.Time  `json:"to"`
+	Revision int         `json:"revision"`
+}
+
+type PriceScheduleResponseV1 struct {
+	Id            int        `json:"id"`
+	ProductId     int        `json:"product_id"`
+	Price         int64      `json:"price"`
+	StartsAt      time.Time  `json:"starts_at"`
+	EndsAt        *time.Time `json:"ends_at"`
+	Status        string     `json:"status"`
+	PreviousPrice *int64     `json:"previous_price,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+type PricePointResponseV1 struct {
+	Price    int64      `json:"price"`
+	From     time.Time  `json:"from"`
+	To       *time.Time `json:"to"`
+	Revision int        `json:"revision"`
+}
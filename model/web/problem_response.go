@@ -0,0 +1,19 @@
+package web
+
+// ProblemResponse is an RFC 7807 application/problem+json error body.
+type ProblemResponse struct {
+	Type          string               `json:"type"`
+	Title         string               `json:"title"`
+	Status        int                  `json:"status"`
+	Detail        string               `json:"detail,omitempty"`
+	Instance      string               `json:"instance,omitempty"`
+	CorrelationId string               `json:"correlation_id,omitempty"`
+	Errors        []FieldErrorResponse `json:"errors,omitempty"`
+}
+
+type FieldErrorResponse struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,28 @@
+package web
+
+import "bubblevy/restful-api/money"
+
+const (
+	BulkModeAllOrNothing = "all_or_nothing"
+	BulkModeBestEffort   = "best_effort"
+
+	BulkOpCreate = "create"
+	BulkOpUpdate = "update"
+	BulkOpDelete = "delete"
+)
+
+type ProductBulkRequest struct {
+	Mode       string                 `validate:"omitempty,oneof=all_or_nothing best_effort" json:"mode"`
+	Operations []ProductBulkOperation `validate:"required,min=1,max=1000" json:"operations"`
+}
+
+// ProductBulkOperation is one item of a bulk request. Id names the product to
+// update or delete, and Version, when set, the version it must be at;
+// ProductName and Price carry the values to create or update.
+type ProductBulkOperation struct {
+	Op          string      `json:"op"`
+	Id          int         `json:"id"`
+	Version     int         `json:"version"`
+	ProductName string      `json:"product_name"`
+	Price       money.Money `json:"price"`
+}
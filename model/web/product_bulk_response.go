@@ -0,0 +1,32 @@
+package web
+
+type ProductBulkResponse struct {
+	Mode      string              `json:"mode"`
+	Committed bool                `json:"committed"`
+	Results   []ProductBulkResult `json:"results"`
+}
+
+// ProductBulkResult reports the outcome of the operation at Index. The service
+// sets Err for a failed operation; Status and Error are its HTTP rendering.
+type ProductBulkResult struct {
+	Index  int              `json:"index"`
+	Op     string           `json:"op"`
+	Status int              `json:"status"`
+	Data   *ProductResponse `json:"data,omitempty"`
+	Error  *ProblemResponse `json:"error,omitempty"`
+	Err    error            `json:"-"`
+}
+
+type ProductBulkResponseV1 struct {
+	Mode      string                `json:"mode"`
+	Committed bool                  `json:"committed"`
+	Results   []ProductBulkResultV1 `json:"results"`
+}
+
+type ProductBulkResultV1 struct {
+	Index  int                `json:"index"`
+	Op     string             `json:"op"`
+	Status int                `json:"status"`
+	Data   *ProductResponseV1 `json:"data,omitempty"`
+	Error  *ProblemResponse   `json:"error,omitempty"`
+}
@@ -1,6 +1,12 @@
 package web
 
+import "bubblevy/restful-api/money"
+
 type ProductCreateRequest struct {
-	ProductName string `validate:"required,max=255,min=1" json:"product_name"`
-	Price       int    `validate:"required" json:"price"`
+	ProductName string      `validate:"required,max=255,min=1" json:"product_name"`
+	Price       money.Money `validate:"required" json:"price"`
+
+	// IdempotencyKey comes from the Idempotency-Key header; a retry sent with
+	// the same key gets the response of the first request.
+	IdempotencyKey string `validate:"max=255" json:"-"`
 }
@@ -0,0 +1,6 @@
+package web
+
+type ProductDeleteRequest struct {
+	Id               int   `validate:"required" json:"id"`
+	ExpectedVersions []int `json:"-"`
+}
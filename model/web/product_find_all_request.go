@@ -0,0 +1,18 @@
+package web
+
+type ProductFindAllRequest struct {
+	Page    int    `validate:"omitempty,min=1" json:"page"`
+	PerPage int    `validate:"omitempty,min=1,max=100" json:"per_page"`
+	Limit   int    `validate:"omitempty,min=1,max=100" json:"limit"`
+	Offset  int    `validate:"omitempty,min=0" json:"offset"`
+	Sort    string `validate:"max=255" json:"sort"`
+	// MinPrice and MaxPrice are decimal amounts in Currency, which defaults
+	// to money.DefaultCurrency when either is set. They match the stored
+	// price, which trails a due price schedule until the scheduler runs.
+	MinPrice     string `validate:"max=32" json:"min_price"`
+	MaxPrice     string `validate:"max=32" json:"max_price"`
+	Currency     string `validate:"omitempty,len=3" json:"currency"`
+	NameContains string `validate:"max=255" json:"name_contains"`
+	Mode         string `validate:"omitempty,oneof=offset cursor" json:"mode"`
+	Cursor       string `validate:"max=1024" json:"cursor"`
+}
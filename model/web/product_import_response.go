@@ -0,0 +1,22 @@
+package web
+
+type ProductImportResponse struct {
+	Format          string               `json:"format"`
+	Key             string               `json:"key"`
+	Rows            int                  `json:"rows"`
+	Created         int                  `json:"created"`
+	Updated         int                  `json:"updated"`
+	Failed          int                  `json:"failed"`
+	Errors          []ProductImportError `json:"errors"`
+	ErrorsTruncated bool                 `json:"errors_truncated,omitempty"`
+}
+
+// ProductImportError reports a row that was skipped. Line is the line of the
+// upload the row starts on. The service sets Err; Status and Error are its
+// HTTP rendering.
+type ProductImportError struct {
+	Line   int              `json:"line"`
+	Status int              `json:"status"`
+	Error  *ProblemResponse `json:"error,omitempty"`
+	Err    error            `json:"-"`
+}
@@ -0,0 +1,7 @@
+package web
+
+type ProductListResponse struct {
+	Products   []ProductResponse `json:"products"`
+	Pagination *Pagination       `json:"pagination,omitempty"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,20 @@
+package web
+
+import "encoding/json"
+
+const (
+	PatchTypeMerge = "application/merge-patch+json"
+	PatchTypeJSON  = "application/json-patch+json"
+)
+
+// ProductPatchRequest changes part of a product. Patch is a JSON Merge Patch
+// or a JSON Patch, as named by Type, applied to the product as ProductResponse
+// renders it.
+type ProductPatchRequest struct {
+	Id    int             `validate:"required" json:"id"`
+	Type  string          `validate:"required,oneof=application/merge-patch+json application/json-patch+json" json:"type"`
+	Patch json.RawMessage `validate:"required" json:"patch"`
+	// ExpectedVersions, when set, are the versions the caller accepts the
+	// product to be at, as sent in If-Match.
+	ExpectedVersions []int `json:"-"`
+}
@@ -1,7 +1,24 @@
 package web
 
+import (
+	"bubblevy/restful-api/money"
+	"time"
+)
+
 type ProductResponse struct {
-	Id          int    `json:"id"`
-	ProductName string `json:"product_name"`
-	Price       int    `json:"price"`
+	Id          int         `json:"id"`
+	ProductName string      `json:"product_name"`
+	Price       money.Money `json:"price"`
+	Version     int         `json:"version"`
+	DeletedAt   *time.Time  `json:"deleted_at,omitempty"`
+}
+
+// ProductResponseV1 is a product as v1 clients know it, with the price in
+// whole units and no currency.
+type ProductResponseV1 struct {
+	Id          int        `json:"id"`
+	ProductName string     `json:"product_name"`
+	Price       int64      `json:"price"`
+	Version     int        `json:"version"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
 }
@@ -0,0 +1,15 @@
+package web
+
+type ProductRevisionFindAllRequest struct {
+	Id      int `validate:"required" json:"id"`
+	Page    int `validate:"omitempty,min=1" json:"page"`
+	PerPage int `validate:"omitempty,min=1,max=100" json:"per_page"`
+}
+
+// ProductRevertRequest brings a product back to the name and price it had at
+// Revision, as a new revision.
+type ProductRevertRequest struct {
+	Id               int   `validate:"required" json:"id"`
+	Revision         int   `validate:"required,min=1" json:"revision"`
+	ExpectedVersions []int `json:"-"`
+}
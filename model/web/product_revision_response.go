@@ -0,0 +1,20 @@
+package web
+
+import "time"
+
+// ProductRevisionResponse is a product as one write left it; its version is
+// the revision number.
+type ProductRevisionResponse struct {
+	ProductResponse
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+type ProductRevisionResponseV1 struct {
+	ProductResponseV1
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+type ProductRevisionListResponse struct {
+	Revisions  []ProductRevisionResponse `json:"revisions"`
+	Pagination *Pagination               `json:"pagination,omitempty"`
+}
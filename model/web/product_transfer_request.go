@@ -0,0 +1,24 @@
+package web
+
+import "io"
+
+const (
+	TransferFormatCSV    = "csv"
+	TransferFormatNDJSON = "ndjson"
+
+	ImportKeyId          = "id"
+	ImportKeyProductName = "product_name"
+)
+
+type ProductExportRequest struct {
+	Format string `validate:"required,oneof=csv ndjson" json:"format"`
+}
+
+// ProductImportRequest carries an upload to import. Key picks how rows find
+// the product they update: by id, or by product_name so that an export can be
+// loaded into a catalog whose ids differ.
+type ProductImportRequest struct {
+	Format string    `validate:"required,oneof=csv ndjson" json:"format"`
+	Key    string    `validate:"omitempty,oneof=id product_name" json:"key"`
+	Body   io.Reader `validate:"-" json:"-"`
+}
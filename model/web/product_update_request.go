@@ -1,7 +1,12 @@
 package web
 
+import "bubblevy/restful-api/money"
+
 type ProductUpdateRequest struct {
-	Id          int    `validate:"required"`
-	ProductName string `validate:"required,max=255,min=1" json:"product_name"`
-	Price       int    `validate:"required" json:"price"`
+	Id          int         `validate:"required"`
+	ProductName string      `validate:"required,max=255,min=1" json:"product_name"`
+	Price       money.Money `validate:"required" json:"price"`
+	// ExpectedVersions, when set, are the versions the caller accepts the
+	// product to be at, as sent in If-Match.
+	ExpectedVersions []int `json:"-"`
 }
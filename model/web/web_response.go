@@ -1,8 +1,10 @@
 package web
 
 type WebResponse struct {
-	Code    int         `json:"code"`
-	Error   bool        `json:"error"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data"`
+	Code       int         `json:"code"`
+	Error      bool        `json:"error"`
+	Message    string      `json:"message"`
+	Data       interface{} `json:"data"`
+	Pagination *Pagination `json:"pagination,omitempty"`
+	NextCursor string      `json:"next_cursor,omitempty"`
 }
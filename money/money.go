@@ -0,0 +1,211 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the currency a price given as a plain integer is in:
+// whole rupiah, as every price was before products had a currency.
+const DefaultCurrency = "IDR"
+
+var (
+	ErrUnknownCurrency = errors.New("unknown currency")
+	ErrInvalidAmount   = errors.New("invalid amount")
+)
+
+// Money is an amount counted in the minor unit of its currency, e.g. 1250
+// USD is 12.50 dollars, so it never goes through a float. Two values are
+// equal when both the amount and the currency are.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// New returns amount minor units of currency.
+func New(amount int64, currency string) (Money, error) {
+	if _, ok := LookupCurrency(currency); !ok {
+		return Money{}, fmt.Errorf("%w: %q", ErrUnknownCurrency, currency)
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// FromMajor returns units whole units of currency.
+func FromMajor(units int64, currency string) (Money, error) {
+	found, ok := LookupCurrency(currency)
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %q", ErrUnknownCurrency, currency)
+	}
+	scale := found.scale()
+	if units > maxAmount/scale || units < -maxAmount/scale {
+		return Money{}, fmt.Errorf("%w: %d %s is out of range", ErrInvalidAmount, units, currency)
+	}
+	return Money{Amount: units * scale, Currency: currency}, nil
+}
+
+// Legacy is the money a v1 price stands for: whole units of DefaultCurrency.
+func Legacy(units int64) (Money, error) {
+	return FromMajor(units, DefaultCurrency)
+}
+
+const maxAmount = int64(^uint64(0) >> 1)
+
+// Parse reads a decimal amount such as "12.50" or "-3" in currency. Digits
+// beyond the minor unit of the currency are rounded half to even, so "0.125"
+// USD is 0.12 and "0.135" USD is 0.14.
+func Parse(amount string, currency string) (Money, error) {
+	found, ok := LookupCurrency(currency)
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %q", ErrUnknownCurrency, currency)
+	}
+
+	invalid := fmt.Errorf("%w: %q", ErrInvalidAmount, amount)
+	digits, negative := strings.CutPrefix(amount, "-")
+	whole, fraction, hasPoint := strings.Cut(digits, ".")
+	if !isDigits(whole) || (hasPoint && !isDigits(fraction)) {
+		return Money{}, invalid
+	}
+
+	var rest string
+	if len(fraction) > found.MinorUnits {
+		fraction, rest = fraction[:found.MinorUnits], fraction[found.MinorUnits:]
+	} else {
+		fraction += strings.Repeat("0", found.MinorUnits-len(fraction))
+	}
+	value, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return Money{}, invalid
+	}
+	if roundsUp(rest, value%2 == 1) {
+		if value == maxAmount {
+			return Money{}, invalid
+		}
+		value++
+	}
+
+	if negative {
+		value = -value
+	}
+	return Money{Amount: value, Currency: currency}, nil
+}
+
+func isDigits(value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// roundsUp reports whether dropping the digits of rest rounds half to even
+// away from zero, given whether the kept value is odd.
+func roundsUp(rest string, odd bool) bool {
+	if rest == "" {
+		return false
+	}
+	switch {
+	case rest[0] > '5':
+		return true
+	case rest[0] < '5':
+		return false
+	case strings.Trim(rest[1:], "0") != "":
+		return true
+	}
+	return odd
+}
+
+// Valid reports whether the currency is a known one. The zero value is not
+// valid.
+func (money Money) Valid() bool {
+	_, ok := LookupCurrency(money.Currency)
+	return ok
+}
+
+// Decimal writes the amount in whole units with every digit of the minor
+// unit, e.g. "12.50" for 1250 USD and "1250" for 1250 JPY.
+func (money Money) Decimal() string {
+	currency, _ := LookupCurrency(money.Currency)
+	digits := strconv.FormatInt(money.Amount, 10)
+	sign := ""
+	if money.Amount < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	if currency.MinorUnits == 0 {
+		return sign + digits
+	}
+	if len(digits) <= currency.MinorUnits {
+		digits = strings.Repeat("0", currency.MinorUnits-len(digits)+1) + digits
+	}
+	point := len(digits) - currency.MinorUnits
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+func (money Money) String() string {
+	return money.Decimal() + " " + money.Currency
+}
+
+// Major is the amount in whole units, rounded half to even, as v1 clients
+// receive it.
+func (money Money) Major() int64 {
+	currency, _ := LookupCurrency(money.Currency)
+	scale := currency.scale()
+	quotient, remainder := money.Amount/scale, money.Amount%scale
+	if remainder < 0 {
+		remainder = -remainder
+	}
+	if remainder*2 > scale || (remainder*2 == scale && quotient%2 != 0) {
+		if money.Amount < 0 {
+			return quotient - 1
+		}
+		return quotient + 1
+	}
+	return quotient
+}
+
+type moneyJSON struct {
+	Amount   *string `json:"amount"`
+	Currency string  `json:"currency"`
+}
+
+// MarshalJSON writes {"amount":"12.50","currency":"USD"}. The amount is a
+// string so clients never read it into a float.
+func (money Money) MarshalJSON() ([]byte, error) {
+	amount := money.Decimal()
+	return json.Marshal(moneyJSON{Amount: &amount, Currency: money.Currency})
+}
+
+// UnmarshalJSON reads the form MarshalJSON writes, or a plain integer, which
+// is a v1 price in whole units of DefaultCurrency.
+func (money *Money) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	if !strings.HasPrefix(strings.TrimSpace(string(data)), "{") {
+		var units int64
+		err := json.Unmarshal(data, &units)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidAmount, data)
+		}
+		*money, err = Legacy(units)
+		return err
+	}
+
+	var fields moneyJSON
+	err := json.Unmarshal(data, &fields)
+	if err != nil {
+		return err
+	}
+	if fields.Amount == nil {
+		return fmt.Errorf("%w: amount is missing", ErrInvalidAmount)
+	}
+	*money, err = Parse(*fields.Amount, fields.Currency)
+	return err
+}
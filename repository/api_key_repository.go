@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/model/domain"
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrApiKeyNotFound = errors.New("api key not found")
+
+type ApiKeyRepository interface {
+	Save(ctx context.Context, tx helper.Tx, apiKey domain.ApiKey) (domain.ApiKey, error)
+	Revoke(ctx context.Context, tx helper.Tx, apiKey domain.ApiKey, revokedAt time.Time) error
+	TouchLastUsed(ctx context.Context, tx helper.Tx, apiKey domain.ApiKey, usedAt time.Time) error
+	FindById(ctx context.Context, tx helper.Tx, apiKeyId int) (domain.ApiKey, error)
+	FindByHash(ctx context.Context, tx helper.Tx, keyHash string) (domain.ApiKey, error)
+	FindAll(ctx context.Context, tx helper.Tx) ([]domain.ApiKey, error)
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/model/domain"
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+type apiKeyRepositoryImpl struct {
+	dialect Dialect
+}
+
+func NewApiKeyRepository() ApiKeyRepository {
+	return &apiKeyRepositoryImpl{dialect: DialectMySQL}
+}
+
+func NewPostgresApiKeyRepository() ApiKeyRepository {
+	return &apiKeyRepositoryImpl{dialect: DialectPostgres}
+}
+
+func NewSQLiteApiKeyRepository() ApiKeyRepository {
+	return &apiKeyRepositoryImpl{dialect: DialectSQLite}
+}
+
+const apiKeyColumns = "id, name, owner, key_prefix, key_hash, scopes, expires_at, last_used_at, revoked_at, created_at"
+
+func (repository *apiKeyRepositoryImpl) Save(ctx context.Context, tx helper.Tx, apiKey domain.ApiKey) (domain.ApiKey, error) {
+	sqlTx, err := toSQLTx(tx)
+	if err != nil {
+		return domain.ApiKey{}, err
+	}
+
+	query := "INSERT INTO api_keys(name, owner, key_prefix, key_hash, scopes, expires_at, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	id, err := repository.dialect.insert(ctx, sqlTx, query, apiKey.Name, apiKey.Owner, apiKey.KeyPrefix, apiKey.KeyHash, strings.Join(apiKey.Scopes, ","), apiKey.ExpiresAt, apiKey.CreatedAt)
+	if err != nil {
+		return apiKey, err
+	}
+
+	apiKey.Id = id
+	return apiKey, nil
+}
+
+func (repository *apiKeyRepositoryImpl) Revoke(ctx context.Context, tx helper.Tx, apiKey domain.ApiKey, revokedAt time.Time) error {
+	sqlTx, err := toSQLTx(tx)
+	if err != nil {
+		return err
+	}
+
+	query := "UPDATE api_keys SET revoked_at = ? WHERE id = ?"
+	_, err = sqlTx.ExecContext(ctx, repository.dialect.Rebind(query), revokedAt, apiKey.Id)
+	return err
+}
+
+func (repository *apiKeyRepositoryImpl) TouchLastUsed(ctx context.Context, tx helper.Tx, apiKey domain.ApiKey, usedAt time.Time) error {
+	sqlTx, err := toSQLTx(tx)
+	if err != nil {
+		return err
+	}
+
+	query := "UPDATE api_keys SET last_used_at = ? WHERE id = ?"
+	_, err = sqlTx.ExecContext(ctx, repository.dialect.Rebind(query), usedAt, apiKey.Id)
+	return err
+}
+
+func (repository *apiKeyRepositoryImpl) FindById(ctx context.Context, tx helper.Tx, apiKeyId int) (domain.ApiKey, error) {
+	sqlTx, err := toSQLTx(tx)
+	if err != nil {
+		return domain.ApiKey{}, err
+	}
+
+	query := "SELECT " + apiKeyColumns + " FROM api_keys WHERE id = ?"
+	return findOneApiKey(ctx, sqlTx, repository.dialect.Rebind(query), apiKeyId)
+}
+
+func (repository *apiKeyRepositoryImpl) FindByHash(ctx context.Context, tx helper.Tx, keyHash string) (domain.ApiKey, error) {
+	sqlTx, err := toSQLTx(tx)
+	if err != nil {
+		return domain.ApiKey{}, err
+	}
+
+	query := "SELECT " + apiKeyColumns + " FROM api_keys WHERE key_hash = ?"
+	return findOneApiKey(ctx, sqlTx, repository.dialect.Rebind(query), keyHash)
+}
+
+func (repository *apiKeyRepositoryImpl) FindAll(ctx context.Context, tx helper.Tx) ([]domain.ApiKey, error) {
+	sqlTx, err := toSQLTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT " + apiKeyColumns + " FROM api_keys ORDER BY id"
+	rows, err := sqlTx.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	apiKeys := []domain.ApiKey{}
+	for rows.Next() {
+		apiKey, err := scanApiKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		apiKeys = append(apiKeys, apiKey)
+	}
+	return apiKeys, rows.Err()
+}
+
+func findOneApiKey(ctx context.Context, sqlTx *sql.Tx, query string, args ...interface{}) (domain.ApiKey, error) {
+	rows, err := sqlTx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return domain.ApiKey{}, err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		return scanApiKey(rows)
+	} else {
+		return domain.ApiKey{}, ErrApiKeyNotFound
+	}
+}
+
+func scanApiKey(rows *sql.Rows) (domain.ApiKey, error) {
+	apiKey := domain.ApiKey{}
+	var scopes string
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+	err := rows.Scan(&apiKey.Id, &apiKey.Name, &apiKey.Owner, &apiKey.KeyPrefix, &apiKey.KeyHash, &scopes, &expiresAt, &lastUsedAt, &revokedAt, &apiKey.CreatedAt)
+	if err != nil {
+		return apiKey, err
+	}
+
+	if scopes != "" {
+		apiKey.Scopes = strings.Split(scopes, ",")
+	}
+	apiKey.ExpiresAt = nullTimePointer(expiresAt)
+	apiKey.LastUsedAt = nullTimePointer(lastUsedAt)
+	apiKey.RevokedAt = nullTimePointer(revokedAt)
+	return apiKey, nil
+}
+
+func nullTimePointer(value sql.NullTime) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Time
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/model/domain"
+	"context"
+	"slices"
+	"time"
+)
+
+type apiKeyRepositoryMemory struct {
+}
+
+// NewMemoryApiKeyRepository stores API keys in the MemoryStore that opened the
+// transaction.
+func NewMemoryApiKeyRepository() ApiKeyRepository {
+	return &apiKeyRepositoryMemory{}
+}
+
+func (repository *apiKeyRepositoryMemory) Save(ctx context.Context, tx helper.Tx, apiKey domain.ApiKey) (domain.ApiKey, error) {
+	memoryTx, err := toMemoryTx(tx)
+	if err != nil {
+		return apiKey, err
+	}
+
+	state := memoryTx.write()
+	apiKey.Id = state.nextApiKeyId
+	state.nextApiKeyId++
+	state.apiKeys[apiKey.Id] = apiKey
+	return apiKey, nil
+}
+
+func (repository *apiKeyRepositoryMemory) Revoke(ctx context.Context, tx helper.Tx, apiKey domain.ApiKey, revokedAt time.Time) error {
+	memoryTx, err := toMemoryTx(tx)
+	if err != nil {
+		return err
+	}
+
+	state := memoryTx.write()
+	if stored, ok := state.apiKeys[apiKey.Id]; ok {
+		stored.RevokedAt = &revokedAt
+		state.apiKeys[apiKey.Id] = stored
+	}
+	return nil
+}
+
+func (repository *apiKeyRepositoryMemory) TouchLastUsed(ctx context.Context, tx helper.Tx, apiKey domain.ApiKey, usedAt time.Time) error {
+	memoryTx, err := toMemoryTx(tx)
+	if err != nil {
+		return err
+	}
+
+	state := memoryTx.write()
+	if stored, ok := state.apiKeys[apiKey.Id]; ok {
+		stored.LastUsedAt = &usedAt
+		state.apiKeys[apiKey.Id] = stored
+	}
+	return nil
+}
+
+func (repository *apiKeyRepositoryMemory) FindById(ctx context.Context, tx helper.Tx, apiKeyId int) (domain.ApiKey, error) {
+	memoryTx, err := toMemoryTx(tx)
+	if err != nil {
+		return domain.ApiKey{}, err
+	}
+
+	apiKey, ok := memoryTx.state.apiKeys[apiKeyId]
+	if !ok {
+		return domain.ApiKey{}, ErrApiKeyNotFound
+	}
+	return apiKey, nil
+}
+
+func (repository *apiKeyRepositoryMemory) FindByHash(ctx context.Context, tx helper.Tx, keyHash string) (domain.ApiKey, error) {
+	memoryTx, err := toMemoryTx(tx)
+	if err != nil {
+		return domain.ApiKey{}, err
+	}
+
+	for _, apiKey := range memoryTx.state.apiKeys {
+		if apiKey.KeyHash == keyHash {
+			return apiKey, nil
+		}
+	}
+	return domain.ApiKey{}, ErrApiKeyNotFound
+}
+
+func (repository *apiKeyRepositoryMemory) FindAll(ctx context.Context, tx helper.Tx) ([]domain.ApiKey, error) {
+	memoryTx, err := toMemoryTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKeys := []domain.ApiKey{}
+	for _, apiKey := range memoryTx.state.apiKeys {
+		apiKeys = append(apiKeys, apiKey)
+	}
+	slices.SortFunc(apiKeys, func(a, b domain.ApiKey) int {
+		return a.Id - b.Id
+	})
+	return apiKeys, nil
+}
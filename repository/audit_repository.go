@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/model/domain"
+	"context"
+)
+
+// AuditRepository appends to the audit log and reads it back. Entries are
+// never changed once saved.
+type AuditRepository interface {
+	SaveAll(ctx context.Context, tx helper.Tx, entries []domain.AuditEntry) ([]domain.AuditEntry, error)
+	FindAllByQuery(ctx context.Context, tx helper.Tx, query domain.AuditQuery) ([]domain.AuditEntry, error)
+	CountByFilter(ctx context.Context, tx helper.Tx, filter domain.AuditFilter) (int, error)
+}
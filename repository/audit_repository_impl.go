@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/model/domain"
+	"context"
+	"database/sql"
+	"encoding/json"
+)
+
+type auditRepositoryImpl struct {
+	dialect Dialect
+}
+
+func NewAuditRepository() AuditRepository {
+	return &auditRepositoryImpl{dialect: DialectMySQL}
+}
+
+func NewPostgresAuditRepository() AuditRepository {
+	return &auditRepositoryImpl{dialect: DialectPostgres}
+}
+
+func NewSQLiteAuditRepository() AuditRepository {
+	return &auditRepositoryImpl{dialect: DialectSQLite}
+}
+
+const auditEntryColumns = "id, actor, action, product_id, before_snapshot, after_snapshot, request_id, created_at"
+
+// auditKeyColumns tell apart the entries of one SaveAll; entries alike in
+// all of them differ at most in created_at.
+var auditKeyColumns = []string{"actor", "action", "product_id", "before_snapshot", "after_snapshot", "request_id"}
+
+func (repository *auditRepositoryImpl) SaveAll(ctx context.Context, tx helper.Tx, entries []domain.AuditEntry) ([]domain.AuditEntry, error) {
+	sqlTx, err := toSQLTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := []string{"actor", "action", "product_id", "before_snapshot", "after_snapshot", "request_id", "created_at"}
+	saved := make([]domain.AuditEntry, 0, len(entries))
+	for start := 0; start < len(entries); start += insertBatchSize {
+		batch := entries[start:min(start+insertBatchSize, len(entries))]
+		rows := make([][]interface{}, 0, len(batch))
+		for _, entry := range batch {
+			rows = append(rows, []interface{}{entry.Actor, entry.Action, entry.ProductId, snapshotValue(entry.Before), snapshotValue(entry.After), entry.RequestId, entry.CreatedAt})
+		}
+
+		ids, err := repository.dialect.insertRows(ctx, sqlTx, "audit_entries", columns, rows, auditKeyColumns)
+		if err != nil {
+			return nil, err
+		}
+		for i, entry := range batch {
+			entry.Id = ids[i]
+			saved = append(saved, entry)
+		}
+	}
+	return saved, nil
+}
+
+func (repository *auditRepositoryImpl) FindAllByQuery(ctx context.Context, tx helper.Tx, query domain.AuditQuery) ([]domain.AuditEntry, error) {
+	sqlTx, err := toSQLTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	where, args := auditWhereClause(query.Filter)
+	if query.AfterId != 0 {
+		where = appendCondition(where, "id > ?")
+		args = append(args, query.AfterId)
+	}
+	orderBy := " ORDER BY id ASC"
+	if query.NewestFirst {
+		orderBy = " ORDER BY id DESC"
+	}
+
+	sqlQuery := "SELECT " + auditEntryColumns + " FROM audit_entries" + where + orderBy + " LIMIT ? OFFSET ?"
+	args = append(args, query.Limit, query.Offset)
+
+	rows, err := sqlTx.QueryContext(ctx, repository.dialect.Rebind(sqlQuery), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []domain.AuditEntry{}
+	for rows.Next() {
+		entry := domain.AuditEntry{}
+		var before, after sql.NullString
+		err := rows.Scan(&entry.Id, &entry.Actor, &entry.Action, &entry.ProductId, &before, &after, &entry.RequestId, &entry.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		entry.Before = snapshotOf(before)
+		entry.After = snapshotOf(after)
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+func (repository *auditRepositoryImpl) CountByFilter(ctx context.Context, tx helper.Tx, filter domain.AuditFilter) (int, error) {
+	sqlTx, err := toSQLTx(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	where, args := auditWhereClause(filter)
+	var total int
+	err = sqlTx.QueryRowContext(ctx, repository.dialect.Rebind("SELECT COUNT(*) FROM audit_entries"+where), args...).Scan(&total)
+	return total, err
+}
+
+func auditWhereClause(filter domain.AuditFilter) (string, []interface{}) {
+	var where string
+	var args []interface{}
+
+	if filter.ProductId != nil {
+		where = appendCondition(where, "product_id = ?")
+		args = append(args, *filter.ProductId)
+	}
+	if filter.Actor != "" {
+		where = appendCondition(where, "actor = ?")
+		args = append(args, filter.Actor)
+	}
+	if filter.From != nil {
+		where = appendCondition(where, "created_at >= ?")
+		args = append(args, *filter.From)
+	}
+	if filter.To != nil {
+		where = appendCondition(where, "created_at < ?")
+		args = append(args, *filter.To)
+	}
+	return where, args
+}
+
+func appendCondition(where string, condition string) string {
+	if where == "" {
+		return " WHERE " + condition
+	}
+	return where + " AND " + condition
+}
+
+// snapshotValue stores a missing snapshot as NULL; snapshots are written as
+// text so every driver sends them the same way.
+func snapshotValue(snapshot json.RawMessage) sql.NullString {
+	return sql.NullString{String: string(snapshot), Valid: snapshot != nil}
+}
+
+func snapshotOf(value sql.NullString) json.RawMessage {
+	if !value.Valid {
+		return nil
+	}
+	return json.RawMessage(value.String)
+}
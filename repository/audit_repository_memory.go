@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/model/domain"
+	"context"
+	"slices"
+)
+
+type auditRepositoryMemory struct {
+}
+
+// NewMemoryAuditRepository keeps the audit log in the MemoryStore that opened
+// the transaction.
+func NewMemoryAuditRepository() AuditRepository {
+	return &auditRepositoryMemory{}
+}
+
+func (repository *auditRepositoryMemory) SaveAll(ctx context.Context, tx helper.Tx, entries []domain.AuditEntry) ([]domain.AuditEntry, error) {
+	memoryTx, err := toMemoryTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	state := memoryTx.write()
+	saved := make([]domain.AuditEntry, 0, len(entries))
+	for _, entry := range entries {
+		entry.Id = state.nextAuditEntryId
+		state.nextAuditEntryId++
+		state.auditEntries = append(state.auditEntries, entry)
+		saved = append(saved, entry)
+	}
+	return saved, nil
+}
+
+func (repository *auditRepositoryMemory) FindAllByQuery(ctx context.Context, tx helper.Tx, query domain.AuditQuery) ([]domain.AuditEntry, error) {
+	memoryTx, err := toMemoryTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []domain.AuditEntry{}
+	for _, entry := range memoryTx.state.auditEntries {
+		if matchesAuditFilter(entry, query.Filter) && entry.Id > query.AfterId {
+			entries = append(entries, entry)
+		}
+	}
+	if query.NewestFirst {
+		slices.Reverse(entries)
+	}
+
+	if query.Offset >= len(entries) {
+		return []domain.AuditEntry{}, nil
+	}
+	entries = entries[query.Offset:]
+	if query.Limit > 0 && query.Limit < len(entries) {
+		entries = entries[:query.Limit]
+	}
+	return entries, nil
+}
+
+func (repository *auditRepositoryMemory) CountByFilter(ctx context.Context, tx helper.Tx, filter domain.AuditFilter) (int, error) {
+	memoryTx, err := toMemoryTx(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, entry := range memoryTx.state.auditEntries {
+		if matchesAuditFilter(entry, filter) {
+			total++
+		}
+	}
+	return total, nil
+}
+
+func matchesAuditFilter(entry domain.AuditEntry, filter domain.AuditFilter) bool {
+	if filter.ProductId != nil && entry.ProductId != *filter.ProductId {
+		return false
+	}
+	if filter.Actor != "" && entry.Actor != filter.Actor {
+		return false
+	}
+	if filter.From != nil && entry.CreatedAt.Before(*filter.From) {
+		return false
+	}
+	if filter.To != nil && !entry.CreatedAt.Before(*filter.To) {
+		return false
+	}
+	return true
+}
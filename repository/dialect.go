@@ -0,0 +1,234 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Dialect describes how the SQL repositories talk to a particular database.
+// Queries are written once with ? placeholders and rebound per dialect.
+type Dialect struct {
+	Name string
+
+	// numberedPlaceholders switches ? to $1, $2, ... as Postgres expects.
+	numberedPlaceholders bool
+	// returningId reads generated ids with INSERT ... RETURNING id instead of
+	// LastInsertId, which the Postgres driver does not implement and which
+	// only reports one id of a multi-row insert on SQLite.
+	returningId bool
+	// likeOperator matches names case-insensitively; LIKE already does on
+	// MySQL's default collation and on SQLite, Postgres needs ILIKE.
+	likeOperator string
+	// insertIgnore skips a row that collides with a unique key with MySQL's
+	// INSERT IGNORE; the others append ON CONFLICT DO NOTHING.
+	insertIgnore bool
+}
+
+var (
+	DialectMySQL    = Dialect{Name: "mysql", likeOperator: "LIKE", insertIgnore: true}
+	DialectPostgres = Dialect{Name: "postgres", numberedPlaceholders: true, returningId: true, likeOperator: "ILIKE"}
+	DialectSQLite   = Dialect{Name: "sqlite", returningId: true, likeOperator: "LIKE"}
+)
+
+// Rebind rewrites the ? placeholders of query into the dialect's syntax.
+func (dialect Dialect) Rebind(query string) string {
+	if !dialect.numberedPlaceholders {
+		return query
+	}
+
+	var builder strings.Builder
+	position := 0
+	quoted := false
+	for _, char := range query {
+		if char == '\'' {
+			quoted = !quoted
+		}
+		if char == '?' && !quoted {
+			position++
+			builder.WriteString("$" + strconv.Itoa(position))
+			continue
+		}
+		builder.WriteRune(char)
+	}
+	return builder.String()
+}
+
+// insert runs an INSERT statement and returns the id generated for the row.
+func (dialect Dialect) insert(ctx context.Context, sqlTx *sql.Tx, query string, args ...interface{}) (int, error) {
+	if dialect.returningId {
+		var id int
+		err := sqlTx.QueryRowContext(ctx, dialect.Rebind(query+" RETURNING id"), args...).Scan(&id)
+		return id, err
+	}
+
+	result, err := sqlTx.ExecContext(ctx, dialect.Rebind(query), args...)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+// insertBatchSize is the most rows a multi-row INSERT carries. The widest
+// batched table has 7 columns, so a batch binds at most 3500 placeholders,
+// well below SQLite's 32766 and the 65535 of MySQL and Postgres.
+const insertBatchSize = 500
+
+// insertRows runs a multi-row INSERT of rows into table and returns the ids
+// generated for them, in the order of rows. MySQL hands out the ids of one
+// INSERT as a sequence and reports the first of them; the sequence steps by
+// auto_increment_increment, which multi-primary setups raise above 1.
+// RETURNING promises no order, so the other dialects return keyColumns along
+// with each id and match it to the row holding the same values. Rows alike in
+// keyColumns have to be interchangeable to the caller.
+func (dialect Dialect) insertRows(ctx context.Context, sqlTx *sql.Tx, table string, columns []string, rows [][]interface{}, keyColumns []string) ([]int, error) {
+	query, args := multiRowInsert(table, columns, rows)
+
+	if dialect.returningId {
+		return dialect.insertReturning(ctx, sqlTx, query, args, columns, rows, keyColumns)
+	}
+
+	ids := make([]int, 0, len(rows))
+
+	var increment int
+	err := sqlTx.QueryRowContext(ctx, "SELECT @@auto_increment_increment").Scan(&increment)
+	if err != nil {
+		return nil, err
+	}
+	result, err := sqlTx.ExecContext(ctx, dialect.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	firstId, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		ids = append(ids, int(firstId)+i*increment)
+	}
+	return ids, nil
+}
+
+func (dialect Dialect) insertReturning(ctx context.Context, sqlTx *sql.Tx, query string, args []interface{}, columns []string, rows [][]interface{}, keyColumns []string) ([]int, error) {
+	positions := make([]int, 0, len(keyColumns))
+	for _, column := range keyColumns {
+		position := slices.Index(columns, column)
+		if position < 0 {
+			return nil, errors.New("key column " + column + " is not inserted")
+		}
+		positions = append(positions, position)
+	}
+
+	// the rows waiting for an id, by key, in the order they were given
+	waiting := map[string][]int{}
+	for i, row := range rows {
+		values := make([]interface{}, 0, len(positions))
+		for _, position := range positions {
+			values = append(values, row[position])
+		}
+		key, err := rowKey(values)
+		if err != nil {
+			return nil, err
+		}
+		waiting[key] = append(waiting[key], i)
+	}
+
+	result, err := sqlTx.QueryContext(ctx, dialect.Rebind(query+" RETURNING id, "+strings.Join(keyColumns, ", ")), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer result.Close()
+
+	ids := make([]int, len(rows))
+	var id int
+	returned := make([]sql.NullString, len(keyColumns))
+	destinations := []interface{}{&id}
+	for i := range returned {
+		destinations = append(destinations, &returned[i])
+	}
+	for result.Next() {
+		if err := result.Scan(destinations...); err != nil {
+			return nil, err
+		}
+		values := make([]interface{}, 0, len(returned))
+		for _, value := range returned {
+			values = append(values, value)
+		}
+		key, err := rowKey(values)
+		if err != nil {
+			return nil, err
+		}
+		if len(waiting[key]) == 0 {
+			return nil, errors.New("RETURNING gave back a row matching none inserted")
+		}
+		ids[waiting[key][0]] = id
+		waiting[key] = waiting[key][1:]
+	}
+	return ids, result.Err()
+}
+
+// rowKey renders values as text the way they read back into sql.NullString,
+// so a row as inserted and as returned give the same key.
+func rowKey(values []interface{}) (string, error) {
+	var builder strings.Builder
+	for _, value := range values {
+		if valuer, ok := value.(driver.Valuer); ok {
+			var err error
+			value, err = valuer.Value()
+			if err != nil {
+				return "", err
+			}
+		}
+		switch value := value.(type) {
+		case nil:
+			builder.WriteString("NULL")
+		case string:
+			builder.WriteString(strconv.Quote(value))
+		case int:
+			builder.WriteString(strconv.Quote(strconv.Itoa(value)))
+		case int64:
+			builder.WriteString(strconv.Quote(strconv.FormatInt(value, 10)))
+		default:
+			return "", fmt.Errorf("unsupported key value %T", value)
+		}
+		builder.WriteByte(',')
+	}
+	return builder.String(), nil
+}
+
+// multiRowInsert builds one INSERT statement for rows and the arguments it
+// binds.
+func multiRowInsert(table string, columns []string, rows [][]interface{}) (string, []interface{}) {
+	placeholders := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")"
+	values := make([]string, 0, len(rows))
+	args := make([]interface{}, 0, len(rows)*len(columns))
+	for _, row := range rows {
+		values = append(values, placeholders)
+		args = append(args, row...)
+	}
+	return "INSERT INTO " + table + "(" + strings.Join(columns, ", ") + ") VALUES " + strings.Join(values, ", "), args
+}
+
+// insertUnique runs an INSERT of a single row and reports whether it was
+// stored. A row whose unique key is already taken is skipped, not an error,
+// and a concurrent insert of the same key waits for the other transaction.
+func (dialect Dialect) insertUnique(ctx context.Context, sqlTx *sql.Tx, query string, args ...interface{}) (bool, error) {
+	if dialect.insertIgnore {
+		query = "INSERT IGNORE" + strings.TrimPrefix(query, "INSERT")
+	} else {
+		query += " ON CONFLICT DO NOTHING"
+	}
+
+	result, err := sqlTx.ExecContext(ctx, dialect.Rebind(query), args...)
+	if err != nil {
+		return false, err
+	}
+	inserted, err := result.RowsAffected()
+	return inserted == 1, err
+}
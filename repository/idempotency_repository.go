@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/model/domain"
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrIdempotencyRecordNotFound = errors.New("idempotency record not found")
+	// ErrIdempotencyKeyExists reports a Save whose owner and key are already
+	// stored, typically by a concurrent request that committed first.
+	ErrIdempotencyKeyExists = errors.New("idempotency key already exists")
+)
+
+// IdempotencyRepository stores the responses of requests sent with an
+// Idempotency-Key. A key belongs to the caller that sent it, so two callers
+// can pick the same one.
+type IdempotencyRepository interface {
+	Save(ctx context.Context, tx helper.Tx, record domain.IdempotencyRecord) error
+	Delete(ctx context.Context, tx helper.Tx, record domain.IdempotencyRecord) error
+	FindByKey(ctx context.Context, tx helper.Tx, owner string, key string) (domain.IdempotencyRecord, error)
+	// DeleteExpired removes up to limit records that expired at or before
+	// now and returns how many it removed.
+	DeleteExpired(ctx context.Context, tx helper.Tx, now time.Time, limit int) (int, error)
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/model/domain"
+	"context"
+	"strings"
+	"time"
+)
+
+type idempotencyRepositoryImpl struct {
+	dialect Dialect
+}
+
+func NewIdempotencyRepository() IdempotencyRepository {
+	return &idempotencyRepositoryImpl{dialect: DialectMySQL}
+}
+
+func NewPostgresIdempotencyRepository() IdempotencyRepository {
+	return &idempotencyRepositoryImpl{dialect: DialectPostgres}
+}
+
+func NewSQLiteIdempotencyRepository() IdempotencyRepository {
+	return &idempotencyRepositoryImpl{dialect: DialectSQLite}
+}
+
+func (repository *idempotencyRepositoryImpl) Save(ctx context.Context, tx helper.Tx, record domain.IdempotencyRecord) error {
+	sqlTx, err := toSQLTx(tx)
+	if err != nil {
+		return err
+	}
+
+	query := "INSERT INTO idempotency_keys(owner, idempotency_key, request_hash, status, body, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	inserted, err := repository.dialect.insertUnique(ctx, sqlTx, query, record.Owner, record.Key, record.RequestHash, record.Status, record.Body, record.CreatedAt, record.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	if !inserted {
+		return ErrIdempotencyKeyExists
+	}
+	return nil
+}
+
+func (repository *idempotencyRepositoryImpl) Delete(ctx context.Context, tx helper.Tx, record domain.IdempotencyRecord) error {
+	sqlTx, err := toSQLTx(tx)
+	if err != nil {
+		return err
+	}
+
+	query := "DELETE FROM idempotency_keys WHERE owner = ? AND idempotency_key = ?"
+	_, err = sqlTx.ExecContext(ctx, repository.dialect.Rebind(query), record.Owner, record.Key)
+	return err
+}
+
+func (repository *idempotencyRepositoryImpl) FindByKey(ctx context.Context, tx helper.Tx, owner string, key string) (domain.IdempotencyRecord, error) {
+	sqlTx, err := toSQLTx(tx)
+	if err != nil {
+		return domain.IdempotencyRecord{}, err
+	}
+
+	query := "SELECT owner, idempotency_key, request_hash, status, body, created_at, expires_at FROM idempotency_keys WHERE owner = ? AND idempotency_key = ?"
+	rows, err := sqlTx.QueryContext(ctx, repository.dialect.Rebind(query), owner, key)
+	if err != nil {
+		return domain.IdempotencyRecord{}, err
+	}
+	defer rows.Close()
+
+	record := domain.IdempotencyRecord{}
+	if rows.Next() {
+		err := rows.Scan(&record.Owner, &record.Key, &record.RequestHash, &record.Status, &record.Body, &record.CreatedAt, &record.ExpiresAt)
+		return record, err
+	} else {
+		return record, ErrIdempotencyRecordNotFound
+	}
+}
+
+func (repository *idempotencyRepositoryImpl) DeleteExpired(ctx context.Context, tx helper.Tx, now time.Time, limit int) (int, error) {
+	sqlTx, err := toSQLTx(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	query := "SELECT owner, idempotency_key FROM idempotency_keys WHERE expires_at <= ? ORDER BY expires_at LIMIT ?"
+	rows, err := sqlTx.QueryContext(ctx, repository.dialect.Rebind(query), now, limit)
+	if err != nil {
+		return 0, err
+	}
+	var keys []string
+	args := []interface{}{now}
+	for rows.Next() {
+		var owner, key string
+		if err := rows.Scan(&owner, &key); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		keys = append(keys, "(?, ?)")
+		args = append(args, owner, key)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	// expires_at is checked again, a key renewed since it was selected stays
+	query = "DELETE FROM idempotency_keys WHERE expires_at <= ? AND (owner, idempotency_key) IN (" + strings.Join(keys, ", ") + ")"
+	result, err := sqlTx.ExecContext(ctx, repository.dialect.Rebind(query), args...)
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := result.RowsAffected()
+	return int(deleted), err
+}
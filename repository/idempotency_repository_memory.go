@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/model/domain"
+	"context"
+	"time"
+)
+
+type idempotencyRepositoryMemory struct {
+}
+
+// NewMemoryIdempotencyRepository stores idempotency records in the
+// MemoryStore that opened the transaction.
+func NewMemoryIdempotencyRepository() IdempotencyRepository {
+	return &idempotencyRepositoryMemory{}
+}
+
+func idempotencyRecordKey(owner string, key string) string {
+	return owner + "\x00" + key
+}
+
+func (repository *idempotencyRepositoryMemory) Save(ctx context.Context, tx helper.Tx, record domain.IdempotencyRecord) error {
+	memoryTx, err := toMemoryTx(tx)
+	if err != nil {
+		return err
+	}
+
+	recordKey := idempotencyRecordKey(record.Owner, record.Key)
+	if _, ok := memoryTx.state.idempotencyRecords[recordKey]; ok {
+		return ErrIdempotencyKeyExists
+	}
+	memoryTx.write().idempotencyRecords[recordKey] = record
+	return nil
+}
+
+func (repository *idempotencyRepositoryMemory) Delete(ctx context.Context, tx helper.Tx, record domain.IdempotencyRecord) error {
+	memoryTx, err := toMemoryTx(tx)
+	if err != nil {
+		return err
+	}
+
+	delete(memoryTx.write().idempotencyRecords, idempotencyRecordKey(record.Owner, record.Key))
+	return nil
+}
+
+func (repository *idempotencyRepositoryMemory) FindByKey(ctx context.Context, tx helper.Tx, owner string, key string) (domain.IdempotencyRecord, error) {
+	memoryTx, err := toMemoryTx(tx)
+	if err != nil {
+		return domain.IdempotencyRecord{}, err
+	}
+
+	record, ok := memoryTx.state.idempotencyRecords[idempotencyRecordKey(owner, key)]
+	if !ok {
+		return domain.IdempotencyRecord{}, ErrIdempotencyRecordNotFound
+	}
+	return record, nil
+}
+
+func (repository *idempotencyRepositoryMemory) DeleteExpired(ctx context.Context, tx helper.Tx, now time.Time, limit int) (int, error) {
+	memoryTx, err := toMemoryTx(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for recordKey, record := range memoryTx.state.idempotencyRecords {
+		if deleted == limit {
+			break
+		}
+		if record.ExpiresAt.After(now) {
+			continue
+		}
+		delete(memoryTx.write().idempotencyRecords, recordKey)
+		deleted++
+	}
+	return deleted, nil
+}
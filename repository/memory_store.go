@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/model/domain"
+	"context"
+	"database/sql"
+	"errors"
+	"maps"
+	"slices"
+)
+
+var ErrMemoryTxRequired = errors.New("transaction was not opened on the memory store")
+
+// MemoryStore keeps every table in process memory for tests and local
+// development. Transactions run one at a time: each one works on a private
+// copy of the tables that replaces the shared state on commit and is dropped
+// on rollback. Transactions do not nest: a goroutine that begins a second one
+// before finishing the first waits for itself until its context ends.
+type MemoryStore struct {
+	// lock holds a token while a transaction is open; a channel rather than
+	// a mutex, so waiting for it can be given up when the context ends
+	lock  chan struct{}
+	state *memoryState
+}
+
+type memoryState struct {
+	products      map[int]domain.Product
+	nextProductId int
+	apiKeys       map[int]domain.ApiKey
+	nextApiKeyId  int
+
+	productRevisions map[int][]domain.ProductRevision
+
+	priceSchedules      map[int]domain.PriceSchedule
+	nextPriceScheduleId int
+
+	idempotencyRecords map[string]domain.IdempotencyRecord
+	auditEntries       []domain.AuditEntry
+	nextAuditEntryId   int
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		lock: make(chan struct{}, 1),
+		state: &memoryState{
+			products:      map[int]domain.Product{},
+			nextProductId: 1,
+			apiKeys:       map[int]domain.ApiKey{},
+			nextApiKeyId:  1,
+
+			productRevisions: map[int][]domain.ProductRevision{},
+
+			priceSchedules:      map[int]domain.PriceSchedule{},
+			nextPriceScheduleId: 1,
+
+			idempotencyRecords: map[string]domain.IdempotencyRecord{},
+			nextAuditEntryId:   1,
+		},
+	}
+}
+
+func (state *memoryState) clone() *memoryState {
+	copied := *state
+	copied.products = maps.Clone(state.products)
+	copied.apiKeys = maps.Clone(state.apiKeys)
+	copied.productRevisions = maps.Clone(state.productRevisions)
+	copied.priceSchedules = maps.Clone(state.priceSchedules)
+	copied.idempotencyRecords = maps.Clone(state.idempotencyRecords)
+	// the log only grows, so capping the capacity makes appends copy it
+	// instead of writing into the committed backing array
+	copied.auditEntries = slices.Clip(state.auditEntries)
+	return &copied
+}
+
+// BeginTx waits for the transaction before it to finish, or for ctx to end.
+func (store *MemoryStore) BeginTx(ctx context.Context) (helper.Tx, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	select {
+	case store.lock <- struct{}{}:
+		return &memoryTx{store: store, state: store.state}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Truncate empties every table and restarts the id sequences.
+func (store *MemoryStore) Truncate() {
+	store.lock <- struct{}{}
+	defer func() { <-store.lock }()
+
+	store.state = NewMemoryStore().state
+}
+
+func (store *MemoryStore) Close() error {
+	return nil
+}
+
+type memoryTx struct {
+	store *MemoryStore
+	state *memoryState
+	dirty bool
+	done  bool
+}
+
+// write returns the transaction's private copy of the tables, making it on the
+// first change so read-only transactions never copy anything.
+func (tx *memoryTx) write() *memoryState {
+	if !tx.dirty {
+		tx.state = tx.state.clone()
+		tx.dirty = true
+	}
+	return tx.state
+}
+
+func (tx *memoryTx) Commit() error {
+	if tx.done {
+		return sql.ErrTxDone
+	}
+	tx.done = true
+
+	if tx.dirty {
+		tx.store.state = tx.state
+	}
+	<-tx.store.lock
+	return nil
+}
+
+func (tx *memoryTx) Rollback() error {
+	if tx.done {
+		return sql.ErrTxDone
+	}
+	tx.done = true
+
+	<-tx.store.lock
+	return nil
+}
+
+func toMemoryTx(tx helper.Tx) (*memoryTx, error) {
+	memory, ok := tx.(*memoryTx)
+	if !ok || memory.done {
+		return nil, ErrMemoryTxRequired
+	}
+	return memory, nil
+}
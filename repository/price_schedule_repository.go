@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/model/domain"
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrPriceScheduleNotFound = errors.New("price schedule not found")
+	// ErrPriceScheduleConflict reports a schedule whose status moved on
+	// since it was read.
+	ErrPriceScheduleConflict = errors.New("price schedule was changed since it was read")
+)
+
+// PriceScheduleRepository stores the price schedules of products. Schedules
+// list by StartsAt; the schedules of a purged product go with it.
+type PriceScheduleRepository interface {
+	Save(ctx context.Context, tx helper.Tx, schedule domain.PriceSchedule) (domain.PriceSchedule, error)
+	// Update writes the status and previous price of schedule, provided its
+	// status is still fromStatus.
+	Update(ctx context.Context, tx helper.Tx, schedule domain.PriceSchedule, fromStatus string) error
+	FindById(ctx context.Context, tx helper.Tx, productId int, scheduleId int) (domain.PriceSchedule, error)
+	FindByProductId(ctx context.Context, tx helper.Tx, productId int) ([]domain.PriceSchedule, error)
+	FindOpenByProductIds(ctx context.Context, tx helper.Tx, productIds []int) ([]domain.PriceSchedule, error)
+	// FindDueProductIds returns the live products with a schedule due at
+	// `at`, by id.
+	FindDueProductIds(ctx context.Context, tx helper.Tx, at time.Time, limit int) ([]int, error)
+}
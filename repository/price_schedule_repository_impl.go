@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/model/domain"
+	"bubblevy/restful-api/money"
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+type priceScheduleRepositoryImpl struct {
+	dialect Dialect
+}
+
+func NewPriceScheduleRepository() PriceScheduleRepository {
+	return &priceScheduleRepositoryImpl{dialect: DialectMySQL}
+}
+
+func NewPostgresPriceScheduleRepository() PriceScheduleRepository {
+	return &priceScheduleRepositoryImpl{dialect: DialectPostgres}
+}
+
+func NewSQLitePriceScheduleRepository() PriceScheduleRepository {
+	return &priceScheduleRepositoryImpl{dialect: DialectSQLite}
+}
+
+const priceScheduleColumns = "id, product_id, price, currency, starts_at, ends_at, status, previous_price, previous_currency, created_at"
+
+func (repository *priceScheduleRepositoryImpl) Save(ctx context.Context, tx helper.Tx, schedule domain.PriceSchedule) (domain.PriceSchedule, error) {
+	sqlTx, err := toSQLTx(tx)
+	if err != nil {
+		return schedule, err
+	}
+
+	previousPrice, previousCurrency := nullMoney(schedule.PreviousPrice)
+	query := "INSERT INTO price_schedules(product_id, price, currency, starts_at, ends_at, status, previous_price, previous_currency, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	id, err := repository.dialect.insert(ctx, sqlTx, query, schedule.ProductId, schedule.Price.Amount, schedule.Price.Currency, schedule.StartsAt, schedule.EndsAt, schedule.Status, previousPrice, previousCurrency, schedule.CreatedAt)
+	if err != nil {
+		return schedule, err
+	}
+
+	schedule.Id = id
+	return schedule, nil
+}
+
+func (repository *priceScheduleRepositoryImpl) Update(ctx context.Context, tx helper.Tx, schedule domain.PriceSchedule, fromStatus string) error {
+	sqlTx, err := toSQLTx(tx)
+	if err != nil {
+		return err
+	}
+
+	previousPrice, previousCurrency := nullMoney(schedule.PreviousPrice)
+	query := "UPDATE price_schedules SET status = ?, previous_price = ?, previous_currency = ? WHERE id = ? AND status = ?"
+	result, err := sqlTx.ExecContext(ctx, repository.dialect.Rebind(query), schedule.Status, previousPrice, previousCurrency, schedule.Id, fromStatus)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrPriceScheduleConflict
+	}
+	return nil
+}
+
+func (repository *priceScheduleRepositoryImpl) FindById(ctx context.Context, tx helper.Tx, productId int, scheduleId int) (domain.PriceSchedule, error) {
+	query := "SELECT " + priceScheduleColumns + " FROM price_schedules WHERE id = ? AND product_id = ?"
+	schedules, err := repository.find(ctx, tx, query, scheduleId, productId)
+	if err != nil {
+		return domain.PriceSchedule{}, err
+	}
+	if len(schedules) == 0 {
+		return domain.PriceSchedule{}, ErrPriceScheduleNotFound
+	}
+	return schedules[0], nil
+}
+
+func (repository *priceScheduleRepositoryImpl) FindByProductId(ctx context.Context, tx helper.Tx, productId int) ([]domain.PriceSchedule, error) {
+	query := "SELECT " + priceScheduleColumns + " FROM price_schedules WHERE product_id = ? ORDER BY starts_at, id"
+	return repository.find(ctx, tx, query, productId)
+}
+
+func (repository *priceScheduleRepositoryImpl) FindOpenByProductIds(ctx context.Context, tx helper.Tx, productIds []int) ([]domain.PriceSchedule, error) {
+	if len(productIds) == 0 {
+		return []domain.PriceSchedule{}, nil
+	}
+
+	args := make([]interface{}, 0, len(productIds)+2)
+	for _, productId := range productIds {
+		args = append(args, productId)
+	}
+	args = append(args, domain.PriceScheduleScheduled, domain.PriceScheduleActive)
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(productIds)), ", ")
+	query := "SELECT " + priceScheduleColumns + " FROM price_schedules WHERE product_id IN (" + placeholders + ") AND status IN (?, ?) ORDER BY product_id, starts_at, id"
+	return repository.find(ctx, tx, query, args...)
+}
+
+func (repository *priceScheduleRepositoryImpl) FindDueProductIds(ctx context.Context, tx helper.Tx, at time.Time, limit int) ([]int, error) {
+	sqlTx, err := toSQLTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT DISTINCT s.product_id FROM price_schedules s JOIN products p ON p.id = s.product_id" +
+		" WHERE p.deleted_at IS NULL AND ((s.status = ? AND s.starts_at <= ?) OR (s.status = ? AND s.ends_at <= ?))" +
+		" ORDER BY s.product_id LIMIT ?"
+	rows, err := sqlTx.QueryContext(ctx, repository.dialect.Rebind(query), domain.PriceScheduleScheduled, at, domain.PriceScheduleActive, at, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	productIds := []int{}
+	for rows.Next() {
+		var productId int
+		if err := rows.Scan(&productId); err != nil {
+			return nil, err
+		}
+		productIds = append(productIds, productId)
+	}
+	return productIds, rows.Err()
+}
+
+func (repository *priceScheduleRepositoryImpl) find(ctx context.Context, tx helper.Tx, query string, args ...interface{}) ([]domain.PriceSchedule, error) {
+	sqlTx, err := toSQLTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := sqlTx.QueryContext(ctx, repository.dialect.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := []domain.PriceSchedule{}
+	for rows.Next() {
+		schedule := domain.PriceSchedule{}
+		var endsAt sql.NullTime
+		var previousPrice sql.NullInt64
+		var previousCurrency sql.NullString
+		err := rows.Scan(&schedule.Id, &schedule.ProductId, &schedule.Price.Amount, &schedule.Price.Currency, &schedule.StartsAt, &endsAt, &schedule.Status, &previousPrice, &previousCurrency, &schedule.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		schedule.EndsAt = nullTimePointer(endsAt)
+		if previousPrice.Valid {
+			schedule.PreviousPrice = &money.Money{Amount: previousPrice.Int64, Currency: previousCurrency.String}
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, rows.Err()
+}
+
+// nullMoney splits an optional price into its nullable columns.
+func nullMoney(price *money.Money) (interface{}, interface{}) {
+	if price == nil {
+		return nil, nil
+	}
+	return price.Amount, price.Currency
+}
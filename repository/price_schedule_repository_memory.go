@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/model/domain"
+	"cmp"
+	"context"
+	"slices"
+	"time"
+)
+
+type priceScheduleRepositoryMemory struct {
+}
+
+// NewMemoryPriceScheduleRepository keeps price schedules in the MemoryStore
+// that opened the transaction.
+func NewMemoryPriceScheduleRepository() PriceScheduleRepository {
+	return &priceScheduleRepositoryMemory{}
+}
+
+func (repository *priceScheduleRepositoryMemory) Save(ctx context.Context, tx helper.Tx, schedule domain.PriceSchedule) (domain.PriceSchedule, error) {
+	memoryTx, err := toMemoryTx(tx)
+	if err != nil {
+		return schedule, err
+	}
+
+	state := memoryTx.write()
+	schedule.Id = state.nextPriceScheduleId
+	state.nextPriceScheduleId++
+	state.priceSchedules[schedule.Id] = schedule
+	return schedule, nil
+}
+
+func (repository *priceScheduleRepositoryMemory) Update(ctx context.Context, tx helper.Tx, schedule domain.PriceSchedule, fromStatus string) error {
+	memoryTx, err := toMemoryTx(tx)
+	if err != nil {
+		return err
+	}
+
+	stored, ok := memoryTx.state.priceSchedules[schedule.Id]
+	if !ok || stored.Status != fromStatus {
+		return ErrPriceScheduleConflict
+	}
+	stored.Status = schedule.Status
+	stored.PreviousPrice = schedule.PreviousPrice
+	memoryTx.write().priceSchedules[schedule.Id] = stored
+	return nil
+}
+
+func (repository *priceScheduleRepositoryMemory) FindById(ctx context.Context, tx helper.Tx, productId int, scheduleId int) (domain.PriceSchedule, error) {
+	memoryTx, err := toMemoryTx(tx)
+	if err != nil {
+		return domain.PriceSchedule{}, err
+	}
+
+	schedule, ok := memoryTx.state.priceSchedules[scheduleId]
+	if !ok || schedule.ProductId != productId {
+		return domain.PriceSchedule{}, ErrPriceScheduleNotFound
+	}
+	return schedule, nil
+}
+
+func (repository *priceScheduleRepositoryMemory) FindByProductId(ctx context.Context, tx helper.Tx, productId int) ([]domain.PriceSchedule, error) {
+	return repository.findAll(tx, func(schedule domain.PriceSchedule) bool {
+		return schedule.ProductId == productId
+	})
+}
+
+func (repository *priceScheduleRepositoryMemory) FindOpenByProductIds(ctx context.Context, tx helper.Tx, productIds []int) ([]domain.PriceSchedule, error) {
+	return repository.findAll(tx, func(schedule domain.PriceSchedule) bool {
+		return schedule.Open() && slices.Contains(productIds, schedule.ProductId)
+	})
+}
+
+func (repository *priceScheduleRepositoryMemory) FindDueProductIds(ctx context.Context, tx helper.Tx, at time.Time, limit int) ([]int, error) {
+	memoryTx, err := toMemoryTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	productIds := []int{}
+	for _, schedule := range memoryTx.state.priceSchedules {
+		product, live := memoryTx.state.products[schedule.ProductId]
+		if !live || product.DeletedAt != nil || slices.Contains(productIds, schedule.ProductId) {
+			continue
+		}
+		if _, applied := schedule.Apply(product.Price, at); applied.Status != schedule.Status {
+			productIds = append(productIds, schedule.ProductId)
+		}
+	}
+	slices.Sort(productIds)
+	if limit < len(productIds) {
+		productIds = productIds[:limit]
+	}
+	return productIds, nil
+}
+
+func (repository *priceScheduleRepositoryMemory) findAll(tx helper.Tx, matches func(schedule domain.PriceSchedule) bool) ([]domain.PriceSchedule, error) {
+	memoryTx, err := toMemoryTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	schedules := []domain.PriceSchedule{}
+	for _, schedule := range memoryTx.state.priceSchedules {
+		if matches(schedule) {
+			schedules = append(schedules, schedule)
+		}
+	}
+	slices.SortFunc(schedules, func(a, b domain.PriceSchedule) int {
+		return cmp.Or(cmp.Compare(a.ProductId, b.ProductId), a.StartsAt.Compare(b.StartsAt), cmp.Compare(a.Id, b.Id))
+	})
+	return schedules, nil
+}
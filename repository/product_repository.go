@@ -1,15 +1,42 @@
 package repository
 
 import (
+	"bubblevy/restful-api/helper"
 	"bubblevy/restful-api/model/domain"
 	"context"
-	"database/sql"
+	"errors"
+	"time"
 )
 
+var (
+	ErrProductNotFound = errors.New("product not found")
+	// ErrProductVersionConflict reports an update or delete of a product
+	// whose version has moved on since it was read.
+	ErrProductVersionConflict  = errors.New("product was changed since it was read")
+	ErrProductRevisionNotFound = errors.New("product revision not found")
+)
+
+// ProductRepository stores products. Delete moves a product to the trash and
+// only Purge removes it; the finders skip the trash unless they say otherwise.
+// Every write also stores the product it leaves behind as a revision, numbered
+// by its version, and Purge drops the product's revisions and price schedules
+// with it.
 type ProductRepository interface {
-	Save(ctx context.Context, tx *sql.Tx, product domain.Product) domain.Product
-	Update(ctx context.Context, tx *sql.Tx, product domain.Product) domain.Product
-	Delete(ctx context.Context, tx *sql.Tx, product domain.Product)
-	FindById(ctx context.Context, tx *sql.Tx, productId int) (domain.Product, error)
-	FindAll(ctx context.Context, tx *sql.Tx) []domain.Product
+	Save(ctx context.Context, tx helper.Tx, product domain.Product) (domain.Product, error)
+	SaveAll(ctx context.Context, tx helper.Tx, products []domain.Product) ([]domain.Product, error)
+	Update(ctx context.Context, tx helper.Tx, product domain.Product) (domain.Product, error)
+	UpdateColumns(ctx context.Context, tx helper.Tx, product domain.Product, columns []string) (domain.Product, error)
+	Delete(ctx context.Context, tx helper.Tx, product domain.Product, deletedAt time.Time) (domain.Product, error)
+	Restore(ctx context.Context, tx helper.Tx, product domain.Product) (domain.Product, error)
+	Purge(ctx context.Context, tx helper.Tx, product domain.Product) error
+	FindById(ctx context.Context, tx helper.Tx, productId int) (domain.Product, error)
+	FindDeletedById(ctx context.Context, tx helper.Tx, productId int) (domain.Product, error)
+	FindByProductName(ctx context.Context, tx helper.Tx, productName string) ([]domain.Product, error)
+	FindAll(ctx context.Context, tx helper.Tx) ([]domain.Product, error)
+	FindAllByQuery(ctx context.Context, tx helper.Tx, query domain.ProductQuery) ([]domain.Product, error)
+	CountByFilter(ctx context.Context, tx helper.Tx, filter domain.ProductFilter) (int, error)
+	FindRevisions(ctx context.Context, tx helper.Tx, productId int, limit int, offset int) ([]domain.ProductRevision, error)
+	CountRevisions(ctx context.Context, tx helper.Tx, productId int) (int, error)
+	FindRevision(ctx context.Context, tx helper.Tx, productId int, revision int) (domain.ProductRevision, error)
+	FindRevisionAsOf(ctx context.Context, tx helper.Tx, productId int, asOf time.Time) (domain.ProductRevision, error)
 }
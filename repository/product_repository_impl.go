@@ -6,69 +6,432 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strings"
+	"time"
 )
 
 type productRepositoryImpl struct {
+	dialect Dialect
 }
 
 func NewProductRepository() ProductRepository {
-	return &productRepositoryImpl{}
+	return &productRepositoryImpl{dialect: DialectMySQL}
 }
 
-func (repository *productRepositoryImpl) Save(ctx context.Context, tx *sql.Tx, product domain.Product) domain.Product {
-	query := "INSERT INTO products(product_name, price) VALUES (?, ?)"
-	result, err := tx.ExecContext(ctx, query, product.ProductName, product.Price)
-	helper.PanicIfError(err)
+func NewPostgresProductRepository() ProductRepository {
+	return &productRepositoryImpl{dialect: DialectPostgres}
+}
+
+func NewSQLiteProductRepository() ProductRepository {
+	return &productRepositoryImpl{dialect: DialectSQLite}
+}
+
+const productColumns = "id, product_name, price, currency, version, deleted_at"
 
-	id, err := result.LastInsertId()
-	helper.PanicIfError(err)
+func (repository *productRepositoryImpl) Save(ctx context.Context, tx helper.Tx, product domain.Product) (domain.Product, error) {
+	sqlTx, err := toSQLTx(tx)
+	if err != nil {
+		return domain.Product{}, err
+	}
+
+	query := "INSERT INTO products(product_name, price, currency) VALUES (?, ?, ?)"
+	id, err := repository.dialect.insert(ctx, sqlTx, query, product.ProductName, product.Price.Amount, product.Price.Currency)
+	if err != nil {
+		return product, err
+	}
 
-	product.Id = int(id)
-	return product
+	product.Id = id
+	product.Version = 1
+	return product, repository.saveRevisions(ctx, sqlTx, time.Now().UTC(), product)
 }
 
-func (repository *productRepositoryImpl) Update(ctx context.Context, tx *sql.Tx, product domain.Product) domain.Product {
-	query := "UPDATE products SET product_name = ?, price = ? WHERE id = ?"
-	_, err := tx.ExecContext(ctx, query, product.ProductName, product.Price, product.Id)
-	helper.PanicIfError(err)
+var productInsertColumns = []string{"product_name", "price", "currency"}
+
+// SaveAll inserts products with multi-row INSERT statements.
+func (repository *productRepositoryImpl) SaveAll(ctx context.Context, tx helper.Tx, products []domain.Product) ([]domain.Product, error) {
+	sqlTx, err := toSQLTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	saved := make([]domain.Product, 0, len(products))
+	for start := 0; start < len(products); start += insertBatchSize {
+		batch := products[start:min(start+insertBatchSize, len(products))]
+		rows := make([][]interface{}, 0, len(batch))
+		for _, product := range batch {
+			rows = append(rows, []interface{}{product.ProductName, product.Price.Amount, product.Price.Currency})
+		}
 
-	return product
+		ids, err := repository.dialect.insertRows(ctx, sqlTx, "products", productInsertColumns, rows, productInsertColumns)
+		if err != nil {
+			return nil, err
+		}
+		for i, product := range batch {
+			product.Id = ids[i]
+			product.Version = 1
+			saved = append(saved, product)
+		}
+	}
+	return saved, repository.saveRevisions(ctx, sqlTx, time.Now().UTC(), saved...)
 }
 
-func (repository *productRepositoryImpl) Delete(ctx context.Context, tx *sql.Tx, product domain.Product) {
-	query := "DELETE FROM products WHERE id = ?"
-	_, err := tx.ExecContext(ctx, query, product.Id)
-	helper.PanicIfError(err)
+func (repository *productRepositoryImpl) Update(ctx context.Context, tx helper.Tx, product domain.Product) (domain.Product, error) {
+	sqlTx, err := toSQLTx(tx)
+	if err != nil {
+		return domain.Product{}, err
+	}
+
+	query := "UPDATE products SET product_name = ?, price = ?, currency = ?, version = version + 1 WHERE id = ? AND version = ?"
+	result, err := sqlTx.ExecContext(ctx, repository.dialect.Rebind(query), product.ProductName, product.Price.Amount, product.Price.Currency, product.Id, product.Version)
+	err = checkProductVersion(result, err)
+	if err != nil {
+		return product, err
+	}
+
+	product.Version++
+	return product, repository.saveRevisions(ctx, sqlTx, time.Now().UTC(), product)
 }
 
-func (repository *productRepositoryImpl) FindById(ctx context.Context, tx *sql.Tx, productId int) (domain.Product, error) {
-	query := "SELECT id, product_name, price FROM products WHERE id = ?"
-	rows, err := tx.QueryContext(ctx, query, productId)
-	helper.PanicIfError(err)
+// UpdateColumns writes only the named columns of product, leaving the others
+// as they are in the database.
+func (repository *productRepositoryImpl) UpdateColumns(ctx context.Context, tx helper.Tx, product domain.Product, columns []string) (domain.Product, error) {
+	sqlTx, err := toSQLTx(tx)
+	if err != nil {
+		return domain.Product{}, err
+	}
+	if len(columns) == 0 {
+		return product, nil
+	}
+
+	assignments := make([]string, 0, len(columns))
+	args := make([]interface{}, 0, len(columns)+1)
+	for _, column := range columns {
+		columnAssignments, values, ok := productColumnValues(product, column)
+		if !ok {
+			return product, errors.New("unsupported product column: " + column)
+		}
+		assignments = append(assignments, columnAssignments...)
+		args = append(args, values...)
+	}
+
+	query := "UPDATE products SET " + strings.Join(assignments, ", ") + ", version = version + 1 WHERE id = ? AND version = ?"
+	result, err := sqlTx.ExecContext(ctx, repository.dialect.Rebind(query), append(args, product.Id, product.Version)...)
+	err = checkProductVersion(result, err)
+	if err != nil {
+		return product, err
+	}
+
+	product.Version++
+	return product, repository.saveRevisions(ctx, sqlTx, time.Now().UTC(), product)
+}
+
+// productColumnValues whitelists the columns UpdateColumns may write, so user
+// input never names a column directly. A price is written with its currency.
+func productColumnValues(product domain.Product, column string) ([]string, []interface{}, bool) {
+	switch column {
+	case "product_name":
+		return []string{"product_name = ?"}, []interface{}{product.ProductName}, true
+	case "price":
+		return []string{"price = ?", "currency = ?"}, []interface{}{product.Price.Amount, product.Price.Currency}, true
+	}
+	return nil, nil, false
+}
+
+func (repository *productRepositoryImpl) Delete(ctx context.Context, tx helper.Tx, product domain.Product, deletedAt time.Time) (domain.Product, error) {
+	sqlTx, err := toSQLTx(tx)
+	if err != nil {
+		return domain.Product{}, err
+	}
+
+	query := "UPDATE products SET deleted_at = ?, version = version + 1 WHERE id = ? AND version = ? AND deleted_at IS NULL"
+	result, err := sqlTx.ExecContext(ctx, repository.dialect.Rebind(query), deletedAt, product.Id, product.Version)
+	err = checkProductVersion(result, err)
+	if err != nil {
+		return product, err
+	}
+
+	product.Version++
+	product.DeletedAt = &deletedAt
+	return product, repository.saveRevisions(ctx, sqlTx, deletedAt, product)
+}
+
+func (repository *productRepositoryImpl) Restore(ctx context.Context, tx helper.Tx, product domain.Product) (domain.Product, error) {
+	sqlTx, err := toSQLTx(tx)
+	if err != nil {
+		return domain.Product{}, err
+	}
+
+	query := "UPDATE products SET deleted_at = NULL, version = version + 1 WHERE id = ? AND version = ? AND deleted_at IS NOT NULL"
+	result, err := sqlTx.ExecContext(ctx, repository.dialect.Rebind(query), product.Id, product.Version)
+	err = checkProductVersion(result, err)
+	if err != nil {
+		return product, err
+	}
+
+	product.Version++
+	product.DeletedAt = nil
+	return product, repository.saveRevisions(ctx, sqlTx, time.Now().UTC(), product)
+}
+
+// Purge removes a product in the trash for good, together with its history
+// and price schedules.
+func (repository *productRepositoryImpl) Purge(ctx context.Context, tx helper.Tx, product domain.Product) error {
+	sqlTx, err := toSQLTx(tx)
+	if err != nil {
+		return err
+	}
+
+	query := "DELETE FROM products WHERE id = ? AND version = ? AND deleted_at IS NOT NULL"
+	result, err := sqlTx.ExecContext(ctx, repository.dialect.Rebind(query), product.Id, product.Version)
+	err = checkProductVersion(result, err)
+	if err != nil {
+		return err
+	}
+
+	for _, query := range []string{"DELETE FROM product_revisions WHERE product_id = ?", "DELETE FROM price_schedules WHERE product_id = ?"} {
+		_, err = sqlTx.ExecContext(ctx, repository.dialect.Rebind(query), product.Id)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkProductVersion reports a statement guarded by the version that matched
+// no row: the product was changed or deleted after it was read.
+func checkProductVersion(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrProductVersionConflict
+	}
+	return nil
+}
+
+func (repository *productRepositoryImpl) FindById(ctx context.Context, tx helper.Tx, productId int) (domain.Product, error) {
+	return repository.findOne(ctx, tx, "SELECT "+productColumns+" FROM products WHERE id = ? AND deleted_at IS NULL", productId)
+}
+
+// FindDeletedById finds a product in the trash.
+func (repository *productRepositoryImpl) FindDeletedById(ctx context.Context, tx helper.Tx, productId int) (domain.Product, error) {
+	return repository.findOne(ctx, tx, "SELECT "+productColumns+" FROM products WHERE id = ? AND deleted_at IS NOT NULL", productId)
+}
+
+func (repository *productRepositoryImpl) findOne(ctx context.Context, tx helper.Tx, query string, args ...interface{}) (domain.Product, error) {
+	sqlTx, err := toSQLTx(tx)
+	if err != nil {
+		return domain.Product{}, err
+	}
+
+	rows, err := sqlTx.QueryContext(ctx, repository.dialect.Rebind(query), args...)
+	if err != nil {
+		return domain.Product{}, err
+	}
 	defer rows.Close()
 
-	product := domain.Product{}
 	if rows.Next() {
-		err := rows.Scan(&product.Id, &product.ProductName, &product.Price)
-		helper.PanicIfError(err)
-		return product, nil
+		return scanProduct(rows)
 	} else {
-		return product, errors.New("product not found")
+		return domain.Product{}, ErrProductNotFound
 	}
 }
 
-func (repository *productRepositoryImpl) FindAll(ctx context.Context, tx *sql.Tx) []domain.Product {
-	query := "SELECT id, product_name, price FROM products"
-	rows, err := tx.QueryContext(ctx, query)
-	helper.PanicIfError(err)
+// FindByProductName returns the products named exactly productName, oldest
+// first. Names are not unique, so there may be several.
+func (repository *productRepositoryImpl) FindByProductName(ctx context.Context, tx helper.Tx, productName string) ([]domain.Product, error) {
+	sqlTx, err := toSQLTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT " + productColumns + " FROM products WHERE product_name = ? AND deleted_at IS NULL ORDER BY id"
+	rows, err := sqlTx.QueryContext(ctx, repository.dialect.Rebind(query), productName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanProducts(rows)
+}
+
+func (repository *productRepositoryImpl) FindAll(ctx context.Context, tx helper.Tx) ([]domain.Product, error) {
+	sqlTx, err := toSQLTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT " + productColumns + " FROM products WHERE deleted_at IS NULL"
+	rows, err := sqlTx.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanProducts(rows)
+}
+
+// productSortColumns whitelists the columns a listing may be ordered by, so
+// user input never reaches the ORDER BY clause directly.
+var productSortColumns = map[string]string{
+	"id":           "id",
+	"product_name": "product_name",
+	"price":        "price",
+}
+
+func (repository *productRepositoryImpl) FindAllByQuery(ctx context.Context, tx helper.Tx, query domain.ProductQuery) ([]domain.Product, error) {
+	sqlTx, err := toSQLTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	orderBy, err := productOrderByClause(query.Sorts)
+	if err != nil {
+		return nil, err
+	}
+
+	where, args := productWhereClause(repository.dialect, query.Filter)
+	if query.After != nil {
+		condition, keysetArgs, err := productKeysetCondition(query.Sorts, *query.After)
+		if err != nil {
+			return nil, err
+		}
+		where += " AND " + condition
+		args = append(args, keysetArgs...)
+	}
+
+	sqlQuery := "SELECT " + productColumns + " FROM products" + where + orderBy + " LIMIT ? OFFSET ?"
+	args = append(args, query.Limit, query.Offset)
+
+	rows, err := sqlTx.QueryContext(ctx, repository.dialect.Rebind(sqlQuery), args...)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
-	var products []domain.Product
+	return scanProducts(rows)
+}
+
+func (repository *productRepositoryImpl) CountByFilter(ctx context.Context, tx helper.Tx, filter domain.ProductFilter) (int, error) {
+	sqlTx, err := toSQLTx(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	where, args := productWhereClause(repository.dialect, filter)
+	sqlQuery := "SELECT COUNT(*) FROM products" + where
+
+	var total int
+	err = sqlTx.QueryRowContext(ctx, repository.dialect.Rebind(sqlQuery), args...).Scan(&total)
+	return total, err
+}
+
+func scanProducts(rows *sql.Rows) ([]domain.Product, error) {
+	products := []domain.Product{}
 	for rows.Next() {
-		product := domain.Product{}
-		err := rows.Scan(&product.Id, &product.ProductName, &product.Price)
-		helper.PanicIfError(err)
+		product, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
 		products = append(products, product)
 	}
-	return products
+	return products, rows.Err()
+}
+
+func scanProduct(rows *sql.Rows) (domain.Product, error) {
+	product := domain.Product{}
+	var deletedAt sql.NullTime
+	err := rows.Scan(&product.Id, &product.ProductName, &product.Price.Amount, &product.Price.Currency, &product.Version, &deletedAt)
+	product.DeletedAt = nullTimePointer(deletedAt)
+	return product, err
+}
+
+func productWhereClause(dialect Dialect, filter domain.ProductFilter) (string, []interface{}) {
+	conditions := []string{"deleted_at IS NULL"}
+	var args []interface{}
+
+	if filter.Deleted {
+		conditions[0] = "deleted_at IS NOT NULL"
+	}
+	if filter.DeletedBefore != nil {
+		conditions = append(conditions, "deleted_at < ?")
+		args = append(args, *filter.DeletedBefore)
+	}
+	if filter.NameContains != "" {
+		conditions = append(conditions, "product_name "+dialect.likeOperator+" ? ESCAPE '!'")
+		args = append(args, "%"+escapeLike(filter.NameContains)+"%")
+	}
+	if filter.Currency != "" {
+		conditions = append(conditions, "currency = ?")
+		args = append(args, filter.Currency)
+	}
+	if filter.MinPrice != nil {
+		conditions = append(conditions, "price >= ?")
+		args = append(args, *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		conditions = append(conditions, "price <= ?")
+		args = append(args, *filter.MaxPrice)
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// productKeysetCondition selects the rows after the keyset for an ordering on
+// a single column followed by id, matching productOrderByClause.
+func productKeysetCondition(sorts []domain.ProductSort, after domain.ProductKeyset) (string, []interface{}, error) {
+	if len(sorts) == 0 {
+		return "id > ?", []interface{}{after.Id}, nil
+	}
+	if len(sorts) > 1 {
+		return "", nil, errors.New("keyset pagination supports a single sort column")
+	}
+
+	column, ok := productSortColumns[sorts[0].Column]
+	if !ok {
+		return "", nil, errors.New("unsupported sort column: " + sorts[0].Column)
+	}
+
+	operator := ">"
+	if sorts[0].Descending {
+		operator = "<"
+	}
+	if column == "id" {
+		return "id " + operator + " ?", []interface{}{after.Id}, nil
+	}
+	return "(" + column + " " + operator + " ? OR (" + column + " = ? AND id > ?))", []interface{}{after.Value, after.Value, after.Id}, nil
+}
+
+func productOrderByClause(sorts []domain.ProductSort) (string, error) {
+	var orders []string
+	hasId := false
+	for _, sort := range sorts {
+		column, ok := productSortColumns[sort.Column]
+		if !ok {
+			return "", errors.New("unsupported sort column: " + sort.Column)
+		}
+		if column == "id" {
+			hasId = true
+		}
+		if sort.Descending {
+			orders = append(orders, column+" DESC")
+		} else {
+			orders = append(orders, column+" ASC")
+		}
+	}
+
+	// id is unique, so appending it keeps the ordering stable between pages
+	if !hasId {
+		orders = append(orders, "id ASC")
+	}
+	return " ORDER BY " + strings.Join(orders, ", "), nil
+}
+
+func escapeLike(value string) string {
+	replacer := strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+	return replacer.Replace(value)
 }
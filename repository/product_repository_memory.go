@@ -0,0 +1,322 @@
+package repository
+
+import (
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/model/domain"
+	"cmp"
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+type productRepositoryMemory struct {
+}
+
+// NewMemoryProductRepository stores products in the MemoryStore that opened
+// the transaction.
+func NewMemoryProductRepository() ProductRepository {
+	return &productRepositoryMemory{}
+}
+
+func (repository *productRepositoryMemory) Save(ctx context.Context, tx helper.Tx, product domain.Product) (domain.Product, error) {
+	memoryTx, err := toMemoryTx(tx)
+	if err != nil {
+		return product, err
+	}
+
+	state := memoryTx.write()
+	product.Id = state.nextProductId
+	product.Version = 1
+	state.nextProductId++
+	state.products[product.Id] = product
+	state.saveRevision(product, time.Now().UTC())
+	return product, nil
+}
+
+func (repository *productRepositoryMemory) SaveAll(ctx context.Context, tx helper.Tx, products []domain.Product) ([]domain.Product, error) {
+	saved := make([]domain.Product, 0, len(products))
+	for _, product := range products {
+		product, err := repository.Save(ctx, tx, product)
+		if err != nil {
+			return nil, err
+		}
+		saved = append(saved, product)
+	}
+	return saved, nil
+}
+
+func (repository *productRepositoryMemory) Update(ctx context.Context, tx helper.Tx, product domain.Product) (domain.Product, error) {
+	memoryTx, err := toMemoryTx(tx)
+	if err != nil {
+		return product, err
+	}
+
+	stored, ok := memoryTx.state.products[product.Id]
+	if !ok || stored.Version != product.Version {
+		return product, ErrProductVersionConflict
+	}
+
+	product.Version++
+	state := memoryTx.write()
+	state.products[product.Id] = product
+	state.saveRevision(product, time.Now().UTC())
+	return product, nil
+}
+
+func (repository *productRepositoryMemory) UpdateColumns(ctx context.Context, tx helper.Tx, product domain.Product, columns []string) (domain.Product, error) {
+	memoryTx, err := toMemoryTx(tx)
+	if err != nil {
+		return product, err
+	}
+
+	if len(columns) == 0 {
+		return product, nil
+	}
+	stored, ok := memoryTx.state.products[product.Id]
+	if !ok || stored.Version != product.Version {
+		return product, ErrProductVersionConflict
+	}
+	for _, column := range columns {
+		switch column {
+		case "product_name":
+			stored.ProductName = product.ProductName
+		case "price":
+			stored.Price = product.Price
+		default:
+			return product, errors.New("unsupported product column: " + column)
+		}
+	}
+	stored.Version++
+	state := memoryTx.write()
+	state.products[product.Id] = stored
+	state.saveRevision(stored, time.Now().UTC())
+	product.Version = stored.Version
+	return product, nil
+}
+
+func (repository *productRepositoryMemory) Delete(ctx context.Context, tx helper.Tx, product domain.Product, deletedAt time.Time) (domain.Product, error) {
+	memoryTx, err := toMemoryTx(tx)
+	if err != nil {
+		return product, err
+	}
+
+	stored, ok := memoryTx.state.products[product.Id]
+	if !ok || stored.Version != product.Version || stored.DeletedAt != nil {
+		return product, ErrProductVersionConflict
+	}
+
+	stored.Version++
+	stored.DeletedAt = &deletedAt
+	state := memoryTx.write()
+	state.products[product.Id] = stored
+	state.saveRevision(stored, deletedAt)
+	product.Version, product.DeletedAt = stored.Version, stored.DeletedAt
+	return product, nil
+}
+
+func (repository *productRepositoryMemory) Restore(ctx context.Context, tx helper.Tx, product domain.Product) (domain.Product, error) {
+	memoryTx, err := toMemoryTx(tx)
+	if err != nil {
+		return product, err
+	}
+
+	stored, ok := memoryTx.state.products[product.Id]
+	if !ok || stored.Version != product.Version || stored.DeletedAt == nil {
+		return product, ErrProductVersionConflict
+	}
+
+	stored.Version++
+	stored.DeletedAt = nil
+	state := memoryTx.write()
+	state.products[product.Id] = stored
+	state.saveRevision(stored, time.Now().UTC())
+	product.Version, product.DeletedAt = stored.Version, nil
+	return product, nil
+}
+
+func (repository *productRepositoryMemory) Purge(ctx context.Context, tx helper.Tx, product domain.Product) error {
+	memoryTx, err := toMemoryTx(tx)
+	if err != nil {
+		return err
+	}
+
+	stored, ok := memoryTx.state.products[product.Id]
+	if !ok || stored.Version != product.Version || stored.DeletedAt == nil {
+		return ErrProductVersionConflict
+	}
+
+	state := memoryTx.write()
+	delete(state.products, product.Id)
+	delete(state.productRevisions, product.Id)
+	maps.DeleteFunc(state.priceSchedules, func(id int, schedule domain.PriceSchedule) bool {
+		return schedule.ProductId == product.Id
+	})
+	return nil
+}
+
+func (repository *productRepositoryMemory) FindById(ctx context.Context, tx helper.Tx, productId int) (domain.Product, error) {
+	memoryTx, err := toMemoryTx(tx)
+	if err != nil {
+		return domain.Product{}, err
+	}
+
+	product, ok := memoryTx.state.products[productId]
+	if !ok || product.DeletedAt != nil {
+		return domain.Product{}, ErrProductNotFound
+	}
+	return product, nil
+}
+
+func (repository *productRepositoryMemory) FindDeletedById(ctx context.Context, tx helper.Tx, productId int) (domain.Product, error) {
+	memoryTx, err := toMemoryTx(tx)
+	if err != nil {
+		return domain.Product{}, err
+	}
+
+	product, ok := memoryTx.state.products[productId]
+	if !ok || product.DeletedAt == nil {
+		return domain.Product{}, ErrProductNotFound
+	}
+	return product, nil
+}
+
+func (repository *productRepositoryMemory) FindByProductName(ctx context.Context, tx helper.Tx, productName string) ([]domain.Product, error) {
+	memoryTx, err := toMemoryTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	products := []domain.Product{}
+	for _, product := range memoryTx.state.products {
+		if product.ProductName == productName && product.DeletedAt == nil {
+			products = append(products, product)
+		}
+	}
+	slices.SortFunc(products, func(a, b domain.Product) int {
+		return cmp.Compare(a.Id, b.Id)
+	})
+	return products, nil
+}
+
+func (repository *productRepositoryMemory) FindAll(ctx context.Context, tx helper.Tx) ([]domain.Product, error) {
+	return repository.FindAllByQuery(ctx, tx, domain.ProductQuery{})
+}
+
+func (repository *productRepositoryMemory) FindAllByQuery(ctx context.Context, tx helper.Tx, query domain.ProductQuery) ([]domain.Product, error) {
+	memoryTx, err := toMemoryTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sort := range query.Sorts {
+		if _, ok := productSortColumns[sort.Column]; !ok {
+			return nil, errors.New("unsupported sort column: " + sort.Column)
+		}
+	}
+	if query.After != nil && len(query.Sorts) > 1 {
+		return nil, errors.New("keyset pagination supports a single sort column")
+	}
+
+	products := []domain.Product{}
+	for _, product := range memoryTx.state.products {
+		if !matchesProductFilter(product, query.Filter) {
+			continue
+		}
+		if query.After != nil && compareProducts(product, productAtKeyset(query.Sorts, *query.After), query.Sorts) <= 0 {
+			continue
+		}
+		products = append(products, product)
+	}
+
+	slices.SortFunc(products, func(a, b domain.Product) int {
+		return compareProducts(a, b, query.Sorts)
+	})
+
+	if query.Offset >= len(products) {
+		return []domain.Product{}, nil
+	}
+	products = products[query.Offset:]
+	if query.Limit > 0 && query.Limit < len(products) {
+		products = products[:query.Limit]
+	}
+	return products, nil
+}
+
+func (repository *productRepositoryMemory) CountByFilter(ctx context.Context, tx helper.Tx, filter domain.ProductFilter) (int, error) {
+	memoryTx, err := toMemoryTx(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, product := range memoryTx.state.products {
+		if matchesProductFilter(product, filter) {
+			total++
+		}
+	}
+	return total, nil
+}
+
+// matchesProductFilter mirrors the SQL filter, including its case-insensitive
+// name match.
+func matchesProductFilter(product domain.Product, filter domain.ProductFilter) bool {
+	if (product.DeletedAt != nil) != filter.Deleted {
+		return false
+	}
+	if filter.DeletedBefore != nil && (product.DeletedAt == nil || !product.DeletedAt.Before(*filter.DeletedBefore)) {
+		return false
+	}
+	if filter.NameContains != "" && !strings.Contains(strings.ToLower(product.ProductName), strings.ToLower(filter.NameContains)) {
+		return false
+	}
+	if filter.Currency != "" && product.Price.Currency != filter.Currency {
+		return false
+	}
+	if filter.MinPrice != nil && product.Price.Amount < *filter.MinPrice {
+		return false
+	}
+	if filter.MaxPrice != nil && product.Price.Amount > *filter.MaxPrice {
+		return false
+	}
+	return true
+}
+
+// compareProducts orders like productOrderByClause: the requested columns,
+// then id ascending.
+func compareProducts(a, b domain.Product, sorts []domain.ProductSort) int {
+	for _, sort := range sorts {
+		var result int
+		switch sort.Column {
+		case "id":
+			result = cmp.Compare(a.Id, b.Id)
+		case "product_name":
+			result = cmp.Compare(strings.ToLower(a.ProductName), strings.ToLower(b.ProductName))
+		case "price":
+			result = cmp.Compare(a.Price.Amount, b.Price.Amount)
+		}
+		if sort.Descending {
+			result = -result
+		}
+		if result != 0 {
+			return result
+		}
+	}
+	return cmp.Compare(a.Id, b.Id)
+}
+
+func productAtKeyset(sorts []domain.ProductSort, keyset domain.ProductKeyset) domain.Product {
+	product := domain.Product{Id: keyset.Id}
+	if len(sorts) == 0 {
+		return product
+	}
+	switch value := keyset.Value.(type) {
+	case string:
+		product.ProductName = value
+	case int64:
+		product.Price.Amount = value
+	}
+	return product
+}
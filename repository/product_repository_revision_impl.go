@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/model/domain"
+	"context"
+	"database/sql"
+	"time"
+)
+
+const productRevisionColumns = "product_id, revision, product_name, price, currency, deleted_at, recorded_at"
+
+// saveRevisions stores products, as just written, as their latest revisions.
+// Every write method calls it in the statement's transaction, so no write
+// path can leave a change out of the history.
+func (repository *productRepositoryImpl) saveRevisions(ctx context.Context, sqlTx *sql.Tx, recordedAt time.Time, products ...domain.Product) error {
+	columns := []string{"product_id", "revision", "product_name", "price", "currency", "deleted_at", "recorded_at"}
+	for start := 0; start < len(products); start += insertBatchSize {
+		batch := products[start:min(start+insertBatchSize, len(products))]
+		rows := make([][]interface{}, 0, len(batch))
+		for _, product := range batch {
+			rows = append(rows, []interface{}{product.Id, product.Version, product.ProductName, product.Price.Amount, product.Price.Currency, product.DeletedAt, recordedAt})
+		}
+
+		query, args := multiRowInsert("product_revisions", columns, rows)
+		_, err := sqlTx.ExecContext(ctx, repository.dialect.Rebind(query), args...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (repository *productRepositoryImpl) FindRevisions(ctx context.Context, tx helper.Tx, productId int, limit int, offset int) ([]domain.ProductRevision, error) {
+	sqlTx, err := toSQLTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT " + productRevisionColumns + " FROM product_revisions WHERE product_id = ? ORDER BY revision DESC LIMIT ? OFFSET ?"
+	rows, err := sqlTx.QueryContext(ctx, repository.dialect.Rebind(query), productId, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []domain.ProductRevision{}
+	for rows.Next() {
+		revision, err := scanProductRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, rows.Err()
+}
+
+func (repository *productRepositoryImpl) CountRevisions(ctx context.Context, tx helper.Tx, productId int) (int, error) {
+	sqlTx, err := toSQLTx(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int
+	err = sqlTx.QueryRowContext(ctx, repository.dialect.Rebind("SELECT COUNT(*) FROM product_revisions WHERE product_id = ?"), productId).Scan(&total)
+	return total, err
+}
+
+func (repository *productRepositoryImpl) FindRevision(ctx context.Context, tx helper.Tx, productId int, revision int) (domain.ProductRevision, error) {
+	query := "SELECT " + productRevisionColumns + " FROM product_revisions WHERE product_id = ? AND revision = ?"
+	return repository.findOneRevision(ctx, tx, query, productId, revision)
+}
+
+// FindRevisionAsOf finds the revision that was current at asOf, the last one
+// recorded by then.
+func (repository *productRepositoryImpl) FindRevisionAsOf(ctx context.Context, tx helper.Tx, productId int, asOf time.Time) (domain.ProductRevision, error) {
+	query := "SELECT " + productRevisionColumns + " FROM product_revisions WHERE product_id = ? AND recorded_at <= ? ORDER BY revision DESC LIMIT 1"
+	return repository.findOneRevision(ctx, tx, query, productId, asOf)
+}
+
+func (repository *productRepositoryImpl) findOneRevision(ctx context.Context, tx helper.Tx, query string, args ...interface{}) (domain.ProductRevision, error) {
+	sqlTx, err := toSQLTx(tx)
+	if err != nil {
+		return domain.ProductRevision{}, err
+	}
+
+	rows, err := sqlTx.QueryContext(ctx, repository.dialect.Rebind(query), args...)
+	if err != nil {
+		return domain.ProductRevision{}, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return domain.ProductRevision{}, ErrProductRevisionNotFound
+	}
+	return scanProductRevision(rows)
+}
+
+func scanProductRevision(rows *sql.Rows) (domain.ProductRevision, error) {
+	revision := domain.ProductRevision{}
+	var deletedAt sql.NullTime
+	err := rows.Scan(&revision.Id, &revision.Version, &revision.ProductName, &revision.Price.Amount, &revision.Price.Currency, &deletedAt, &revision.RecordedAt)
+	revision.DeletedAt = nullTimePointer(deletedAt)
+	return revision, err
+}
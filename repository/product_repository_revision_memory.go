@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/model/domain"
+	"context"
+	"slices"
+	"time"
+)
+
+// saveRevision appends product to its history. The history may still be
+// shared with the committed state, so it is copied rather than appended to in
+// place.
+func (state *memoryState) saveRevision(product domain.Product, recordedAt time.Time) {
+	revision := domain.ProductRevision{Product: product, RecordedAt: recordedAt}
+	state.productRevisions[product.Id] = append(slices.Clip(state.productRevisions[product.Id]), revision)
+}
+
+func (repository *productRepositoryMemory) FindRevisions(ctx context.Context, tx helper.Tx, productId int, limit int, offset int) ([]domain.ProductRevision, error) {
+	memoryTx, err := toMemoryTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := slices.Clone(memoryTx.state.productRevisions[productId])
+	slices.Reverse(revisions)
+	if offset >= len(revisions) {
+		return []domain.ProductRevision{}, nil
+	}
+	revisions = revisions[offset:]
+	if limit > 0 && limit < len(revisions) {
+		revisions = revisions[:limit]
+	}
+	return revisions, nil
+}
+
+func (repository *productRepositoryMemory) CountRevisions(ctx context.Context, tx helper.Tx, productId int) (int, error) {
+	memoryTx, err := toMemoryTx(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(memoryTx.state.productRevisions[productId]), nil
+}
+
+func (repository *productRepositoryMemory) FindRevision(ctx context.Context, tx helper.Tx, productId int, revision int) (domain.ProductRevision, error) {
+	memoryTx, err := toMemoryTx(tx)
+	if err != nil {
+		return domain.ProductRevision{}, err
+	}
+
+	for _, stored := range memoryTx.state.productRevisions[productId] {
+		if stored.Version == revision {
+			return stored, nil
+		}
+	}
+	return domain.ProductRevision{}, ErrProductRevisionNotFound
+}
+
+func (repository *productRepositoryMemory) FindRevisionAsOf(ctx context.Context, tx helper.Tx, productId int, asOf time.Time) (domain.ProductRevision, error) {
+	memoryTx, err := toMemoryTx(tx)
+	if err != nil {
+		return domain.ProductRevision{}, err
+	}
+
+	revisions := memoryTx.state.productRevisions[productId]
+	for i := len(revisions) - 1; i >= 0; i-- {
+		if !revisions[i].RecordedAt.After(asOf) {
+			return revisions[i], nil
+		}
+	}
+	return domain.ProductRevision{}, ErrProductRevisionNotFound
+}
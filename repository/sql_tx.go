@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"bubblevy/restful-api/helper"
+	"database/sql"
+	"errors"
+)
+
+var ErrUnsupportedTx = errors.New("transaction was not opened on a SQL database")
+
+// toSQLTx unwraps the transaction handed to a SQL repository.
+func toSQLTx(tx helper.Tx) (*sql.Tx, error) {
+	sqlTx, ok := tx.(*sql.Tx)
+	if !ok {
+		return nil, ErrUnsupportedTx
+	}
+	return sqlTx, nil
+}
@@ -0,0 +1,13 @@
+package service
+
+import (
+	"bubblevy/restful-api/model/web"
+	"context"
+)
+
+type ApiKeyService interface {
+	Create(ctx context.Context, request web.ApiKeyCreateRequest) (web.ApiKeyCreateResponse, error)
+	Revoke(ctx context.Context, apiKeyId int) error
+	FindAll(ctx context.Context) ([]web.ApiKeyResponse, error)
+	Authenticate(ctx context.Context, key string) (web.ApiKeyResponse, error)
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"bubblevy/restful-api/model/web"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+)
+
+var auditCSVColumns = []string{"id", "created_at", "actor", "action", "product_id", "request_id", "before", "after"}
+
+type auditRowWriter interface {
+	Write(entry web.AuditEntryResponse) error
+	Flush() error
+}
+
+func newAuditRowWriter(format string, writer io.Writer) (auditRowWriter, error) {
+	if format == web.TransferFormatCSV {
+		return newCSVAuditWriter(writer)
+	}
+	return newNDJSONAuditWriter(writer), nil
+}
+
+// csvAuditWriter writes the snapshots as JSON text in their own columns, left
+// empty when there is no snapshot.
+type csvAuditWriter struct {
+	writer *csv.Writer
+}
+
+func newCSVAuditWriter(writer io.Writer) (*csvAuditWriter, error) {
+	csvWriter := csv.NewWriter(writer)
+	err := csvWriter.Write(auditCSVColumns)
+	return &csvAuditWriter{writer: csvWriter}, err
+}
+
+func (writer *csvAuditWriter) Write(entry web.AuditEntryResponse) error {
+	return writer.writer.Write([]string{
+		strconv.Itoa(entry.Id),
+		entry.CreatedAt.Format(time.RFC3339Nano),
+		entry.Actor,
+		entry.Action,
+		strconv.Itoa(entry.ProductId),
+		entry.RequestId,
+		string(entry.Before),
+		string(entry.After),
+	})
+}
+
+func (writer *csvAuditWriter) Flush() error {
+	writer.writer.Flush()
+	return writer.writer.Error()
+}
+
+type ndjsonAuditWriter struct {
+	buffer  *bufio.Writer
+	encoder *json.Encoder
+}
+
+func newNDJSONAuditWriter(writer io.Writer) *ndjsonAuditWriter {
+	buffer := bufio.NewWriter(writer)
+	return &ndjsonAuditWriter{buffer: buffer, encoder: json.NewEncoder(buffer)}
+}
+
+func (writer *ndjsonAuditWriter) Write(entry web.AuditEntryResponse) error {
+	return writer.encoder.Encode(entry)
+}
+
+func (writer *ndjsonAuditWriter) Flush() error {
+	return writer.buffer.Flush()
+}
@@ -0,0 +1,12 @@
+package service
+
+import (
+	"bubblevy/restful-api/model/web"
+	"context"
+	"io"
+)
+
+type AuditService interface {
+	FindAll(ctx context.Context, request web.AuditFindAllRequest) (web.AuditListResponse, error)
+	Export(ctx context.Context, request web.AuditExportRequest, writer io.Writer) error
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"bubblevy/restful-api/exception"
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/model/domain"
+	"bubblevy/restful-api/model/web"
+	"bubblevy/restful-api/repository"
+	"context"
+	"io"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type auditServiceImpl struct {
+	AuditRepository repository.AuditRepository
+	TxManager       helper.TxManager
+	Validate        *validator.Validate
+}
+
+func NewAuditService(auditRepository repository.AuditRepository, txManager helper.TxManager, validate *validator.Validate) AuditService {
+	return &auditServiceImpl{
+		AuditRepository: auditRepository,
+		TxManager:       txManager,
+		Validate:        validate,
+	}
+}
+
+// FindAll lists the matching entries newest first, a page at a time.
+func (service *auditServiceImpl) FindAll(ctx context.Context, request web.AuditFindAllRequest) (response web.AuditListResponse, err error) {
+	err = authorize(ctx, OperationAuditFindAll)
+	if err != nil {
+		return response, err
+	}
+
+	filter, err := service.auditFilter(request, request.AuditFilterRequest)
+	if err != nil {
+		return response, err
+	}
+
+	pagination := web.Pagination{Page: request.Page, PerPage: request.PerPage}
+	if pagination.Page == 0 {
+		pagination.Page = 1
+	}
+	if pagination.PerPage == 0 {
+		pagination.PerPage = defaultPerPage
+	}
+	query := domain.AuditQuery{
+		Filter:      filter,
+		NewestFirst: true,
+		Limit:       pagination.PerPage,
+		Offset:      (pagination.Page - 1) * pagination.PerPage,
+	}
+
+	tx, err := helper.BeginTx(ctx, service.TxManager)
+	if err != nil {
+		return response, exception.NewInternalError(err)
+	}
+	defer helper.CommitOrRollback(tx, &err)
+
+	entries, err := service.AuditRepository.FindAllByQuery(ctx, tx, query)
+	if err != nil {
+		return response, exception.NewInternalError(err)
+	}
+	pagination.Total, err = service.AuditRepository.CountByFilter(ctx, tx, filter)
+	if err != nil {
+		return response, exception.NewInternalError(err)
+	}
+	pagination.Limit = query.Limit
+	pagination.Offset = query.Offset
+	pagination.TotalPages = (pagination.Total + pagination.PerPage - 1) / pagination.PerPage
+
+	return web.AuditListResponse{
+		Entries:    helper.ToAuditEntryResponses(entries),
+		Pagination: &pagination,
+	}, nil
+}
+
+// Export writes every matching entry to writer, oldest first. Like the
+// product export it reads a page per transaction; entries recorded while it
+// runs are included up to the page that reaches them.
+func (service *auditServiceImpl) Export(ctx context.Context, request web.AuditExportRequest, writer io.Writer) error {
+	err := authorize(ctx, OperationAuditExport)
+	if err != nil {
+		return err
+	}
+
+	filter, err := service.auditFilter(request, request.AuditFilterRequest)
+	if err != nil {
+		return err
+	}
+
+	rows, err := newAuditRowWriter(request.Format, writer)
+	if err != nil {
+		return err
+	}
+
+	query := domain.AuditQuery{Filter: filter, Limit: exportPageSize}
+	for {
+		entries, err := service.exportPage(ctx, query)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			err = rows.Write(helper.ToAuditEntryResponse(entry))
+			if err != nil {
+				return err
+			}
+		}
+		err = rows.Flush()
+		if err != nil {
+			return err
+		}
+
+		if len(entries) < exportPageSize {
+			return nil
+		}
+		query.AfterId = entries[len(entries)-1].Id
+	}
+}
+
+func (service *auditServiceImpl) exportPage(ctx context.Context, query domain.AuditQuery) (entries []domain.AuditEntry, err error) {
+	tx, err := helper.BeginTx(ctx, service.TxManager)
+	if err != nil {
+		return nil, exception.NewInternalError(err)
+	}
+	defer helper.CommitOrRollback(tx, &err)
+
+	entries, err = service.AuditRepository.FindAllByQuery(ctx, tx, query)
+	if err != nil {
+		return nil, exception.NewInternalError(err)
+	}
+	return entries, nil
+}
+
+// auditFilter validates request, which embeds filter, and turns the filter
+// into its domain form.
+func (service *auditServiceImpl) auditFilter(request interface{}, filter web.AuditFilterRequest) (domain.AuditFilter, error) {
+	err := service.Validate.Struct(request)
+	if err != nil {
+		return domain.AuditFilter{}, exception.FromValidator(err)
+	}
+
+	if filter.From != nil && filter.To != nil && filter.From.After(*filter.To) {
+		return domain.AuditFilter{}, exception.NewValidationError("from must not be after to")
+	}
+
+	return domain.AuditFilter{
+		ProductId: filter.ProductId,
+		Actor:     filter.Actor,
+		From:      filter.From,
+		To:        filter.To,
+	}, nil
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"bubblevy/restful-api/auth"
+	"bubblevy/restful-api/exception"
+	"context"
+)
+
+const (
+	OperationProductCreate              = "ProductService.Create"
+	OperationProductUpdate              = "ProductService.Update"
+	OperationProductPatch               = "ProductService.Patch"
+	OperationProductDelete              = "ProductService.Delete"
+	OperationProductFindById            = "ProductService.FindById"
+	OperationProductFindAll             = "ProductService.FindAll"
+	OperationProductBulk                = "ProductService.Bulk"
+	OperationProductImport              = "ProductService.Import"
+	OperationProductExport              = "ProductService.Export"
+	OperationProductFindTrash           = "ProductService.FindTrash"
+	OperationProductRestore             = "ProductService.Restore"
+	OperationProductPurge               = "ProductService.Purge"
+	OperationProductPurgeTrash          = "ProductService.PurgeTrash"
+	OperationProductPurgeIdempotency    = "ProductService.PurgeIdempotencyKeys"
+	OperationProductFindRevisions       = "ProductService.FindRevisions"
+	OperationProductFindRevision        = "ProductService.FindRevision"
+	OperationProductFindByIdAsOf        = "ProductService.FindByIdAsOf"
+	OperationProductRevert              = "ProductService.Revert"
+	OperationProductSchedulePrice       = "ProductService.SchedulePrice"
+	OperationProductFindPriceSchedules  = "ProductService.FindPriceSchedules"
+	OperationProductCancelPriceSchedule = "ProductService.CancelPriceSchedule"
+	OperationProductFindPriceHistory    = "ProductService.FindPriceHistory"
+	OperationProductApplyPriceSchedules = "ProductService.ApplyPriceSchedules"
+	OperationApiKeyCreate               = "ApiKeyService.Create"
+	OperationApiKeyRevoke               = "ApiKeyService.Revoke"
+	OperationApiKeyFindAll              = "ApiKeyService.FindAll"
+	OperationAuditFindAll               = "AuditService.FindAll"
+	OperationAuditExport                = "AuditService.Export"
+)
+
+// policy maps every guarded service operation to the permission it needs.
+// Operations missing from the table are denied.
+var policy = map[string]string{
+	OperationProductCreate:              auth.ScopeProductsWrite,
+	OperationProductUpdate:              auth.ScopeProductsWrite,
+	OperationProductPatch:               auth.ScopeProductsWrite,
+	OperationProductDelete:              auth.ScopeProductsDelete,
+	OperationProductFindById:            auth.ScopeProductsRead,
+	OperationProductFindAll:             auth.ScopeProductsRead,
+	OperationProductBulk:                auth.ScopeProductsWrite,
+	OperationProductImport:              auth.ScopeProductsWrite,
+	OperationProductExport:              auth.ScopeProductsRead,
+	OperationProductFindTrash:           auth.ScopeProductsRead,
+	OperationProductRestore:             auth.ScopeProductsDelete,
+	OperationProductPurge:               auth.ScopeProductsPurge,
+	OperationProductPurgeTrash:          auth.ScopeProductsPurge,
+	OperationProductPurgeIdempotency:    auth.ScopeProductsWrite,
+	OperationProductFindRevisions:       auth.ScopeProductsRead,
+	OperationProductFindRevision:        auth.ScopeProductsRead,
+	OperationProductFindByIdAsOf:        auth.ScopeProductsRead,
+	OperationProductRevert:              auth.ScopeProductsWrite,
+	OperationProductSchedulePrice:       auth.ScopeProductsWrite,
+	OperationProductFindPriceSchedules:  auth.ScopeProductsRead,
+	OperationProductCancelPriceSchedule: auth.ScopeProductsWrite,
+	OperationProductFindPriceHistory:    auth.ScopeProductsRead,
+	OperationProductApplyPriceSchedules: auth.ScopeProductsWrite,
+	OperationApiKeyCreate:               auth.ScopeApiKeysAdmin,
+	OperationApiKeyRevoke:               auth.ScopeApiKeysAdmin,
+	OperationApiKeyFindAll:              auth.ScopeApiKeysAdmin,
+	OperationAuditFindAll:               auth.ScopeAuditRead,
+	OperationAuditExport:                auth.ScopeAuditRead,
+}
+
+// authorize checks the caller carried in ctx against the policy, so the rules
+// hold for every entry point and not only for HTTP.
+func authorize(ctx context.Context, operation string) error {
+	identity, ok := auth.FromContext(ctx)
+	if !ok {
+		return exception.NewUnauthorizedError("No authenticated caller.")
+	}
+
+	permission, ok := policy[operation]
+	if !ok || !identity.Can(permission) {
+		return exception.NewForbiddenError("You are not allowed to perform " + operation + ".")
+	}
+	return nil
+}
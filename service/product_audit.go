@@ -0,0 +1,67 @@
+package service
+
+import (
+	"bubblevy/restful-api/auth"
+	"bubblevy/restful-api/exception"
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/model/domain"
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// productChange is one product mutation waiting to be audited. Before is nil
+// for a create and After for a purge.
+type productChange struct {
+	Action string
+	Before *domain.Product
+	After  *domain.Product
+}
+
+// audit records changes in the audit log through tx, so an entry is committed
+// or rolled back together with the change it describes. The actor and request
+// id are taken from ctx.
+func (service *productServiceImpl) audit(ctx context.Context, tx helper.Tx, changes ...productChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	identity, _ := auth.FromContext(ctx)
+	requestId := helper.RequestId(ctx)
+	now := time.Now().UTC()
+
+	entries := make([]domain.AuditEntry, 0, len(changes))
+	for _, change := range changes {
+		entry := domain.AuditEntry{
+			Actor:     identity.Subject,
+			Action:    change.Action,
+			RequestId: requestId,
+			CreatedAt: now,
+		}
+		var err error
+		if change.Before != nil {
+			entry.ProductId = change.Before.Id
+			entry.Before, err = productSnapshot(*change.Before)
+		}
+		if err == nil && change.After != nil {
+			entry.ProductId = change.After.Id
+			entry.After, err = productSnapshot(*change.After)
+		}
+		if err != nil {
+			return exception.NewInternalError(err)
+		}
+		entries = append(entries, entry)
+	}
+
+	_, err := service.AuditRepository.SaveAll(ctx, tx, entries)
+	if err != nil {
+		return exception.NewInternalError(err)
+	}
+	return nil
+}
+
+// productSnapshot captures a product the way clients see it, with the price
+// in its currency as version 2 shows it.
+func productSnapshot(product domain.Product) (json.RawMessage, error) {
+	return json.Marshal(helper.ToProductResponse(product))
+}
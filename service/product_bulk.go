@@ -0,0 +1,205 @@
+package service
+
+import (
+	"bubblevy/restful-api/exception"
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/model/domain"
+	"bubblevy/restful-api/model/web"
+	"context"
+	"errors"
+	"time"
+)
+
+// errBulkAborted rolls back an all-or-nothing batch after one of its
+// operations missed its product or version; the failure itself is reported on
+// that operation.
+var errBulkAborted = errors.New("bulk operation aborted")
+
+// Bulk applies a batch of operations in one transaction. In all-or-nothing
+// mode the first failing operation rolls the batch back; in best-effort mode
+// failing operations are reported and skipped. Only storage failures fail the
+// whole request.
+func (service *productServiceImpl) Bulk(ctx context.Context, request web.ProductBulkRequest) (response web.ProductBulkResponse, err error) {
+	err = authorize(ctx, OperationProductBulk)
+	if err != nil {
+		return response, err
+	}
+
+	err = service.Validate.Struct(request)
+	if err != nil {
+		return response, exception.FromValidator(err)
+	}
+
+	response.Mode = request.Mode
+	if response.Mode == "" {
+		response.Mode = web.BulkModeAllOrNothing
+	}
+
+	response.Results = make([]web.ProductBulkResult, len(request.Operations))
+	failed := false
+	for i, operation := range request.Operations {
+		response.Results[i] = web.ProductBulkResult{Index: i, Op: operation.Op}
+		response.Results[i].Err = service.checkBulkOperation(ctx, operation)
+		failed = failed || response.Results[i].Err != nil
+	}
+	if failed && response.Mode == web.BulkModeAllOrNothing {
+		return response, nil
+	}
+
+	err = service.applyBulk(ctx, request.Operations, response)
+	if errors.Is(err, errBulkAborted) {
+		for i := range response.Results {
+			response.Results[i].Data = nil
+		}
+		return response, nil
+	}
+	if err != nil {
+		return web.ProductBulkResponse{}, err
+	}
+
+	response.Committed = true
+	return response, nil
+}
+
+// checkBulkOperation applies the authorization and validation rules of the
+// single-product operation an item stands for.
+func (service *productServiceImpl) checkBulkOperation(ctx context.Context, operation web.ProductBulkOperation) error {
+	switch operation.Op {
+	case web.BulkOpCreate:
+		if err := authorize(ctx, OperationProductCreate); err != nil {
+			return err
+		}
+		request := web.ProductCreateRequest{ProductName: operation.ProductName, Price: operation.Price}
+		return exception.FromValidator(service.Validate.Struct(request))
+	case web.BulkOpUpdate:
+		if err := authorize(ctx, OperationProductUpdate); err != nil {
+			return err
+		}
+		request := web.ProductUpdateRequest{Id: operation.Id, ProductName: operation.ProductName, Price: operation.Price}
+		return exception.FromValidator(service.Validate.Struct(request))
+	case web.BulkOpDelete:
+		if err := authorize(ctx, OperationProductDelete); err != nil {
+			return err
+		}
+		if operation.Id == 0 {
+			return exception.NewValidationError("id is required")
+		}
+		return nil
+	default:
+		return exception.NewValidationError("op must be one of: create update delete")
+	}
+}
+
+// applyBulk runs the operations that passed their checks, recording results
+// in place. Consecutive creates are saved together with one multi-row insert.
+func (service *productServiceImpl) applyBulk(ctx context.Context, operations []web.ProductBulkOperation, response web.ProductBulkResponse) (err error) {
+	tx, err := helper.BeginTx(ctx, service.TxManager)
+	if err != nil {
+		return exception.NewInternalError(err)
+	}
+	defer helper.CommitOrRollback(tx, &err)
+
+	var changes []productChange
+	var pending []int
+	saveCreates := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		products := make([]domain.Product, 0, len(pending))
+		for _, i := range pending {
+			products = append(products, domain.Product{ProductName: operations[i].ProductName, Price: operations[i].Price})
+		}
+		saved, err := service.ProductRepository.SaveAll(ctx, tx, products)
+		if err != nil {
+			return exception.NewInternalError(err)
+		}
+		for k, i := range pending {
+			productResponse := helper.ToProductResponse(saved[k])
+			response.Results[i].Data = &productResponse
+			changes = append(changes, productChange{Action: domain.AuditActionCreate, After: &saved[k]})
+		}
+		pending = pending[:0]
+		return nil
+	}
+
+	for i, operation := range operations {
+		result := &response.Results[i]
+		if result.Err != nil {
+			continue
+		}
+		if operation.Op == web.BulkOpCreate {
+			pending = append(pending, i)
+			continue
+		}
+
+		err = saveCreates()
+		if err != nil {
+			return err
+		}
+
+		expected := operationVersions(operation)
+		product, err := service.findProduct(ctx, tx, operation.Id)
+		if err == nil {
+			err = checkExpectedVersion(product, expected)
+		}
+		var notFound exception.NotFoundError
+		var preconditionFailed exception.PreconditionFailedError
+		if errors.As(err, &notFound) || errors.As(err, &preconditionFailed) {
+			result.Err = err
+			if response.Mode == web.BulkModeAllOrNothing {
+				return errBulkAborted
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		before := product
+		if operation.Op == web.BulkOpDelete {
+			deleted, err := service.ProductRepository.Delete(ctx, tx, product, time.Now().UTC())
+			if err != nil {
+				return productWriteError(err, expected)
+			}
+			changes = append(changes, productChange{Action: domain.AuditActionDelete, Before: &before, After: &deleted})
+			continue
+		}
+
+		product, err = service.applyDueSchedules(ctx, tx, product, time.Now().UTC())
+		if err != nil {
+			return productWriteError(err, expected)
+		}
+		price, err := requestPrice(ctx, product, operation.Price)
+		if err != nil {
+			result.Err = err
+			if response.Mode == web.BulkModeAllOrNothing {
+				return errBulkAborted
+			}
+			continue
+		}
+
+		before = product
+		product.ProductName = operation.ProductName
+		product.Price = price
+		product, err = service.ProductRepository.Update(ctx, tx, product)
+		if err != nil {
+			return productWriteError(err, expected)
+		}
+		productResponse := helper.ToProductResponse(product)
+		result.Data = &productResponse
+		changes = append(changes, productChange{Action: domain.AuditActionUpdate, Before: &before, After: &product})
+	}
+
+	err = saveCreates()
+	if err != nil {
+		return err
+	}
+	return service.audit(ctx, tx, changes...)
+}
+
+func operationVersions(operation web.ProductBulkOperation) []int {
+	if operation.Version == 0 {
+		return nil
+	}
+	return []int{operation.Version}
+}
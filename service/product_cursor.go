@@ -0,0 +1,77 @@
+package service
+
+import (
+	"bubblevy/restful-api/model/domain"
+	"encoding/json"
+	"errors"
+)
+
+// productCursor is the signed payload behind the opaque next_cursor. It records
+// the sort it was issued for so it cannot be replayed against another ordering.
+type productCursor struct {
+	Sort  string          `json:"s"`
+	Value json.RawMessage `json:"v,omitempty"`
+	Id    int             `json:"i"`
+}
+
+func productSortKey(sorts []domain.ProductSort) string {
+	if len(sorts) == 0 {
+		return "id"
+	}
+	if sorts[0].Descending {
+		return "-" + sorts[0].Column
+	}
+	return sorts[0].Column
+}
+
+func (service *productServiceImpl) encodeProductCursor(product domain.Product, sortKey string, sorts []domain.ProductSort) (string, error) {
+	cursor := productCursor{Sort: sortKey, Id: product.Id}
+	if len(sorts) != 0 {
+		var value interface{}
+		switch sorts[0].Column {
+		case "product_name":
+			value = product.ProductName
+		case "price":
+			value = product.Price.Amount
+		}
+		if value != nil {
+			data, err := json.Marshal(value)
+			if err != nil {
+				return "", err
+			}
+			cursor.Value = data
+		}
+	}
+	return service.CursorCodec.Encode(cursor)
+}
+
+func (service *productServiceImpl) decodeProductCursor(token string, sortKey string, sorts []domain.ProductSort) (domain.ProductKeyset, error) {
+	cursor := productCursor{}
+	if err := service.CursorCodec.Decode(token, &cursor); err != nil {
+		return domain.ProductKeyset{}, err
+	}
+	if cursor.Sort != sortKey {
+		return domain.ProductKeyset{}, errors.New("cursor was issued for a different sort order")
+	}
+
+	keyset := domain.ProductKeyset{Id: cursor.Id}
+	if len(sorts) == 0 {
+		return keyset, nil
+	}
+
+	var err error
+	switch sorts[0].Column {
+	case "product_name":
+		var value string
+		err = json.Unmarshal(cursor.Value, &value)
+		keyset.Value = value
+	case "price":
+		var value int64
+		err = json.Unmarshal(cursor.Value, &value)
+		keyset.Value = value
+	}
+	if err != nil {
+		return domain.ProductKeyset{}, errors.New("invalid cursor")
+	}
+	return keyset, nil
+}
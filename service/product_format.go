@@ -0,0 +1,284 @@
+package service
+
+import (
+	"bubblevy/restful-api/exception"
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/model/web"
+	"bubblevy/restful-api/money"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxImportLineSize bounds one NDJSON line, so a broken upload cannot make the
+// reader buffer it whole.
+const maxImportLineSize = 1 << 20
+
+// productCSVColumns are the columns of a CSV upload or v2 export. v1 exports
+// leave out currency, and an upload without it is in money.DefaultCurrency.
+var productCSVColumns = []string{"id", "product_name", "price", "currency", "version"}
+
+var productCSVColumnsV1 = []string{"id", "product_name", "price", "version"}
+
+// productRow is one product read from an upload. Err is set when the row could
+// not be parsed; the upload itself can still be read past it. LegacyPrice is
+// set when the price came in whole units without a currency, as v1 exports
+// write it.
+type productRow struct {
+	Line        int
+	Id          int
+	ProductName string
+	Price       money.Money
+	LegacyPrice bool
+	Version     int
+	Err         error
+}
+
+type productRowReader interface {
+	// Read returns the next row, or io.EOF after the last one. Any other
+	// error means the rest of the upload cannot be read.
+	Read() (productRow, error)
+}
+
+type productRowWriter interface {
+	Write(product web.ProductResponse) error
+	Flush() error
+}
+
+func newProductRowReader(format string, reader io.Reader) (productRowReader, error) {
+	if format == web.TransferFormatCSV {
+		return newCSVProductReader(reader)
+	}
+	return newNDJSONProductReader(reader), nil
+}
+
+// newProductRowWriter writes products the way apiVersion renders them.
+func newProductRowWriter(format string, writer io.Writer, apiVersion int) (productRowWriter, error) {
+	if format == web.TransferFormatCSV {
+		return newCSVProductWriter(writer, apiVersion)
+	}
+	return newNDJSONProductWriter(writer, apiVersion), nil
+}
+
+// csvProductReader reads a CSV upload whose header row names its columns, in
+// any order. The id, currency and version columns are optional.
+type csvProductReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+func newCSVProductReader(reader io.Reader) (*csvProductReader, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.ReuseRecord = true
+
+	header, err := csvReader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, exception.NewValidationError("CSV upload has no header row")
+	}
+	if err != nil {
+		return nil, csvReadError(err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		// spreadsheets often save CSV with a byte order mark
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\uFEFF")))
+		if !slices.Contains(productCSVColumns, name) {
+			return nil, exception.NewValidationError("unknown CSV column " + strconv.Quote(name) + ", expected: " + strings.Join(productCSVColumns, " "))
+		}
+		if _, ok := columns[name]; ok {
+			return nil, exception.NewValidationError("duplicate CSV column " + strconv.Quote(name))
+		}
+		columns[name] = i
+	}
+	for _, name := range []string{"product_name", "price"} {
+		if _, ok := columns[name]; !ok {
+			return nil, exception.NewValidationError("CSV header has no " + name + " column")
+		}
+	}
+
+	return &csvProductReader{reader: csvReader, columns: columns}, nil
+}
+
+func (reader *csvProductReader) Read() (productRow, error) {
+	record, err := reader.reader.Read()
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return productRow{Line: parseErr.StartLine, Err: exception.NewValidationError(parseErr.Err.Error())}, nil
+	}
+	if err != nil {
+		return productRow{}, csvReadError(err)
+	}
+
+	line, _ := reader.reader.FieldPos(0)
+	row := productRow{Line: line, ProductName: record[reader.columns["product_name"]]}
+	currency := money.DefaultCurrency
+	row.LegacyPrice = true
+	if column, ok := reader.columns["currency"]; ok && strings.TrimSpace(record[column]) != "" {
+		currency = strings.TrimSpace(record[column])
+		row.LegacyPrice = false
+	}
+	row.Price, row.Err = parseCSVPrice(record[reader.columns["price"]], currency)
+	if column, ok := reader.columns["id"]; ok && row.Err == nil {
+		row.Id, row.Err = parseCSVInt(record[column], "id")
+	}
+	if column, ok := reader.columns["version"]; ok && row.Err == nil {
+		row.Version, row.Err = parseCSVInt(record[column], "version")
+	}
+	return row, nil
+}
+
+// csvReadError passes io.EOF through and reports malformed CSV that stops the
+// reader as a validation error.
+func csvReadError(err error) error {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return exception.NewValidationError("malformed CSV: " + parseErr.Error())
+	}
+	return err
+}
+
+// parseCSVInt reads an integer cell; an empty cell is zero and left to the
+// validator.
+func parseCSVInt(cell string, column string) (int, error) {
+	cell = strings.TrimSpace(cell)
+	if cell == "" {
+		return 0, nil
+	}
+	value, err := strconv.Atoi(cell)
+	if err != nil {
+		return 0, exception.NewValidationError(column + " must be an integer")
+	}
+	return value, nil
+}
+
+// parseCSVPrice reads a decimal price cell; an empty cell is zero and left to
+// the validator.
+func parseCSVPrice(cell string, currency string) (money.Money, error) {
+	cell = strings.TrimSpace(cell)
+	if cell == "" {
+		cell = "0"
+	}
+	price, err := money.Parse(cell, currency)
+	if err != nil {
+		return price, exception.NewValidationError("price: " + err.Error())
+	}
+	return price, nil
+}
+
+// ndjsonProductReader reads one JSON object per line, skipping blank lines.
+type ndjsonProductReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func newNDJSONProductReader(reader io.Reader) *ndjsonProductReader {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(nil, maxImportLineSize)
+	return &ndjsonProductReader{scanner: scanner}
+}
+
+func (reader *ndjsonProductReader) Read() (productRow, error) {
+	for reader.scanner.Scan() {
+		reader.line++
+		line := bytes.TrimSpace(reader.scanner.Bytes())
+		if reader.line == 1 {
+			line = bytes.TrimPrefix(line, []byte("\uFEFF"))
+		}
+		if len(line) == 0 {
+			continue
+		}
+
+		var product struct {
+			Id          int             `json:"id"`
+			ProductName string          `json:"product_name"`
+			Price       json.RawMessage `json:"price"`
+			Version     int             `json:"version"`
+		}
+		row := productRow{Line: reader.line}
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.DisallowUnknownFields()
+		err := decoder.Decode(&product)
+		if err == nil && decoder.More() {
+			err = errors.New("more than one value on the line")
+		}
+		if err == nil && len(product.Price) != 0 {
+			err = json.Unmarshal(product.Price, &row.Price)
+		}
+		if err != nil {
+			row.Err = exception.NewValidationError("malformed JSON: " + err.Error())
+			return row, nil
+		}
+
+		row.Id, row.ProductName, row.Version = product.Id, product.ProductName, product.Version
+		// a v1 price is a plain number, a v2 one an object with its currency
+		row.LegacyPrice = len(product.Price) != 0 && product.Price[0] != '{'
+		return row, nil
+	}
+
+	err := reader.scanner.Err()
+	if errors.Is(err, bufio.ErrTooLong) {
+		return productRow{}, exception.NewValidationError("line " + strconv.Itoa(reader.line+1) + " is longer than " + strconv.Itoa(maxImportLineSize) + " bytes")
+	}
+	if err != nil {
+		return productRow{}, err
+	}
+	return productRow{}, io.EOF
+}
+
+// csvProductWriter writes the header row up front, so an empty catalog still
+// exports a file that imports cleanly.
+type csvProductWriter struct {
+	writer     *csv.Writer
+	apiVersion int
+}
+
+func newCSVProductWriter(writer io.Writer, apiVersion int) (*csvProductWriter, error) {
+	csvWriter := csv.NewWriter(writer)
+	columns := productCSVColumns
+	if apiVersion == helper.ApiVersion1 {
+		columns = productCSVColumnsV1
+	}
+	err := csvWriter.Write(columns)
+	return &csvProductWriter{writer: csvWriter, apiVersion: apiVersion}, err
+}
+
+func (writer *csvProductWriter) Write(product web.ProductResponse) error {
+	if writer.apiVersion == helper.ApiVersion1 {
+		return writer.writer.Write([]string{strconv.Itoa(product.Id), product.ProductName, strconv.FormatInt(product.Price.Major(), 10), strconv.Itoa(product.Version)})
+	}
+	return writer.writer.Write([]string{strconv.Itoa(product.Id), product.ProductName, product.Price.Decimal(), product.Price.Currency, strconv.Itoa(product.Version)})
+}
+
+func (writer *csvProductWriter) Flush() error {
+	writer.writer.Flush()
+	return writer.writer.Error()
+}
+
+type ndjsonProductWriter struct {
+	buffer     *bufio.Writer
+	encoder    *json.Encoder
+	apiVersion int
+}
+
+func newNDJSONProductWriter(writer io.Writer, apiVersion int) *ndjsonProductWriter {
+	buffer := bufio.NewWriter(writer)
+	return &ndjsonProductWriter{buffer: buffer, encoder: json.NewEncoder(buffer), apiVersion: apiVersion}
+}
+
+func (writer *ndjsonProductWriter) Write(product web.ProductResponse) error {
+	if writer.apiVersion == helper.ApiVersion1 {
+		return writer.encoder.Encode(helper.ToProductResponseV1(product))
+	}
+	return writer.encoder.Encode(product)
+}
+
+func (writer *ndjsonProductWriter) Flush() error {
+	return writer.buffer.Flush()
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"bubblevy/restful-api/auth"
+	"bubblevy/restful-api/exception"
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/model/domain"
+	"bubblevy/restful-api/model/web"
+	"bubblevy/restful-api/repository"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// idempotencyPurgeBatchSize is the number of expired records
+// PurgeIdempotencyKeys removes per transaction.
+const idempotencyPurgeBatchSize = 500
+
+// createIdempotent runs a create sent with an Idempotency-Key. The response
+// is stored by the transaction that saves the product, so a retry finds
+// either both or neither. A retry with a different body is refused, and one
+// arriving while the first request still runs here is told to try again.
+func (service *productServiceImpl) createIdempotent(ctx context.Context, request web.ProductCreateRequest) (web.ProductResponse, error) {
+	identity, _ := auth.FromContext(ctx)
+	record := domain.IdempotencyRecord{
+		Owner:       identity.Subject,
+		Key:         request.IdempotencyKey,
+		RequestHash: idempotencyHash(request),
+	}
+
+	inFlightKey := record.Owner + "\x00" + record.Key
+	if _, running := service.inFlight.LoadOrStore(inFlightKey, struct{}{}); running {
+		return web.ProductResponse{}, idempotencyConflict()
+	}
+	defer service.inFlight.Delete(inFlightKey)
+
+	response, err := service.createOnce(ctx, request, record)
+	if errors.Is(err, repository.ErrIdempotencyKeyExists) {
+		// another instance stored the key first and has committed by now,
+		// so a second attempt replays its response
+		response, err = service.createOnce(ctx, request, record)
+	}
+	if errors.Is(err, repository.ErrIdempotencyKeyExists) {
+		return response, idempotencyConflict()
+	}
+	return response, err
+}
+
+// createOnce replays the stored response for the key or creates the product
+// and stores its response. ErrIdempotencyKeyExists is returned as is, after
+// rolling back, when another transaction stored the key in the meantime.
+func (service *productServiceImpl) createOnce(ctx context.Context, request web.ProductCreateRequest, record domain.IdempotencyRecord) (response web.ProductResponse, err error) {
+	tx, err := helper.BeginTx(ctx, service.TxManager)
+	if err != nil {
+		return response, exception.NewInternalError(err)
+	}
+	defer helper.CommitOrRollback(tx, &err)
+
+	now := time.Now().UTC()
+	stored, err := service.IdempotencyRepository.FindByKey(ctx, tx, record.Owner, record.Key)
+	switch {
+	case err == nil && now.Before(stored.ExpiresAt):
+		return replayCreate(stored, record)
+	case err == nil:
+		err = service.IdempotencyRepository.Delete(ctx, tx, stored)
+	case errors.Is(err, repository.ErrIdempotencyRecordNotFound):
+		err = nil
+	}
+	if err != nil {
+		return response, exception.NewInternalError(err)
+	}
+
+	response, err = service.save(ctx, tx, request)
+	if err != nil {
+		return response, err
+	}
+
+	body, err := json.Marshal(response)
+	if err != nil {
+		return response, exception.NewInternalError(err)
+	}
+	record.Status = http.StatusCreated
+	record.Body = string(body)
+	record.CreatedAt = now
+	record.ExpiresAt = now.Add(service.IdempotencyTTL)
+
+	err = service.IdempotencyRepository.Save(ctx, tx, record)
+	if err != nil && !errors.Is(err, repository.ErrIdempotencyKeyExists) {
+		return response, exception.NewInternalError(err)
+	}
+	return response, err
+}
+
+// replayCreate answers a retry from the stored response, provided the retry
+// asks for the same thing as the request that stored it.
+func replayCreate(stored domain.IdempotencyRecord, record domain.IdempotencyRecord) (response web.ProductResponse, err error) {
+	if stored.RequestHash != record.RequestHash {
+		return response, exception.NewUnprocessableEntityError("Idempotency-Key " + record.Key + " was already used with a different request body")
+	}
+
+	err = json.Unmarshal([]byte(stored.Body), &response)
+	if err != nil {
+		return response, exception.NewInternalError(err)
+	}
+	return response, nil
+}
+
+// PurgeIdempotencyKeys removes the stored responses that expired at or before
+// now and returns how many it removed. An expired record is otherwise only
+// dropped when its key is sent again.
+func (service *productServiceImpl) PurgeIdempotencyKeys(ctx context.Context, now time.Time) (int, error) {
+	err := authorize(ctx, OperationProductPurgeIdempotency)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for {
+		count, err := service.purgeIdempotencyBatch(ctx, now)
+		purged += count
+		if err != nil || count < idempotencyPurgeBatchSize {
+			return purged, err
+		}
+	}
+}
+
+func (service *productServiceImpl) purgeIdempotencyBatch(ctx context.Context, now time.Time) (purged int, err error) {
+	tx, err := helper.BeginTx(ctx, service.TxManager)
+	if err != nil {
+		return 0, exception.NewInternalError(err)
+	}
+	defer helper.CommitOrRollback(tx, &err)
+
+	purged, err = service.IdempotencyRepository.DeleteExpired(ctx, tx, now, idempotencyPurgeBatchSize)
+	if err != nil {
+		return 0, exception.NewInternalError(err)
+	}
+	return purged, nil
+}
+
+func idempotencyConflict() error {
+	return exception.NewConflictError("a request with this Idempotency-Key is still in progress, retry it later")
+}
+
+// idempotencyHash fingerprints a request by its decoded fields, so retries
+// that only differ in whitespace or key order still match.
+func idempotencyHash(request interface{}) string {
+	encoded, _ := json.Marshal(request)
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])
+}
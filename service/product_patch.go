@@ -0,0 +1,157 @@
+package service
+
+import (
+	"bubblevy/restful-api/exception"
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/model/domain"
+	"bubblevy/restful-api/model/web"
+	"bubblevy/restful-api/money"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"time"
+)
+
+// Patch applies a merge patch or JSON patch to a product. The patched product
+// is validated like an update, and only the columns the patch changed are
+// written.
+func (service *productServiceImpl) Patch(ctx context.Context, request web.ProductPatchRequest) (response web.ProductResponse, err error) {
+	err = authorize(ctx, OperationProductPatch)
+	if err != nil {
+		return response, err
+	}
+
+	err = service.Validate.Struct(request)
+	if err != nil {
+		return response, exception.FromValidator(err)
+	}
+
+	tx, err := helper.BeginTx(ctx, service.TxManager)
+	if err != nil {
+		return response, exception.NewInternalError(err)
+	}
+	defer helper.CommitOrRollback(tx, &err)
+
+	product, err := service.findProduct(ctx, tx, request.Id)
+	if err != nil {
+		return response, err
+	}
+	err = checkExpectedVersion(product, request.ExpectedVersions)
+	if err != nil {
+		return response, err
+	}
+	product, err = service.applyDueSchedules(ctx, tx, product, time.Now().UTC())
+	if err != nil {
+		return response, productWriteError(err, request.ExpectedVersions)
+	}
+
+	patched, err := patchProduct(ctx, product, request)
+	if err != nil {
+		return response, err
+	}
+
+	err = service.Validate.Struct(web.ProductUpdateRequest{Id: patched.Id, ProductName: patched.ProductName, Price: patched.Price})
+	if err != nil {
+		return response, exception.FromValidator(err)
+	}
+
+	var columns []string
+	if patched.ProductName != product.ProductName {
+		columns = append(columns, "product_name")
+	}
+	if patched.Price != product.Price {
+		columns = append(columns, "price")
+	}
+	if len(columns) == 0 {
+		return helper.ToProductResponse(product), nil
+	}
+
+	patched, err = service.ProductRepository.UpdateColumns(ctx, tx, patched, columns)
+	if err != nil {
+		return response, productWriteError(err, request.ExpectedVersions)
+	}
+	err = service.audit(ctx, tx, productChange{Action: domain.AuditActionUpdate, Before: &product, After: &patched})
+	if err != nil {
+		return response, err
+	}
+	return helper.ToProductResponse(patched), nil
+}
+
+// patchProduct applies the patch to the product as clients see it, in the
+// API version of ctx. A failing test operation is a conflict with the current
+// state; any other failure is the caller's to fix.
+func patchProduct(ctx context.Context, product domain.Product, request web.ProductPatchRequest) (domain.Product, error) {
+	var rendered interface{} = helper.ToProductResponse(product)
+	if helper.ApiVersion(ctx) == helper.ApiVersion1 {
+		rendered = helper.ToProductResponseV1(rendered.(web.ProductResponse))
+	}
+	document, err := json.Marshal(rendered)
+	if err != nil {
+		return product, exception.NewInternalError(err)
+	}
+	original, err := documentPrice(document)
+	if err != nil {
+		return product, exception.NewInternalError(err)
+	}
+
+	if request.Type == web.PatchTypeMerge {
+		document, err = helper.MergePatch(document, request.Patch)
+	} else {
+		document, err = helper.JSONPatch(document, request.Patch)
+	}
+	if errors.Is(err, helper.ErrPatchTestFailed) {
+		return product, exception.NewConflictError(err.Error())
+	}
+	if err != nil {
+		return product, exception.NewValidationError(err.Error())
+	}
+
+	var patched struct {
+		Id          int             `json:"id"`
+		ProductName string          `json:"product_name"`
+		Price       json.RawMessage `json:"price"`
+		Version     int             `json:"version"`
+		DeletedAt   *time.Time      `json:"deleted_at,omitempty"`
+	}
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&patched)
+	if err != nil {
+		return product, exception.NewValidationError("the patched product is invalid: " + err.Error())
+	}
+	if patched.Id != product.Id {
+		return product, exception.NewValidationError("id cannot be changed")
+	}
+	if patched.Version != product.Version {
+		return product, exception.NewValidationError("version cannot be changed")
+	}
+
+	product.ProductName = patched.ProductName
+	// a price left as rendered keeps the stored one, which a v1 document only
+	// shows rounded and without its currency
+	price, err := documentPrice(document)
+	if err != nil {
+		return product, exception.NewInternalError(err)
+	}
+	if reflect.DeepEqual(price, original) {
+		return product, nil
+	}
+	product.Price = money.Money{}
+	if len(patched.Price) != 0 {
+		err = json.Unmarshal(patched.Price, &product.Price)
+		if err != nil {
+			return product, exception.NewValidationError("the patched product is invalid: price: " + err.Error())
+		}
+	}
+	return product, nil
+}
+
+// documentPrice reads the price of a product document as generic JSON, so
+// documents that only differ in layout compare equal.
+func documentPrice(document []byte) (interface{}, error) {
+	var fields map[string]interface{}
+	err := json.Unmarshal(document, &fields)
+	return fields["price"], err
+}
@@ -0,0 +1,342 @@
+package service
+
+import (
+	"bubblevy/restful-api/exception"
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/model/domain"
+	"bubblevy/restful-api/model/web"
+	"bubblevy/restful-api/money"
+	"bubblevy/restful-api/repository"
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// priceScheduleBatchSize is the number of products ApplyPriceSchedules looks
+// up at a time.
+const priceScheduleBatchSize = 500
+
+// errPriceScheduleSkipped rolls back the schedules of a product that changed
+// while they were applied; the next run picks them up again.
+var errPriceScheduleSkipped = errors.New("price schedule skipped")
+
+// SchedulePrice schedules a price change for a live product, in the currency
+// the product is priced in. It may not overlap the schedules of the product
+// that are still to start or to end.
+func (service *productServiceImpl) SchedulePrice(ctx context.Context, request web.PriceScheduleCreateRequest) (response web.PriceScheduleResponse, err error) {
+	err = authorize(ctx, OperationProductSchedulePrice)
+	if err != nil {
+		return response, err
+	}
+
+	err = service.Validate.Struct(request)
+	if err != nil {
+		return response, exception.FromValidator(err)
+	}
+
+	now := time.Now().UTC()
+	schedule := domain.PriceSchedule{
+		ProductId: request.ProductId,
+		Price:     request.Price,
+		StartsAt:  request.StartsAt.UTC(),
+		Status:    domain.PriceScheduleScheduled,
+		CreatedAt: now,
+	}
+	if !schedule.StartsAt.After(now) {
+		return response, exception.NewValidationError("starts_at must be in the future")
+	}
+	if request.EndsAt != nil {
+		endsAt := request.EndsAt.UTC()
+		if !endsAt.After(schedule.StartsAt) {
+			return response, exception.NewValidationError("ends_at must be after starts_at")
+		}
+		schedule.EndsAt = &endsAt
+	}
+
+	tx, err := helper.BeginTx(ctx, service.TxManager)
+	if err != nil {
+		return response, exception.NewInternalError(err)
+	}
+	defer helper.CommitOrRollback(tx, &err)
+
+	product, err := service.findProduct(ctx, tx, request.ProductId)
+	if err != nil {
+		return response, err
+	}
+	if schedule.Price.Currency != product.Price.Currency {
+		return response, exception.NewValidationError("price must be in " + product.Price.Currency + ", the currency of product " + strconv.Itoa(product.Id))
+	}
+
+	open, err := service.PriceScheduleRepository.FindOpenByProductIds(ctx, tx, []int{request.ProductId})
+	if err != nil {
+		return response, exception.NewInternalError(err)
+	}
+	for _, other := range open {
+		if schedule.Overlaps(other) {
+			return response, exception.NewConflictError("the schedule overlaps price schedule " + strconv.Itoa(other.Id) + " of product " + strconv.Itoa(request.ProductId))
+		}
+	}
+
+	schedule, err = service.PriceScheduleRepository.Save(ctx, tx, schedule)
+	if err != nil {
+		return response, exception.NewInternalError(err)
+	}
+	return helper.ToPriceScheduleResponse(schedule), nil
+}
+
+// FindPriceSchedules lists every schedule of a product, finished ones
+// included, by start time.
+func (service *productServiceImpl) FindPriceSchedules(ctx context.Context, productId int) (response []web.PriceScheduleResponse, err error) {
+	err = authorize(ctx, OperationProductFindPriceSchedules)
+	if err != nil {
+		return response, err
+	}
+
+	tx, err := helper.BeginTx(ctx, service.TxManager)
+	if err != nil {
+		return response, exception.NewInternalError(err)
+	}
+	defer helper.CommitOrRollback(tx, &err)
+
+	_, err = service.findProduct(ctx, tx, productId)
+	if err != nil {
+		return response, err
+	}
+
+	schedules, err := service.PriceScheduleRepository.FindByProductId(ctx, tx, productId)
+	if err != nil {
+		return response, exception.NewInternalError(err)
+	}
+	return helper.ToPriceScheduleResponses(schedules), nil
+}
+
+// CancelPriceSchedule stops a schedule that has not finished. Cancelling one
+// that is in effect brings back the price it replaced straight away.
+func (service *productServiceImpl) CancelPriceSchedule(ctx context.Context, productId int, scheduleId int) (response web.PriceScheduleResponse, err error) {
+	err = authorize(ctx, OperationProductCancelPriceSchedule)
+	if err != nil {
+		return response, err
+	}
+
+	tx, err := helper.BeginTx(ctx, service.TxManager)
+	if err != nil {
+		return response, exception.NewInternalError(err)
+	}
+	defer helper.CommitOrRollback(tx, &err)
+
+	product, err := service.findProduct(ctx, tx, productId)
+	if err != nil {
+		return response, err
+	}
+
+	schedule, err := service.PriceScheduleRepository.FindById(ctx, tx, productId, scheduleId)
+	if errors.Is(err, repository.ErrPriceScheduleNotFound) {
+		return response, exception.NewNotFoundError(err.Error())
+	}
+	if err != nil {
+		return response, exception.NewInternalError(err)
+	}
+	if !schedule.Open() {
+		return response, exception.NewConflictError("price schedule " + strconv.Itoa(scheduleId) + " is already " + schedule.Status)
+	}
+
+	price, cancelled := schedule.Cancel(product.Price)
+	err = service.PriceScheduleRepository.Update(ctx, tx, cancelled, schedule.Status)
+	if errors.Is(err, repository.ErrPriceScheduleConflict) {
+		return response, exception.NewConflictError(err.Error() + ", retry the request")
+	}
+	if err != nil {
+		return response, exception.NewInternalError(err)
+	}
+
+	_, err = service.reprice(ctx, tx, product, price)
+	if err != nil {
+		return response, productWriteError(err, nil)
+	}
+	return helper.ToPriceScheduleResponse(cancelled), nil
+}
+
+// FindPriceHistory returns the prices a product has had, oldest first, as
+// recorded in its revisions. Revisions that left the price alone are folded
+// into the price before them.
+func (service *productServiceImpl) FindPriceHistory(ctx context.Context, productId int) (response []web.PricePointResponse, err error) {
+	err = authorize(ctx, OperationProductFindPriceHistory)
+	if err != nil {
+		return response, err
+	}
+
+	tx, err := helper.BeginTx(ctx, service.TxManager)
+	if err != nil {
+		return response, exception.NewInternalError(err)
+	}
+	defer helper.CommitOrRollback(tx, &err)
+
+	total, err := service.ProductRepository.CountRevisions(ctx, tx, productId)
+	if err != nil {
+		return response, exception.NewInternalError(err)
+	}
+	if total == 0 {
+		return response, exception.NewNotFoundError(repository.ErrProductNotFound.Error())
+	}
+	revisions, err := service.ProductRepository.FindRevisions(ctx, tx, productId, total, 0)
+	if err != nil {
+		return response, exception.NewInternalError(err)
+	}
+
+	slices.Reverse(revisions)
+	response = []web.PricePointResponse{}
+	for _, revision := range revisions {
+		if len(response) != 0 && response[len(response)-1].Price == revision.Price {
+			continue
+		}
+		if len(response) != 0 {
+			response[len(response)-1].To = &revision.RecordedAt
+		}
+		response = append(response, web.PricePointResponse{Price: revision.Price, From: revision.RecordedAt, Revision: revision.Version})
+	}
+	return response, nil
+}
+
+// ApplyPriceSchedules applies the schedules due at `at` and returns the
+// number of products whose schedules it applied. Each product is handled in
+// its own transaction, so a failure keeps the products applied before it.
+// Products in the trash are left alone until they are restored.
+func (service *productServiceImpl) ApplyPriceSchedules(ctx context.Context, at time.Time) (int, error) {
+	err := authorize(ctx, OperationProductApplyPriceSchedules)
+	if err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	for {
+		productIds, err := service.duePriceScheduleProducts(ctx, at)
+		if err != nil {
+			return applied, err
+		}
+
+		progress := false
+		for _, productId := range productIds {
+			err = service.applyPriceSchedules(ctx, productId, at)
+			if errors.Is(err, errPriceScheduleSkipped) {
+				continue
+			}
+			if err != nil {
+				return applied, err
+			}
+			applied++
+			progress = true
+		}
+
+		if len(productIds) < priceScheduleBatchSize || !progress {
+			return applied, nil
+		}
+	}
+}
+
+func (service *productServiceImpl) duePriceScheduleProducts(ctx context.Context, at time.Time) (productIds []int, err error) {
+	tx, err := helper.BeginTx(ctx, service.TxManager)
+	if err != nil {
+		return nil, exception.NewInternalError(err)
+	}
+	defer helper.CommitOrRollback(tx, &err)
+
+	productIds, err = service.PriceScheduleRepository.FindDueProductIds(ctx, tx, at, priceScheduleBatchSize)
+	if err != nil {
+		return nil, exception.NewInternalError(err)
+	}
+	return productIds, nil
+}
+
+func (service *productServiceImpl) applyPriceSchedules(ctx context.Context, productId int, at time.Time) (err error) {
+	tx, err := helper.BeginTx(ctx, service.TxManager)
+	if err != nil {
+		return exception.NewInternalError(err)
+	}
+	defer helper.CommitOrRollback(tx, &err)
+
+	product, err := service.ProductRepository.FindById(ctx, tx, productId)
+	if errors.Is(err, repository.ErrProductNotFound) {
+		// deleted since it was listed
+		return errPriceScheduleSkipped
+	}
+	if err != nil {
+		return exception.NewInternalError(err)
+	}
+
+	_, err = service.applyDueSchedules(ctx, tx, product, at)
+	if errors.Is(err, repository.ErrPriceScheduleConflict) || errors.Is(err, repository.ErrProductVersionConflict) {
+		// another server applied them first, or the product changed
+		return errPriceScheduleSkipped
+	}
+	if err != nil {
+		return exception.NewInternalError(err)
+	}
+	return nil
+}
+
+// applyDueSchedules runs the transitions of the product's schedules that are
+// due at `at` and returns the product as they leave it. Writes run it before
+// their own change, so a schedule that fell due since the last scheduler run
+// replaces the price it was due to replace, and not the one the write brings.
+// Errors come from the repositories as they are.
+func (service *productServiceImpl) applyDueSchedules(ctx context.Context, tx helper.Tx, product domain.Product, at time.Time) (domain.Product, error) {
+	schedules, err := service.PriceScheduleRepository.FindOpenByProductIds(ctx, tx, []int{product.Id})
+	if err != nil {
+		return product, err
+	}
+
+	price := product.Price
+	for _, schedule := range schedules {
+		var applied domain.PriceSchedule
+		price, applied = schedule.Apply(price, at)
+		if applied.Status == schedule.Status {
+			continue
+		}
+		err = service.PriceScheduleRepository.Update(ctx, tx, applied, schedule.Status)
+		if err != nil {
+			return product, err
+		}
+	}
+	return service.reprice(ctx, tx, product, price)
+}
+
+// reprice writes price to the product, recording the change like any other
+// update, when it differs from the stored one, and returns the product as
+// stored. Errors come from the repositories as they are.
+func (service *productServiceImpl) reprice(ctx context.Context, tx helper.Tx, product domain.Product, price money.Money) (domain.Product, error) {
+	if price == product.Price {
+		return product, nil
+	}
+
+	repriced := product
+	repriced.Price = price
+	repriced, err := service.ProductRepository.Update(ctx, tx, repriced)
+	if err != nil {
+		return product, err
+	}
+	return repriced, service.audit(ctx, tx, productChange{Action: domain.AuditActionUpdate, Before: &product, After: &repriced})
+}
+
+// loadPriceSchedules attaches their unfinished schedules to products about to
+// be answered with, so the response shows the price in effect now.
+func (service *productServiceImpl) loadPriceSchedules(ctx context.Context, tx helper.Tx, products []domain.Product) error {
+	productIds := make([]int, 0, len(products))
+	for _, product := range products {
+		productIds = append(productIds, product.Id)
+	}
+
+	schedules, err := service.PriceScheduleRepository.FindOpenByProductIds(ctx, tx, productIds)
+	if err != nil {
+		return exception.NewInternalError(err)
+	}
+	for i := range products {
+		for _, schedule := range schedules {
+			if schedule.ProductId == products[i].Id {
+				products[i].PriceSchedules = append(products[i].PriceSchedules, schedule)
+			}
+		}
+	}
+	return nil
+}
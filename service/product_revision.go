@@ -0,0 +1,174 @@
+package service
+
+import (
+	"bubblevy/restful-api/exception"
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/model/domain"
+	"bubblevy/restful-api/model/web"
+	"bubblevy/restful-api/repository"
+	"context"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// FindRevisions lists the history of a product, newest revision first. The
+// history of a product in the trash can still be read.
+func (service *productServiceImpl) FindRevisions(ctx context.Context, request web.ProductRevisionFindAllRequest) (response web.ProductRevisionListResponse, err error) {
+	err = authorize(ctx, OperationProductFindRevisions)
+	if err != nil {
+		return response, err
+	}
+
+	err = service.Validate.Struct(request)
+	if err != nil {
+		return response, exception.FromValidator(err)
+	}
+
+	pagination := web.Pagination{Page: request.Page, PerPage: request.PerPage}
+	if pagination.Page == 0 {
+		pagination.Page = 1
+	}
+	if pagination.PerPage == 0 {
+		pagination.PerPage = defaultPerPage
+	}
+	pagination.Limit = pagination.PerPage
+	pagination.Offset = (pagination.Page - 1) * pagination.PerPage
+
+	tx, err := helper.BeginTx(ctx, service.TxManager)
+	if err != nil {
+		return response, exception.NewInternalError(err)
+	}
+	defer helper.CommitOrRollback(tx, &err)
+
+	pagination.Total, err = service.ProductRepository.CountRevisions(ctx, tx, request.Id)
+	if err != nil {
+		return response, exception.NewInternalError(err)
+	}
+	if pagination.Total == 0 {
+		return response, exception.NewNotFoundError(repository.ErrProductNotFound.Error())
+	}
+	pagination.TotalPages = (pagination.Total + pagination.PerPage - 1) / pagination.PerPage
+
+	revisions, err := service.ProductRepository.FindRevisions(ctx, tx, request.Id, pagination.Limit, pagination.Offset)
+	if err != nil {
+		return response, exception.NewInternalError(err)
+	}
+
+	return web.ProductRevisionListResponse{
+		Revisions:  helper.ToProductRevisionResponses(revisions),
+		Pagination: &pagination,
+	}, nil
+}
+
+func (service *productServiceImpl) FindRevision(ctx context.Context, productId int, revision int) (response web.ProductRevisionResponse, err error) {
+	err = authorize(ctx, OperationProductFindRevision)
+	if err != nil {
+		return response, err
+	}
+
+	tx, err := helper.BeginTx(ctx, service.TxManager)
+	if err != nil {
+		return response, exception.NewInternalError(err)
+	}
+	defer helper.CommitOrRollback(tx, &err)
+
+	found, err := service.findRevision(ctx, tx, productId, revision)
+	if err != nil {
+		return response, err
+	}
+	return helper.ToProductRevisionResponse(found), nil
+}
+
+// FindByIdAsOf returns the product as it was at asOf. A product that did not
+// exist yet, or was in the trash at the time, is not found.
+func (service *productServiceImpl) FindByIdAsOf(ctx context.Context, productId int, asOf time.Time) (response web.ProductResponse, err error) {
+	err = authorize(ctx, OperationProductFindByIdAsOf)
+	if err != nil {
+		return response, err
+	}
+
+	tx, err := helper.BeginTx(ctx, service.TxManager)
+	if err != nil {
+		return response, exception.NewInternalError(err)
+	}
+	defer helper.CommitOrRollback(tx, &err)
+
+	revision, err := service.ProductRepository.FindRevisionAsOf(ctx, tx, productId, asOf.UTC())
+	if err == nil && revision.DeletedAt != nil {
+		err = repository.ErrProductRevisionNotFound
+	}
+	if errors.Is(err, repository.ErrProductRevisionNotFound) {
+		return response, exception.NewNotFoundError("product " + strconv.Itoa(productId) + " did not exist at " + asOf.Format(time.RFC3339))
+	}
+	if err != nil {
+		return response, exception.NewInternalError(err)
+	}
+	return helper.ToProductResponse(revision.Product), nil
+}
+
+// Revert writes the name and price of an earlier revision over the product.
+// Reverting to a revision recorded in the trash keeps the product live; only
+// Restore takes a product out of the trash.
+func (service *productServiceImpl) Revert(ctx context.Context, request web.ProductRevertRequest) (response web.ProductResponse, err error) {
+	err = authorize(ctx, OperationProductRevert)
+	if err != nil {
+		return response, err
+	}
+
+	err = service.Validate.Struct(request)
+	if err != nil {
+		return response, exception.FromValidator(err)
+	}
+
+	tx, err := helper.BeginTx(ctx, service.TxManager)
+	if err != nil {
+		return response, exception.NewInternalError(err)
+	}
+	defer helper.CommitOrRollback(tx, &err)
+
+	product, err := service.findProduct(ctx, tx, request.Id)
+	if err != nil {
+		return response, err
+	}
+	err = checkExpectedVersion(product, request.ExpectedVersions)
+	if err != nil {
+		return response, err
+	}
+	product, err = service.applyDueSchedules(ctx, tx, product, time.Now().UTC())
+	if err != nil {
+		return response, productWriteError(err, request.ExpectedVersions)
+	}
+
+	revision, err := service.findRevision(ctx, tx, request.Id, request.Revision)
+	if err != nil {
+		return response, err
+	}
+	if revision.ProductName == product.ProductName && revision.Price == product.Price {
+		return helper.ToProductResponse(product), nil
+	}
+
+	reverted := product
+	reverted.ProductName = revision.ProductName
+	reverted.Price = revision.Price
+	reverted, err = service.ProductRepository.Update(ctx, tx, reverted)
+	if err != nil {
+		return response, productWriteError(err, request.ExpectedVersions)
+	}
+	err = service.audit(ctx, tx, productChange{Action: domain.AuditActionRevert, Before: &product, After: &reverted})
+	if err != nil {
+		return response, err
+	}
+	return helper.ToProductResponse(reverted), nil
+}
+
+func (service *productServiceImpl) findRevision(ctx context.Context, tx helper.Tx, productId int, revision int) (domain.ProductRevision, error) {
+	found, err := service.ProductRepository.FindRevision(ctx, tx, productId, revision)
+	if errors.Is(err, repository.ErrProductRevisionNotFound) {
+		return found, exception.NewNotFoundError("product " + strconv.Itoa(productId) + " has no revision " + strconv.Itoa(revision))
+	}
+	if err != nil {
+		return found, exception.NewInternalError(err)
+	}
+	return found, nil
+}
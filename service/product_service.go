@@ -3,12 +3,32 @@ package service
 import (
 	"bubblevy/restful-api/model/web"
 	"context"
+	"io"
+	"time"
 )
 
 type ProductService interface {
-	Create(ctx context.Context, request web.ProductCreateRequest) web.ProductResponse
-	Update(ctx context.Context, request web.ProductUpdateRequest) web.ProductResponse
-	Delete(ctx context.Context, productId int)
-	FindById(ctx context.Context, productId int) web.ProductResponse
-	FindAll(ctx context.Context) []web.ProductResponse
+	Create(ctx context.Context, request web.ProductCreateRequest) (web.ProductResponse, error)
+	Update(ctx context.Context, request web.ProductUpdateRequest) (web.ProductResponse, error)
+	Patch(ctx context.Context, request web.ProductPatchRequest) (web.ProductResponse, error)
+	Delete(ctx context.Context, request web.ProductDeleteRequest) error
+	FindById(ctx context.Context, productId int) (web.ProductResponse, error)
+	FindAll(ctx context.Context, request web.ProductFindAllRequest) (web.ProductListResponse, error)
+	Bulk(ctx context.Context, request web.ProductBulkRequest) (web.ProductBulkResponse, error)
+	Import(ctx context.Context, request web.ProductImportRequest) (web.ProductImportResponse, error)
+	Export(ctx context.Context, request web.ProductExportRequest, writer io.Writer) error
+	FindTrash(ctx context.Context, request web.ProductFindAllRequest) (web.ProductListResponse, error)
+	Restore(ctx context.Context, request web.ProductRestoreRequest) (web.ProductResponse, error)
+	Purge(ctx context.Context, request web.ProductPurgeRequest) error
+	PurgeTrash(ctx context.Context, deletedBefore time.Time) (int, error)
+	PurgeIdempotencyKeys(ctx context.Context, now time.Time) (int, error)
+	FindRevisions(ctx context.Context, request web.ProductRevisionFindAllRequest) (web.ProductRevisionListResponse, error)
+	FindRevision(ctx context.Context, productId int, revision int) (web.ProductRevisionResponse, error)
+	FindByIdAsOf(ctx context.Context, productId int, asOf time.Time) (web.ProductResponse, error)
+	Revert(ctx context.Context, request web.ProductRevertRequest) (web.ProductResponse, error)
+	SchedulePrice(ctx context.Context, request web.PriceScheduleCreateRequest) (web.PriceScheduleResponse, error)
+	FindPriceSchedules(ctx context.Context, productId int) ([]web.PriceScheduleResponse, error)
+	CancelPriceSchedule(ctx context.Context, productId int, scheduleId int) (web.PriceScheduleResponse, error)
+	FindPriceHistory(ctx context.Context, productId int) ([]web.PricePointResponse, error)
+	ApplyPriceSchedules(ctx context.Context, at time.Time) (int, error)
 }
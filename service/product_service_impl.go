@@ -5,96 +5,438 @@ import (
 	"bubblevy/restful-api/helper"
 	"bubblevy/restful-api/model/domain"
 	"bubblevy/restful-api/model/web"
+	"bubblevy/restful-api/money"
 	"bubblevy/restful-api/repository"
 	"context"
-	"database/sql"
+	"errors"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 )
 
 type productServiceImpl struct {
-	ProductRepository repository.ProductRepository
-	DB                *sql.DB
-	Validate          *validator.Validate
+	ProductRepository       repository.ProductRepository
+	IdempotencyRepository   repository.IdempotencyRepository
+	AuditRepository         repository.AuditRepository
+	PriceScheduleRepository repository.PriceScheduleRepository
+	TxManager               helper.TxManager
+	Validate                *validator.Validate
+	CursorCodec             *helper.CursorCodec
+	IdempotencyTTL          time.Duration
+
+	// inFlight holds the idempotency keys of creates still running here.
+	inFlight sync.Map
 }
 
-func NewProductService(productRepository repository.ProductRepository, DB *sql.DB, validate *validator.Validate) ProductService {
+func NewProductService(productRepository repository.ProductRepository, idempotencyRepository repository.IdempotencyRepository, auditRepository repository.AuditRepository, priceScheduleRepository repository.PriceScheduleRepository, txManager helper.TxManager, validate *validator.Validate, cursorCodec *helper.CursorCodec, idempotencyTTL time.Duration) ProductService {
 	return &productServiceImpl{
-		ProductRepository: productRepository,
-		DB:                DB,
-		Validate:          validate,
+		ProductRepository:       productRepository,
+		IdempotencyRepository:   idempotencyRepository,
+		AuditRepository:         auditRepository,
+		PriceScheduleRepository: priceScheduleRepository,
+		TxManager:               txManager,
+		Validate:                validate,
+		CursorCodec:             cursorCodec,
+		IdempotencyTTL:          idempotencyTTL,
 	}
 }
 
-func (service *productServiceImpl) Create(ctx context.Context, request web.ProductCreateRequest) web.ProductResponse {
-	err := service.Validate.Struct(request)
-	helper.PanicIfError(err)
+func (service *productServiceImpl) Create(ctx context.Context, request web.ProductCreateRequest) (response web.ProductResponse, err error) {
+	err = authorize(ctx, OperationProductCreate)
+	if err != nil {
+		return response, err
+	}
 
-	tx, err := service.DB.Begin()
-	helper.PanicIfError(err)
-	defer helper.CommitOrRollback(tx)
+	err = service.Validate.Struct(request)
+	if err != nil {
+		return response, exception.FromValidator(err)
+	}
+
+	if request.IdempotencyKey != "" {
+		return service.createIdempotent(ctx, request)
+	}
 
+	tx, err := helper.BeginTx(ctx, service.TxManager)
+	if err != nil {
+		return response, exception.NewInternalError(err)
+	}
+	defer helper.CommitOrRollback(tx, &err)
+
+	return service.save(ctx, tx, request)
+}
+
+func (service *productServiceImpl) save(ctx context.Context, tx helper.Tx, request web.ProductCreateRequest) (web.ProductResponse, error) {
 	product := domain.Product{
 		ProductName: request.ProductName,
 		Price:       request.Price,
 	}
 
-	product = service.ProductRepository.Save(ctx, tx, product)
-	return helper.ToProductResponse(product)
+	product, err := service.ProductRepository.Save(ctx, tx, product)
+	if err != nil {
+		return web.ProductResponse{}, exception.NewInternalError(err)
+	}
+	err = service.audit(ctx, tx, productChange{Action: domain.AuditActionCreate, After: &product})
+	if err != nil {
+		return web.ProductResponse{}, err
+	}
+	return helper.ToProductResponse(product), nil
 }
 
-func (service *productServiceImpl) Update(ctx context.Context, request web.ProductUpdateRequest) web.ProductResponse {
-	err := service.Validate.Struct(request)
-	helper.PanicIfError(err)
+func (service *productServiceImpl) Update(ctx context.Context, request web.ProductUpdateRequest) (response web.ProductResponse, err error) {
+	err = authorize(ctx, OperationProductUpdate)
+	if err != nil {
+		return response, err
+	}
 
-	tx, err := service.DB.Begin()
-	helper.PanicIfError(err)
-	defer helper.CommitOrRollback(tx)
+	err = service.Validate.Struct(request)
+	if err != nil {
+		return response, exception.FromValidator(err)
+	}
 
-	product, err := service.ProductRepository.FindById(ctx, tx, request.Id)
+	tx, err := helper.BeginTx(ctx, service.TxManager)
 	if err != nil {
-		panic(exception.NewNotFoundError(err.Error()))
+		return response, exception.NewInternalError(err)
 	}
+	defer helper.CommitOrRollback(tx, &err)
 
+	product, err := service.findProduct(ctx, tx, request.Id)
+	if err != nil {
+		return response, err
+	}
+	err = checkExpectedVersion(product, request.ExpectedVersions)
+	if err != nil {
+		return response, err
+	}
+	product, err = service.applyDueSchedules(ctx, tx, product, time.Now().UTC())
+	if err != nil {
+		return response, productWriteError(err, request.ExpectedVersions)
+	}
+
+	price, err := requestPrice(ctx, product, request.Price)
+	if err != nil {
+		return response, err
+	}
+
+	before := product
 	product.ProductName = request.ProductName
-	product.Price = request.Price
+	product.Price = price
 
-	product = service.ProductRepository.Update(ctx, tx, product)
-	return helper.ToProductResponse(product)
+	product, err = service.ProductRepository.Update(ctx, tx, product)
+	if err != nil {
+		return response, productWriteError(err, request.ExpectedVersions)
+	}
+	err = service.audit(ctx, tx, productChange{Action: domain.AuditActionUpdate, Before: &before, After: &product})
+	if err != nil {
+		return response, err
+	}
+	return helper.ToProductResponse(product), nil
 }
 
-func (service *productServiceImpl) Delete(ctx context.Context, productId int) {
-	tx, err := service.DB.Begin()
-	helper.PanicIfError(err)
-	defer helper.CommitOrRollback(tx)
+func (service *productServiceImpl) Delete(ctx context.Context, request web.ProductDeleteRequest) (err error) {
+	err = authorize(ctx, OperationProductDelete)
+	if err != nil {
+		return err
+	}
 
-	product, err := service.ProductRepository.FindById(ctx, tx, productId)
+	err = service.Validate.Struct(request)
 	if err != nil {
-		panic(exception.NewNotFoundError(err.Error()))
+		return exception.FromValidator(err)
 	}
 
-	service.ProductRepository.Delete(ctx, tx, product)
+	tx, err := helper.BeginTx(ctx, service.TxManager)
+	if err != nil {
+		return exception.NewInternalError(err)
+	}
+	defer helper.CommitOrRollback(tx, &err)
+
+	product, err := service.findProduct(ctx, tx, request.Id)
+	if err != nil {
+		return err
+	}
+	err = checkExpectedVersion(product, request.ExpectedVersions)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := service.ProductRepository.Delete(ctx, tx, product, time.Now().UTC())
+	if err != nil {
+		return productWriteError(err, request.ExpectedVersions)
+	}
+	return service.audit(ctx, tx, productChange{Action: domain.AuditActionDelete, Before: &product, After: &deleted})
 }
 
-func (service *productServiceImpl) FindById(ctx context.Context, productId int) web.ProductResponse {
-	tx, err := service.DB.Begin()
-	helper.PanicIfError(err)
-	defer helper.CommitOrRollback(tx)
+func (service *productServiceImpl) FindById(ctx context.Context, productId int) (response web.ProductResponse, err error) {
+	err = authorize(ctx, OperationProductFindById)
+	if err != nil {
+		return response, err
+	}
+
+	tx, err := helper.BeginTx(ctx, service.TxManager)
+	if err != nil {
+		return response, exception.NewInternalError(err)
+	}
+	defer helper.CommitOrRollback(tx, &err)
 
+	product, err := service.findProduct(ctx, tx, productId)
+	if err != nil {
+		return response, err
+	}
+	products := []domain.Product{product}
+	err = service.loadPriceSchedules(ctx, tx, products)
+	if err != nil {
+		return response, err
+	}
+
+	return helper.ToProductResponse(products[0]), nil
+}
+
+// findProduct loads a product, telling a missing row apart from a failing query.
+func (service *productServiceImpl) findProduct(ctx context.Context, tx helper.Tx, productId int) (domain.Product, error) {
 	product, err := service.ProductRepository.FindById(ctx, tx, productId)
+	if errors.Is(err, repository.ErrProductNotFound) {
+		return product, exception.NewNotFoundError(err.Error())
+	}
+	if err != nil {
+		return product, exception.NewInternalError(err)
+	}
+	return product, nil
+}
+
+// checkExpectedVersion enforces the version a caller last saw, when it sent
+// one.
+func checkExpectedVersion(product domain.Product, expected []int) error {
+	if len(expected) == 0 || slices.Contains(expected, product.Version) {
+		return nil
+	}
+	return exception.NewPreconditionFailedError("product " + strconv.Itoa(product.Id) + " is at version " + strconv.Itoa(product.Version))
+}
+
+// requestPrice is the price a write from the caller sets on product. A v1
+// caller's price in money.DefaultCurrency is taken as the whole units v1
+// shows, see legacyPrice.
+func requestPrice(ctx context.Context, product domain.Product, price money.Money) (money.Money, error) {
+	if helper.ApiVersion(ctx) != helper.ApiVersion1 || price.Currency != money.DefaultCurrency {
+		return price, nil
+	}
+	return legacyPrice(product, price)
+}
+
+// legacyPrice reads a price sent for product in whole units and without a
+// currency, the way v1 clients and uploads without a currency send it. v1
+// shows prices rounded to whole units, so sending back the one shown keeps
+// the stored price. Any other price is in money.DefaultCurrency, which a
+// product priced in another currency refuses rather than change currency.
+func legacyPrice(product domain.Product, price money.Money) (money.Money, error) {
+	shown, err := money.Legacy(product.Price.Major())
+	if err == nil && price == shown {
+		return product.Price, nil
+	}
+	if product.Price.Currency != money.DefaultCurrency {
+		return price, exception.NewConflictError("product " + strconv.Itoa(product.Id) + " is priced in " + product.Price.Currency + ", send its price with API-Version 2 or a currency")
+	}
+	return price, nil
+}
+
+// productWriteError maps a failed update or delete. A version conflict means
+// another request changed the product between our read and write; it fails
+// the caller's precondition if it had one and is a plain conflict otherwise.
+// A schedule applied by someone else first is a plain conflict too.
+func productWriteError(err error, expected []int) error {
+	if errors.Is(err, repository.ErrPriceScheduleConflict) {
+		return exception.NewConflictError(err.Error() + ", retry the request")
+	}
+	if !errors.Is(err, repository.ErrProductVersionConflict) {
+		return exception.NewInternalError(err)
+	}
+	if len(expected) != 0 {
+		return exception.NewPreconditionFailedError(err.Error())
+	}
+	return exception.NewConflictError(err.Error() + ", retry the request")
+}
+
+func (service *productServiceImpl) FindAll(ctx context.Context, request web.ProductFindAllRequest) (web.ProductListResponse, error) {
+	err := authorize(ctx, OperationProductFindAll)
+	if err != nil {
+		return web.ProductListResponse{}, err
+	}
+
+	return service.findAll(ctx, request, false)
+}
+
+// findAll lists the live products, or the ones in the trash when deleted is
+// set, with either kind of pagination.
+func (service *productServiceImpl) findAll(ctx context.Context, request web.ProductFindAllRequest, deleted bool) (web.ProductListResponse, error) {
+	err := service.Validate.Struct(request)
+	if err != nil {
+		return web.ProductListResponse{}, exception.FromValidator(err)
+	}
+
+	sorts, err := parseProductSorts(request.Sort)
+	if err != nil {
+		return web.ProductListResponse{}, exception.NewValidationError(err.Error())
+	}
+
+	filter := domain.ProductFilter{
+		NameContains: request.NameContains,
+		Deleted:      deleted,
+	}
+	err = priceFilter(request, &filter)
+	if err != nil {
+		return web.ProductListResponse{}, err
+	}
+
+	if request.Mode == "cursor" || request.Cursor != "" {
+		return service.findAllByCursor(ctx, request, filter, sorts)
+	}
+	return service.findAllByOffset(ctx, request, filter, sorts)
+}
+
+// priceFilter narrows filter to the currency and the price range of request.
+// Amounts in different currencies do not compare, so a range always applies
+// to one currency, money.DefaultCurrency unless another was asked for.
+func priceFilter(request web.ProductFindAllRequest, filter *domain.ProductFilter) error {
+	filter.Currency = request.Currency
+	if _, ok := money.LookupCurrency(filter.Currency); filter.Currency != "" && !ok {
+		return exception.NewValidationError("currency " + filter.Currency + " is not an ISO 4217 currency")
+	}
+	if request.MinPrice == "" && request.MaxPrice == "" {
+		return nil
+	}
+	if filter.Currency == "" {
+		filter.Currency = money.DefaultCurrency
+	}
+
+	bounds := []struct {
+		name   string
+		amount string
+		target **int64
+	}{
+		{"min_price", request.MinPrice, &filter.MinPrice},
+		{"max_price", request.MaxPrice, &filter.MaxPrice},
+	}
+	for _, bound := range bounds {
+		if bound.amount == "" {
+			continue
+		}
+		price, err := money.Parse(bound.amount, filter.Currency)
+		if err != nil {
+			return exception.NewValidationError(bound.name + ": " + err.Error())
+		}
+		if price.Amount < 0 {
+			return exception.NewValidationError(bound.name + " must not be negative")
+		}
+		*bound.target = &price.Amount
+	}
+
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		return exception.NewValidationError("min_price must not be greater than max_price")
+	}
+	return nil
+}
+
+func (service *productServiceImpl) findAllByOffset(ctx context.Context, request web.ProductFindAllRequest, filter domain.ProductFilter, sorts []domain.ProductSort) (response web.ProductListResponse, err error) {
+	query := domain.ProductQuery{Filter: filter, Sorts: sorts}
+	pagination := web.Pagination{}
+	if request.Limit != 0 || request.Offset != 0 {
+		query.Limit = request.Limit
+		if query.Limit == 0 {
+			query.Limit = defaultPerPage
+		}
+		query.Offset = request.Offset
+	} else {
+		pagination.Page = request.Page
+		if pagination.Page == 0 {
+			pagination.Page = 1
+		}
+		pagination.PerPage = request.PerPage
+		if pagination.PerPage == 0 {
+			pagination.PerPage = defaultPerPage
+		}
+		query.Limit = pagination.PerPage
+		query.Offset = (pagination.Page - 1) * pagination.PerPage
+	}
+
+	tx, err := helper.BeginTx(ctx, service.TxManager)
+	if err != nil {
+		return response, exception.NewInternalError(err)
+	}
+	defer helper.CommitOrRollback(tx, &err)
+
+	products, err := service.ProductRepository.FindAllByQuery(ctx, tx, query)
+	if err != nil {
+		return response, exception.NewInternalError(err)
+	}
+	err = service.loadPriceSchedules(ctx, tx, products)
+	if err != nil {
+		return response, err
+	}
+	pagination.Total, err = service.ProductRepository.CountByFilter(ctx, tx, query.Filter)
 	if err != nil {
-		panic(exception.NewNotFoundError(err.Error()))
+		return response, exception.NewInternalError(err)
+	}
+	pagination.Limit = query.Limit
+	pagination.Offset = query.Offset
+	if pagination.PerPage != 0 {
+		pagination.TotalPages = (pagination.Total + pagination.PerPage - 1) / pagination.PerPage
 	}
 
-	return helper.ToProductResponse(product)
+	return web.ProductListResponse{
+		Products:   helper.ToProductResponses(products),
+		Pagination: &pagination,
+	}, nil
 }
 
-func (service *productServiceImpl) FindAll(ctx context.Context) []web.ProductResponse {
-	tx, err := service.DB.Begin()
-	helper.PanicIfError(err)
-	defer helper.CommitOrRollback(tx)
+func (service *productServiceImpl) findAllByCursor(ctx context.Context, request web.ProductFindAllRequest, filter domain.ProductFilter, sorts []domain.ProductSort) (response web.ProductListResponse, err error) {
+	if request.Page != 0 || request.PerPage != 0 || request.Offset != 0 {
+		return response, exception.NewValidationError("cursor pagination does not accept page, per_page or offset")
+	}
+	if len(sorts) > 1 {
+		return response, exception.NewValidationError("cursor pagination supports a single sort field")
+	}
+
+	sortKey := productSortKey(sorts)
+	query := domain.ProductQuery{Filter: filter, Sorts: sorts, Limit: request.Limit}
+	if query.Limit == 0 {
+		query.Limit = defaultPerPage
+	}
 
-	products := service.ProductRepository.FindAll(ctx, tx)
+	if request.Cursor != "" {
+		after, err := service.decodeProductCursor(request.Cursor, sortKey, sorts)
+		if err != nil {
+			return response, exception.NewValidationError(err.Error())
+		}
+		query.After = &after
+	}
+
+	// one extra row tells whether another page exists without counting the table
+	query.Limit++
+
+	tx, err := helper.BeginTx(ctx, service.TxManager)
+	if err != nil {
+		return response, exception.NewInternalError(err)
+	}
+	defer helper.CommitOrRollback(tx, &err)
+
+	products, err := service.ProductRepository.FindAllByQuery(ctx, tx, query)
+	if err != nil {
+		return response, exception.NewInternalError(err)
+	}
+	err = service.loadPriceSchedules(ctx, tx, products)
+	if err != nil {
+		return response, err
+	}
+
+	if len(products) == query.Limit {
+		products = products[:len(products)-1]
+		response.NextCursor, err = service.encodeProductCursor(products[len(products)-1], sortKey, sorts)
+		if err != nil {
+			return response, exception.NewInternalError(err)
+		}
+	}
+	response.Products = helper.ToProductResponses(products)
 
-	return helper.ToProductResponses(products)
+	return response, nil
 }
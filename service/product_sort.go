@@ -0,0 +1,42 @@
+package service
+
+import (
+	"bubblevy/restful-api/model/domain"
+	"errors"
+	"strings"
+)
+
+const defaultPerPage = 20
+
+var productSortableFields = map[string]bool{
+	"id":           true,
+	"product_name": true,
+	"price":        true,
+}
+
+// parseProductSorts turns a sort expression such as "price,-product_name"
+// into sort columns. A leading "-" means descending order.
+func parseProductSorts(sort string) ([]domain.ProductSort, error) {
+	var sorts []domain.ProductSort
+	if sort == "" {
+		return sorts, nil
+	}
+
+	seen := map[string]bool{}
+	for _, field := range strings.Split(sort, ",") {
+		field = strings.TrimSpace(field)
+		descending := strings.HasPrefix(field, "-")
+		field = strings.TrimPrefix(field, "-")
+
+		if !productSortableFields[field] {
+			return nil, errors.New("unsupported sort field: " + field)
+		}
+		if seen[field] {
+			return nil, errors.New("duplicate sort field: " + field)
+		}
+		seen[field] = true
+
+		sorts = append(sorts, domain.ProductSort{Column: field, Descending: descending})
+	}
+	return sorts, nil
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"bubblevy/restful-api/exception"
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/model/domain"
+	"bubblevy/restful-api/model/web"
+	"cmp"
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	// exportPageSize bounds the products an export holds in memory at once.
+	exportPageSize = 500
+	// importChunkSize is the number of rows an import commits per transaction.
+	importChunkSize = 500
+	// maxImportErrors bounds the row errors an import report lists; the
+	// failed count stays exact.
+	maxImportErrors = 1000
+)
+
+// Export writes every product to writer, ordered by id, at the price in effect
+// like the other reads. Each page is read in its own short transaction, so a
+// slow client never holds one open; products changed while the export runs
+// may show up in either state.
+func (service *productServiceImpl) Export(ctx context.Context, request web.ProductExportRequest, writer io.Writer) error {
+	err := authorize(ctx, OperationProductExport)
+	if err != nil {
+		return err
+	}
+
+	err = service.Validate.Struct(request)
+	if err != nil {
+		return exception.FromValidator(err)
+	}
+
+	rows, err := newProductRowWriter(request.Format, writer, helper.ApiVersion(ctx))
+	if err != nil {
+		return err
+	}
+
+	query := domain.ProductQuery{Limit: exportPageSize}
+	for {
+		products, err := service.exportPage(ctx, query)
+		if err != nil {
+			return err
+		}
+		for _, product := range products {
+			err = rows.Write(helper.ToProductResponse(product))
+			if err != nil {
+				return err
+			}
+		}
+		err = rows.Flush()
+		if err != nil {
+			return err
+		}
+
+		if len(products) < exportPageSize {
+			return nil
+		}
+		query.After = &domain.ProductKeyset{Id: products[len(products)-1].Id}
+	}
+}
+
+func (service *productServiceImpl) exportPage(ctx context.Context, query domain.ProductQuery) (products []domain.Product, err error) {
+	tx, err := helper.BeginTx(ctx, service.TxManager)
+	if err != nil {
+		return nil, exception.NewInternalError(err)
+	}
+	defer helper.CommitOrRollback(tx, &err)
+
+	products, err = service.ProductRepository.FindAllByQuery(ctx, tx, query)
+	if err != nil {
+		return nil, exception.NewInternalError(err)
+	}
+	err = service.loadPriceSchedules(ctx, tx, products)
+	if err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// Import upserts the rows of an upload, committing them in chunks of
+// importChunkSize. Rows that fail to parse, validate or match are reported
+// and skipped. A storage failure stops the import; chunks committed before it
+// stay committed.
+func (service *productServiceImpl) Import(ctx context.Context, request web.ProductImportRequest) (response web.ProductImportResponse, err error) {
+	err = authorize(ctx, OperationProductImport)
+	if err != nil {
+		return response, err
+	}
+
+	err = service.Validate.Struct(request)
+	if err != nil {
+		return response, exception.FromValidator(err)
+	}
+
+	response.Format = request.Format
+	response.Key = request.Key
+	if response.Key == "" {
+		response.Key = web.ImportKeyId
+	}
+	response.Errors = []web.ProductImportError{}
+
+	rows, err := newProductRowReader(request.Format, request.Body)
+	if err != nil {
+		return response, importReadError(err)
+	}
+
+	chunk := make([]productRow, 0, importChunkSize)
+	for {
+		row, err := rows.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return response, importReadError(err)
+		}
+
+		response.Rows++
+		if row.Err == nil {
+			row.Err = service.checkImportRow(row, response.Key)
+		}
+		if row.Err != nil {
+			addImportError(&response, row.Line, row.Err)
+			continue
+		}
+
+		chunk = append(chunk, row)
+		if len(chunk) == importChunkSize {
+			err = service.importChunk(ctx, chunk, &response)
+			if err != nil {
+				return response, err
+			}
+			chunk = chunk[:0]
+		}
+	}
+
+	err = service.importChunk(ctx, chunk, &response)
+	if err != nil {
+		return response, err
+	}
+
+	slices.SortStableFunc(response.Errors, func(a, b web.ProductImportError) int {
+		return cmp.Compare(a.Line, b.Line)
+	})
+	return response, nil
+}
+
+// checkImportRow validates a row as the create or update request it stands
+// for. Rows matched by name are checked as creates, their id and version are
+// ignored.
+func (service *productServiceImpl) checkImportRow(row productRow, key string) error {
+	if key == web.ImportKeyId && row.Id != 0 {
+		request := web.ProductUpdateRequest{Id: row.Id, ProductName: row.ProductName, Price: row.Price}
+		return exception.FromValidator(service.Validate.Struct(request))
+	}
+	request := web.ProductCreateRequest{ProductName: row.ProductName, Price: row.Price}
+	return exception.FromValidator(service.Validate.Struct(request))
+}
+
+// importChunk applies rows in one transaction. Consecutive creates are saved
+// together with one multi-row insert.
+func (service *productServiceImpl) importChunk(ctx context.Context, rows []productRow, response *web.ProductImportResponse) (err error) {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	tx, err := helper.BeginTx(ctx, service.TxManager)
+	if err != nil {
+		return exception.NewInternalError(err)
+	}
+	defer helper.CommitOrRollback(tx, &err)
+
+	var changes []productChange
+	var pending []domain.Product
+	pendingNames := map[string]bool{}
+	saveCreates := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		saved, err := service.ProductRepository.SaveAll(ctx, tx, pending)
+		if err != nil {
+			return exception.NewInternalError(err)
+		}
+		for i := range saved {
+			changes = append(changes, productChange{Action: domain.AuditActionCreate, After: &saved[i]})
+		}
+		response.Created += len(pending)
+		pending = pending[:0]
+		clear(pendingNames)
+		return nil
+	}
+
+	for _, row := range rows {
+		var matches []domain.Product
+		if response.Key == web.ImportKeyProductName {
+			// a name created earlier in the chunk has to be saved to be found
+			if pendingNames[row.ProductName] {
+				err = saveCreates()
+				if err != nil {
+					return err
+				}
+			}
+			matches, err = service.ProductRepository.FindByProductName(ctx, tx, row.ProductName)
+			if err != nil {
+				return exception.NewInternalError(err)
+			}
+		} else if row.Id != 0 {
+			product, err := service.findProduct(ctx, tx, row.Id)
+			if err == nil && row.Version != 0 {
+				err = checkExpectedVersion(product, []int{row.Version})
+			}
+			var notFound exception.NotFoundError
+			var preconditionFailed exception.PreconditionFailedError
+			if errors.As(err, &notFound) || errors.As(err, &preconditionFailed) {
+				addImportError(response, row.Line, err)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			matches = []domain.Product{product}
+		}
+
+		switch len(matches) {
+		case 0:
+			pending = append(pending, domain.Product{ProductName: row.ProductName, Price: row.Price})
+			pendingNames[row.ProductName] = true
+		case 1:
+			before, err := service.applyDueSchedules(ctx, tx, matches[0], time.Now().UTC())
+			if err != nil {
+				return productWriteError(err, nil)
+			}
+			price := row.Price
+			if row.LegacyPrice {
+				price, err = legacyPrice(before, row.Price)
+				if err != nil {
+					addImportError(response, row.Line, err)
+					continue
+				}
+			}
+			product := before
+			product.ProductName = row.ProductName
+			product.Price = price
+			product, err = service.ProductRepository.Update(ctx, tx, product)
+			if err != nil {
+				return productWriteError(err, nil)
+			}
+			changes = append(changes, productChange{Action: domain.AuditActionUpdate, Before: &before, After: &product})
+			response.Updated++
+		default:
+			addImportError(response, row.Line, exception.NewConflictError("product_name matches "+strconv.Itoa(len(matches))+" products, import by id instead"))
+		}
+	}
+
+	err = saveCreates()
+	if err != nil {
+		return err
+	}
+	return service.audit(ctx, tx, changes...)
+}
+
+func addImportError(response *web.ProductImportResponse, line int, err error) {
+	response.Failed++
+	if len(response.Errors) == maxImportErrors {
+		response.ErrorsTruncated = true
+		return
+	}
+	response.Errors = append(response.Errors, web.ProductImportError{Line: line, Err: err})
+}
+
+// importReadError reports an upload that cannot be read as the caller's
+// mistake; the reader has already described malformed content.
+func importReadError(err error) error {
+	var validation exception.ValidationError
+	if errors.As(err, &validation) {
+		return err
+	}
+	return exception.NewValidationError("the upload could not be read: " + err.Error())
+}
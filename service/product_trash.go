@@ -0,0 +1,171 @@
+package service
+
+import (
+	"bubblevy/restful-api/exception"
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/model/domain"
+	"bubblevy/restful-api/model/web"
+	"bubblevy/restful-api/repository"
+	"context"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// trashPurgeBatchSize is the number of products PurgeTrash removes per
+// transaction.
+const trashPurgeBatchSize = 500
+
+// FindTrash lists the deleted products, paginated and filtered like FindAll.
+func (service *productServiceImpl) FindTrash(ctx context.Context, request web.ProductFindAllRequest) (web.ProductListResponse, error) {
+	err := authorize(ctx, OperationProductFindTrash)
+	if err != nil {
+		return web.ProductListResponse{}, err
+	}
+
+	return service.findAll(ctx, request, true)
+}
+
+func (service *productServiceImpl) Restore(ctx context.Context, request web.ProductRestoreRequest) (response web.ProductResponse, err error) {
+	err = authorize(ctx, OperationProductRestore)
+	if err != nil {
+		return response, err
+	}
+
+	err = service.Validate.Struct(request)
+	if err != nil {
+		return response, exception.FromValidator(err)
+	}
+
+	tx, err := helper.BeginTx(ctx, service.TxManager)
+	if err != nil {
+		return response, exception.NewInternalError(err)
+	}
+	defer helper.CommitOrRollback(tx, &err)
+
+	product, err := service.findDeletedProduct(ctx, tx, request.Id)
+	if err != nil {
+		return response, err
+	}
+	err = checkExpectedVersion(product, request.ExpectedVersions)
+	if err != nil {
+		return response, err
+	}
+
+	restored, err := service.ProductRepository.Restore(ctx, tx, product)
+	if err != nil {
+		return response, productWriteError(err, request.ExpectedVersions)
+	}
+	err = service.audit(ctx, tx, productChange{Action: domain.AuditActionRestore, Before: &product, After: &restored})
+	if err != nil {
+		return response, err
+	}
+	return helper.ToProductResponse(restored), nil
+}
+
+// Purge removes a product from the trash for good. A live product has to be
+// deleted first, so nothing skips the trash by accident.
+func (service *productServiceImpl) Purge(ctx context.Context, request web.ProductPurgeRequest) (err error) {
+	err = authorize(ctx, OperationProductPurge)
+	if err != nil {
+		return err
+	}
+
+	err = service.Validate.Struct(request)
+	if err != nil {
+		return exception.FromValidator(err)
+	}
+
+	tx, err := helper.BeginTx(ctx, service.TxManager)
+	if err != nil {
+		return exception.NewInternalError(err)
+	}
+	defer helper.CommitOrRollback(tx, &err)
+
+	product, err := service.findDeletedProduct(ctx, tx, request.Id)
+	if err != nil {
+		return err
+	}
+	err = checkExpectedVersion(product, request.ExpectedVersions)
+	if err != nil {
+		return err
+	}
+
+	err = service.ProductRepository.Purge(ctx, tx, product)
+	if err != nil {
+		return productWriteError(err, request.ExpectedVersions)
+	}
+	return service.audit(ctx, tx, productChange{Action: domain.AuditActionPurge, Before: &product})
+}
+
+// findDeletedProduct loads a product from the trash. Naming a live product is
+// a conflict rather than a miss, so the caller knows to delete it first.
+func (service *productServiceImpl) findDeletedProduct(ctx context.Context, tx helper.Tx, productId int) (domain.Product, error) {
+	product, err := service.ProductRepository.FindDeletedById(ctx, tx, productId)
+	if errors.Is(err, repository.ErrProductNotFound) {
+		_, err = service.findProduct(ctx, tx, productId)
+		if err == nil {
+			return product, exception.NewConflictError("product " + strconv.Itoa(productId) + " is not in the trash")
+		}
+		return product, err
+	}
+	if err != nil {
+		return product, exception.NewInternalError(err)
+	}
+	return product, nil
+}
+
+// PurgeTrash removes the products deleted before deletedBefore for good and
+// returns how many it removed. It commits a batch at a time, so a failure
+// keeps the batches purged before it.
+func (service *productServiceImpl) PurgeTrash(ctx context.Context, deletedBefore time.Time) (int, error) {
+	err := authorize(ctx, OperationProductPurgeTrash)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for {
+		count, more, err := service.purgeTrashBatch(ctx, deletedBefore)
+		purged += count
+		if err != nil || !more {
+			return purged, err
+		}
+	}
+}
+
+func (service *productServiceImpl) purgeTrashBatch(ctx context.Context, deletedBefore time.Time) (purged int, more bool, err error) {
+	tx, err := helper.BeginTx(ctx, service.TxManager)
+	if err != nil {
+		return 0, false, exception.NewInternalError(err)
+	}
+	defer helper.CommitOrRollback(tx, &err)
+
+	query := domain.ProductQuery{
+		Filter: domain.ProductFilter{Deleted: true, DeletedBefore: &deletedBefore},
+		Limit:  trashPurgeBatchSize,
+	}
+	products, err := service.ProductRepository.FindAllByQuery(ctx, tx, query)
+	if err != nil {
+		return 0, false, exception.NewInternalError(err)
+	}
+
+	changes := make([]productChange, 0, len(products))
+	for i, product := range products {
+		err = service.ProductRepository.Purge(ctx, tx, product)
+		if errors.Is(err, repository.ErrProductVersionConflict) {
+			// restored since it was listed, so it stays
+			continue
+		}
+		if err != nil {
+			return 0, false, exception.NewInternalError(err)
+		}
+		changes = append(changes, productChange{Action: domain.AuditActionPurge, Before: &products[i]})
+	}
+
+	err = service.audit(ctx, tx, changes...)
+	if err != nil {
+		return 0, false, err
+	}
+	return len(changes), len(products) == trashPurgeBatchSize, nil
+}
@@ -0,0 +1,115 @@
+package test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createApiKey(t *testing.T, router http.Handler, body string) map[string]interface{} {
+	request := httptest.NewRequest(http.MethodPost, "http://localhost:3000/api/apikeys", strings.NewReader(body))
+	request.Header.Add("Content-Type", "application/json")
+	request.Header.Add("API-Key", "BUBBLEKEY")
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	response := recorder.Result()
+	assert.Equal(t, 201, response.StatusCode)
+
+	responseBody, _ := io.ReadAll(response.Body)
+	var webResponse map[string]interface{}
+	json.Unmarshal(responseBody, &webResponse)
+
+	return webResponse["data"].(map[string]interface{})
+}
+
+func TestCreateApiKeySuccess(t *testing.T) {
+	storage := testStorage()
+	truncateApiKey(storage)
+	router := setupRouter(storage)
+
+	apiKey := createApiKey(t, router, `{"name": "Reporting", "owner": "finance", "scopes": ["products:read"]}`)
+	assert.Equal(t, "Reporting", apiKey["name"])
+	assert.True(t, strings.HasPrefix(apiKey["key"].(string), apiKey["key_prefix"].(string)))
+
+	request := httptest.NewRequest(http.MethodGet, "http://localhost:3000/api/apikeys", nil)
+	request.Header.Add("API-Key", "BUBBLEKEY")
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	body, _ := io.ReadAll(recorder.Result().Body)
+	assert.Equal(t, 200, recorder.Result().StatusCode)
+	assert.False(t, strings.Contains(string(body), apiKey["key"].(string)))
+}
+
+func TestCreateApiKeyFailed(t *testing.T) {
+	storage := testStorage()
+	truncateApiKey(storage)
+	router := setupRouter(storage)
+
+	request := httptest.NewRequest(http.MethodPost, "http://localhost:3000/api/apikeys", strings.NewReader(`{"name": "Reporting", "owner": "finance", "scopes": ["products:everything"]}`))
+	request.Header.Add("Content-Type", "application/json")
+	request.Header.Add("API-Key", "BUBBLEKEY")
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	assert.Equal(t, 400, recorder.Result().StatusCode)
+}
+
+func TestApiKeyScopes(t *testing.T) {
+	storage := testStorage()
+	truncateApiKey(storage)
+	truncateProduct(storage)
+	router := setupRouter(storage)
+
+	apiKey := createApiKey(t, router, `{"name": "Reporting", "owner": "finance", "scopes": ["products:read"]}`)
+	key := apiKey["key"].(string)
+
+	request := httptest.NewRequest(http.MethodGet, "http://localhost:3000/api/products", nil)
+	request.Header.Add("API-Key", key)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Result().StatusCode)
+
+	request = httptest.NewRequest(http.MethodPost, "http://localhost:3000/api/products", strings.NewReader(`{"product_name" : "Cokelat", "price" : 9500}`))
+	request.Header.Add("API-Key", key)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	assert.Equal(t, 403, recorder.Result().StatusCode)
+
+	request = httptest.NewRequest(http.MethodGet, "http://localhost:3000/api/apikeys", nil)
+	request.Header.Add("API-Key", key)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	assert.Equal(t, 403, recorder.Result().StatusCode)
+}
+
+func TestRevokeApiKey(t *testing.T) {
+	storage := testStorage()
+	truncateApiKey(storage)
+	router := setupRouter(storage)
+
+	apiKey := createApiKey(t, router, `{"name": "Reporting", "owner": "finance", "scopes": ["products:read"]}`)
+	id := strconv.Itoa(int(apiKey["id"].(float64)))
+
+	request := httptest.NewRequest(http.MethodDelete, "http://localhost:3000/api/apikeys/"+id, nil)
+	request.Header.Add("API-Key", "BUBBLEKEY")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Result().StatusCode)
+
+	request = httptest.NewRequest(http.MethodGet, "http://localhost:3000/api/products", nil)
+	request.Header.Add("API-Key", apiKey["key"].(string))
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	assert.Equal(t, 401, recorder.Result().StatusCode)
+}
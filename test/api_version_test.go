@@ -0,0 +1,176 @@
+package test
+
+import (
+	"bubblevy/restful-api/model/domain"
+	"bubblevy/restful-api/money"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sendVersionedRequest(router http.Handler, version string, method string, path string, headers map[string]string, body string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	request := httptest.NewRequest(method, "http://localhost:3000/api/products"+path, strings.NewReader(body))
+	request.Header.Add("Content-Type", "application/json")
+	request.Header.Add("API-Key", "BUBBLEKEY")
+	if version != "" {
+		request.Header.Add("API-Version", version)
+	}
+	for key, value := range headers {
+		request.Header.Set(key, value)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	var responseBody map[string]interface{}
+	json.Unmarshal(recorder.Body.Bytes(), &responseBody)
+	return recorder, responseBody
+}
+
+func TestApiVersionPrices(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+	router := setupRouter(storage)
+
+	recorder, responseBody := sendVersionedRequest(router, "2", http.MethodPost, "", nil, `{"product_name": "Cokelat", "price": {"amount": "12.505", "currency": "USD"}}`)
+	assert.Equal(t, 201, recorder.Code)
+	assert.Equal(t, "2", recorder.Header().Get("API-Version"))
+	assert.Equal(t, "API-Version", recorder.Header().Get("Vary"))
+	data := responseBody["data"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"amount": "12.50", "currency": "USD"}, data["price"])
+	path := "/" + strconv.Itoa(int(data["id"].(float64)))
+
+	// v1 clients, the default, see whole units rounded half to even
+	recorder, responseBody = sendVersionedRequest(router, "", http.MethodGet, path, nil, "")
+	assert.Equal(t, 200, recorder.Code)
+	assert.Equal(t, "1", recorder.Header().Get("API-Version"))
+	assert.Equal(t, 12, int(responseBody["data"].(map[string]interface{})["price"].(float64)))
+
+	// a v1 patch that leaves the rounded price alone keeps the stored one
+	recorder, _ = sendVersionedRequest(router, "1", http.MethodPatch, path, map[string]string{"Content-Type": "application/merge-patch+json"}, `{"product_name": "Cokelat Susu"}`)
+	assert.Equal(t, 200, recorder.Code)
+	_, responseBody = sendVersionedRequest(router, "2", http.MethodGet, path, nil, "")
+	data = responseBody["data"].(map[string]interface{})
+	assert.Equal(t, "Cokelat Susu", data["product_name"])
+	assert.Equal(t, map[string]interface{}{"amount": "12.50", "currency": "USD"}, data["price"])
+
+	recorder, _ = sendVersionedRequest(router, "1", http.MethodPost, "", nil, `{"product_name": "Kentang", "price": 9500}`)
+	assert.Equal(t, 201, recorder.Code)
+
+	// prices in another currency than the product's cannot be scheduled
+	recorder, _ = sendVersionedRequest(router, "2", http.MethodPost, path+"/prices/schedules", nil, `{"price": {"amount": "10", "currency": "IDR"}, "starts_at": "2090-01-01T00:00:00Z"}`)
+	assert.Equal(t, 400, recorder.Code)
+
+	recorder, _ = sendVersionedRequest(router, "3", http.MethodGet, path, nil, "")
+	assert.Equal(t, 400, recorder.Code)
+	recorder, _ = sendVersionedRequest(router, "2", http.MethodPost, "", nil, `{"product_name": "Permen", "price": {"amount": "5", "currency": "XYZ"}}`)
+	assert.Equal(t, 400, recorder.Code)
+	recorder, _ = sendVersionedRequest(router, "2", http.MethodPost, "", nil, `{"product_name": "Permen", "price": {"amount": "0.001", "currency": "USD"}}`)
+	assert.Equal(t, 400, recorder.Code)
+}
+
+func TestApiVersionPriceFilter(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+	router := setupRouter(storage)
+	sendVersionedRequest(router, "2", http.MethodPost, "", nil, `{"product_name": "Cokelat", "price": {"amount": "12.50", "currency": "USD"}}`)
+	sendVersionedRequest(router, "2", http.MethodPost, "", nil, `{"product_name": "Kentang", "price": {"amount": "3", "currency": "USD"}}`)
+	sendVersionedRequest(router, "1", http.MethodPost, "", nil, `{"product_name": "Permen", "price": 500}`)
+
+	names := func(query string) []string {
+		recorder, responseBody := sendVersionedRequest(router, "2", http.MethodGet, "?sort=product_name&"+query, nil, "")
+		assert.Equal(t, 200, recorder.Code, query)
+		var names []string
+		for _, product := range responseBody["data"].([]interface{}) {
+			names = append(names, product.(map[string]interface{})["product_name"].(string))
+		}
+		return names
+	}
+	assert.Equal(t, []string{"Cokelat", "Kentang"}, names("currency=USD"))
+	assert.Equal(t, []string{"Cokelat"}, names("currency=USD&min_price=12.50"))
+	assert.Equal(t, []string{"Kentang"}, names("currency=USD&max_price=12.49"))
+	// a range without a currency is in rupiah
+	assert.Equal(t, []string{"Permen"}, names("min_price=100"))
+
+	for _, query := range []string{"currency=XYZ", "min_price=abc", "currency=USD&min_price=-1", "min_price=10&max_price=5"} {
+		recorder, _ := sendVersionedRequest(router, "2", http.MethodGet, "?"+query, nil, "")
+		assert.Equal(t, 400, recorder.Code, query)
+	}
+}
+
+func TestApiVersion1KeepsOtherCurrencies(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+	saved := loadProducts(storage,
+		domain.Product{ProductName: "Cokelat", Price: money.Money{Amount: 1250, Currency: "USD"}},
+		domain.Product{ProductName: "Permen", Price: money.Money{Amount: 50050, Currency: "IDR"}},
+	)
+	router := setupRouter(storage)
+	path := "/" + strconv.Itoa(saved[0].Id)
+
+	// a v1 client writes back the whole units it was shown
+	recorder, responseBody := sendVersionedRequest(router, "1", http.MethodGet, path, nil, "")
+	assert.Equal(t, 200, recorder.Code)
+	assert.Equal(t, 12, int(responseBody["data"].(map[string]interface{})["price"].(float64)))
+	recorder, responseBody = sendVersionedRequest(router, "1", http.MethodPut, path, nil, `{"product_name": "Cokelat Susu", "price": 12}`)
+	assert.Equal(t, 200, recorder.Code)
+	assert.Equal(t, 12, int(responseBody["data"].(map[string]interface{})["price"].(float64)))
+	product := findProduct(storage, saved[0].Id)
+	assert.Equal(t, "Cokelat Susu", product.ProductName)
+	assert.Equal(t, money.Money{Amount: 1250, Currency: "USD"}, product.Price)
+
+	// any other v1 price would be rupiah, which the product is not priced in
+	recorder, _ = sendVersionedRequest(router, "1", http.MethodPut, path, nil, `{"product_name": "Cokelat Susu", "price": 13}`)
+	assert.Equal(t, 409, recorder.Code)
+	recorder, _ = sendVersionedRequest(router, "2", http.MethodPut, path, nil, `{"product_name": "Cokelat Susu", "price": {"amount": "13", "currency": "USD"}}`)
+	assert.Equal(t, 200, recorder.Code)
+
+	// a v1 export imports back unchanged
+	export := exportProducts(router, "?format=csv")
+	assert.Equal(t, 200, export.StatusCode)
+	code, responseBody := importProducts(router, "", "text/csv", export.Body)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, 2, int(responseBody["data"].(map[string]interface{})["updated"].(float64)))
+	assert.Equal(t, money.Money{Amount: 1300, Currency: "USD"}, findProduct(storage, saved[0].Id).Price)
+	assert.Equal(t, money.Money{Amount: 50050, Currency: "IDR"}, findProduct(storage, saved[1].Id).Price)
+
+	body := "id,product_name,price\n" +
+		strconv.Itoa(saved[0].Id) + ",Cokelat Susu,15\n" +
+		strconv.Itoa(saved[1].Id) + ",Permen,600\n"
+	code, responseBody = importProducts(router, "", "text/csv", strings.NewReader(body))
+	assert.Equal(t, 207, code)
+	assert.Equal(t, [][2]int{{2, 409}}, importErrors(responseBody))
+	code, responseBody = importProducts(router, "?format=ndjson", "application/x-ndjson", strings.NewReader(`{"id": `+strconv.Itoa(saved[0].Id)+`, "product_name": "Cokelat Susu", "price": 15}`+"\n"))
+	assert.Equal(t, 207, code)
+	assert.Equal(t, [][2]int{{1, 409}}, importErrors(responseBody))
+	assert.Equal(t, money.Money{Amount: 1300, Currency: "USD"}, findProduct(storage, saved[0].Id).Price)
+	assert.Equal(t, rupiah(600), findProduct(storage, saved[1].Id).Price)
+}
+
+func TestApiVersionETags(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+	saved := loadProducts(storage, domain.Product{ProductName: "Cokelat", Price: money.Money{Amount: 1250, Currency: "USD"}})
+	router := setupRouter(storage)
+	path := "/" + strconv.Itoa(saved[0].Id)
+
+	// the two renderings of one version are different bodies
+	recorder, _ := sendVersionedRequest(router, "1", http.MethodGet, path, nil, "")
+	assert.Equal(t, `"1-12-v1"`, recorder.Header().Get("ETag"))
+	recorder, _ = sendVersionedRequest(router, "2", http.MethodGet, path, nil, "")
+	assert.Equal(t, `"1-12.50-USD"`, recorder.Header().Get("ETag"))
+
+	recorder, _ = sendVersionedRequest(router, "2", http.MethodGet, path, map[string]string{"If-None-Match": `"1-12-v1"`}, "")
+	assert.Equal(t, 200, recorder.Code)
+	recorder, _ = sendVersionedRequest(router, "1", http.MethodGet, path, map[string]string{"If-None-Match": `"1-12-v1"`}, "")
+	assert.Equal(t, 304, recorder.Code)
+
+	// either tag names the version a write expects
+	recorder, _ = sendVersionedRequest(router, "2", http.MethodPatch, path, map[string]string{"Content-Type": "application/merge-patch+json", "If-Match": `"1-12-v1"`}, `{"product_name": "Cokelat Susu"}`)
+	assert.Equal(t, 200, recorder.Code)
+	assert.Equal(t, `"2-12.50-USD"`, recorder.Header().Get("ETag"))
+}
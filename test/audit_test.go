@@ -0,0 +1,176 @@
+package test
+
+import (
+	"bubblevy/restful-api/app"
+	"bubblevy/restful-api/auth"
+	"bubblevy/restful-api/exception"
+	"bubblevy/restful-api/model/domain"
+	"bubblevy/restful-api/model/web"
+	"bubblevy/restful-api/service"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sendAuditRequest(router http.Handler, path string, query url.Values) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodGet, "http://localhost:3000/api/audit"+path+"?"+query.Encode(), nil)
+	request.Header.Add("API-Key", "BUBBLEKEY")
+	request.Header.Add("X-Request-Id", "audit-test")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func auditEntries(t *testing.T, recorder *httptest.ResponseRecorder) []map[string]interface{} {
+	var responseBody struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &responseBody))
+	return responseBody.Data
+}
+
+func TestAuditRecordsProductChanges(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+	truncateAuditEntry(storage)
+	router := setupRouter(storage)
+
+	status, created := createWithIdempotencyKey(router, "", `{"product_name": "Cokelat", "price": 9500}`)
+	assert.Equal(t, 201, status)
+	productId := int(created["data"].(map[string]interface{})["id"].(float64))
+	response := sendProductRequest(router, http.MethodPut, productId, nil, `{"product_name": "Cokelat", "price": 12000}`)
+	assert.Equal(t, 200, response.StatusCode)
+	code, _ := sendTrashRequest(router, http.MethodDelete, "/"+strconv.Itoa(productId))
+	assert.Equal(t, 200, code)
+
+	// a failed change rolls its entry back with it
+	response = sendProductRequest(router, http.MethodPut, productId, nil, `{"product_name": "Cokelat", "price": 15000}`)
+	assert.Equal(t, 404, response.StatusCode)
+
+	recorder := sendAuditRequest(router, "", url.Values{"product_id": {strconv.Itoa(productId)}})
+	assert.Equal(t, 200, recorder.Code)
+	entries := auditEntries(t, recorder)
+	assert.Equal(t, 3, len(entries))
+
+	// newest first
+	deleted, updated, createdEntry := entries[0], entries[1], entries[2]
+	assert.Equal(t, "delete", deleted["action"])
+	assert.NotNil(t, deleted["after"].(map[string]interface{})["deleted_at"])
+	assert.Equal(t, "update", updated["action"])
+	// snapshots keep the price with its currency, whatever version the change was made in
+	assert.Equal(t, map[string]interface{}{"amount": "9500.00", "currency": "IDR"}, updated["before"].(map[string]interface{})["price"])
+	assert.Equal(t, map[string]interface{}{"amount": "12000.00", "currency": "IDR"}, updated["after"].(map[string]interface{})["price"])
+	assert.Equal(t, "create", createdEntry["action"])
+	assert.Nil(t, createdEntry["before"])
+	assert.Equal(t, 1, int(createdEntry["after"].(map[string]interface{})["version"].(float64)))
+	for _, entry := range entries {
+		assert.Equal(t, "root", entry["actor"])
+		assert.Equal(t, productId, int(entry["product_id"].(float64)))
+		assert.NotEmpty(t, entry["request_id"])
+		assert.NotEmpty(t, entry["created_at"])
+	}
+}
+
+func TestAuditRecordsBulkChangesTogether(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+	truncateAuditEntry(storage)
+	saved := loadProducts(storage, domain.Product{ProductName: "Cokelat", Price: rupiah(9500)})
+	router := setupRouter(storage)
+
+	code, _ := postBulk(router, `{"operations": [
+		{"op": "create", "product_name": "Permen", "price": 500},
+		{"op": "update", "id": `+strconv.Itoa(saved[0].Id)+`, "product_name": "Cokelat", "price": 12000}
+	]}`, withApiKey)
+	assert.Equal(t, 200, code)
+
+	// an all-or-nothing batch that is rolled back records nothing
+	code, _ = postBulk(router, `{"operations": [
+		{"op": "create", "product_name": "Kentang", "price": 5000},
+		{"op": "delete", "id": 999}
+	]}`, withApiKey)
+	assert.Equal(t, 422, code)
+
+	recorder := sendAuditRequest(router, "", url.Values{})
+	entries := auditEntries(t, recorder)
+	assert.Equal(t, 2, len(entries))
+	actions := []interface{}{entries[0]["action"], entries[1]["action"]}
+	assert.ElementsMatch(t, []interface{}{"create", "update"}, actions)
+}
+
+func TestAuditFilters(t *testing.T) {
+	storage := testStorage()
+	truncateAuditEntry(storage)
+	router := setupRouter(storage)
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+	tx, _ := storage.TxManager.BeginTx(ctx)
+	_, err := storage.AuditRepository.SaveAll(ctx, tx, []domain.AuditEntry{
+		{Actor: "bob", Action: domain.AuditActionCreate, ProductId: 1, After: json.RawMessage(`{"id":1}`), RequestId: "r1", CreatedAt: base},
+		{Actor: "carol", Action: domain.AuditActionUpdate, ProductId: 1, Before: json.RawMessage(`{"id":1}`), After: json.RawMessage(`{"id":1}`), RequestId: "r2", CreatedAt: base.Add(time.Hour)},
+		{Actor: "bob", Action: domain.AuditActionCreate, ProductId: 2, After: json.RawMessage(`{"id":2}`), RequestId: "r3", CreatedAt: base.Add(2 * time.Hour)},
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, tx.Commit())
+
+	entries := auditEntries(t, sendAuditRequest(router, "", url.Values{"actor": {"bob"}}))
+	assert.Equal(t, 2, len(entries))
+	entries = auditEntries(t, sendAuditRequest(router, "", url.Values{"actor": {"bob"}, "product_id": {"1"}}))
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, "r1", entries[0]["request_id"])
+
+	// from is inclusive and to exclusive
+	entries = auditEntries(t, sendAuditRequest(router, "", url.Values{"from": {"2024-01-01T01:00:00Z"}, "to": {"2024-01-01T02:00:00Z"}}))
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, "carol", entries[0]["actor"])
+
+	recorder := sendAuditRequest(router, "", url.Values{"per_page": {"2"}, "page": {"2"}})
+	assert.Equal(t, 200, recorder.Code)
+	entries = auditEntries(t, recorder)
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, "r1", entries[0]["request_id"])
+
+	assert.Equal(t, 400, sendAuditRequest(router, "", url.Values{"from": {"yesterday"}}).Code)
+	assert.Equal(t, 400, sendAuditRequest(router, "", url.Values{"from": {"2024-01-02T00:00:00Z"}, "to": {"2024-01-01T00:00:00Z"}}).Code)
+
+	recorder = sendAuditRequest(router, "/export", url.Values{"actor": {"bob"}})
+	assert.Equal(t, 200, recorder.Code)
+	assert.Equal(t, `attachment; filename="audit.csv"`, recorder.Header().Get("Content-Disposition"))
+	records, err := csv.NewReader(recorder.Body).ReadAll()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(records))
+	assert.Equal(t, []string{"id", "created_at", "actor", "action", "product_id", "request_id", "before", "after"}, records[0])
+	assert.Equal(t, "r1", records[1][5])
+	assert.Equal(t, "", records[1][6])
+	assert.Equal(t, `{"id":1}`, records[1][7])
+
+	recorder = sendAuditRequest(router, "/export", url.Values{"format": {"ndjson"}})
+	assert.Equal(t, 200, recorder.Code)
+	lines := strings.Split(strings.TrimSpace(recorder.Body.String()), "\n")
+	assert.Equal(t, 3, len(lines))
+	var first web.AuditEntryResponse
+	assert.Nil(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.Equal(t, "r1", first.RequestId)
+	assert.True(t, base.Equal(first.CreatedAt))
+}
+
+func TestAuditNeedsAuditScope(t *testing.T) {
+	storage := testStorage()
+	auditService := service.NewAuditService(storage.AuditRepository, storage.TxManager, app.NewValidator())
+
+	editor := auth.WithIdentity(context.Background(), auth.Identity{Subject: "bob", Roles: []string{auth.RoleEditor}})
+	_, err := auditService.FindAll(editor, web.AuditFindAllRequest{})
+	assert.IsType(t, exception.ForbiddenError{}, err)
+	err = auditService.Export(editor, web.AuditExportRequest{Format: web.TransferFormatCSV}, &strings.Builder{})
+	assert.IsType(t, exception.ForbiddenError{}, err)
+}
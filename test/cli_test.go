@@ -0,0 +1,147 @@
+package test
+
+import (
+	"bubblevy/restful-api/cli"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// runCLI runs command against the SQLite database in dir and returns its exit
+// code and output.
+func runCLI(dir string, command []string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	arguments := append(append(append([]string{}, command...), databaseFlags(dir)...), args...)
+	code := cli.New(&stdout, &stderr).Run(arguments)
+	return code, stdout.String(), stderr.String()
+}
+
+func databaseFlags(dir string) []string {
+	return []string{"-storage", "sql", "-db-driver", "sqlite", "-db-dsn", filepath.Join(dir, "bubble.db")}
+}
+
+func TestCLISeedAndList(t *testing.T) {
+	dir := t.TempDir()
+
+	code, stdout, _ := runCLI(dir, []string{"seed"})
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "seeded 6 products\n", stdout)
+
+	code, stdout, _ = runCLI(dir, []string{"seed"})
+	assert.Equal(t, 0, code)
+	assert.True(t, strings.Contains(stdout, "nothing seeded"))
+
+	code, stdout, _ = runCLI(dir, []string{"products", "list"}, "-output", "json", "-sort", "-price", "-per-page", "2")
+	assert.Equal(t, 0, code)
+	var list map[string]interface{}
+	json.Unmarshal([]byte(stdout), &list)
+	products := list["products"].([]interface{})
+	assert.Equal(t, 2, len(products))
+	assert.Equal(t, "Susu Ultramilk", products[0].(map[string]interface{})["product_name"])
+
+	code, stdout, _ = runCLI(dir, []string{"products", "list"})
+	assert.Equal(t, 0, code)
+	assert.True(t, strings.HasPrefix(stdout, "ID  NAME"))
+}
+
+func TestCLIServeRefusesPublicAPIKey(t *testing.T) {
+	code, _, stderr := runCLI(t.TempDir(), []string{"serve"}, "-api-key", "BUBBLEKEY")
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "error: auth.api_key is the published key BUBBLEKEY, set a secret key or leave it empty\n", stderr)
+}
+
+func TestCLISeedFixtures(t *testing.T) {
+	dir := t.TempDir()
+
+	code, stdout, _ := runCLI(dir, []string{"seed"}, "-file", "testdata/cokelat.yaml")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "seeded 4 products\n", stdout)
+
+	code, stdout, _ = runCLI(dir, []string{"seed"}, "-fake", "1200", "-seed", "7", "-force")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "seeded 1200 products\n", stdout)
+
+	code, _, stderr := runCLI(dir, []string{"seed"}, "-file", "testdata/missing.yaml")
+	assert.Equal(t, 1, code)
+	assert.True(t, strings.HasPrefix(stderr, "error: "))
+}
+
+func TestCLIProductLifecycle(t *testing.T) {
+	dir := t.TempDir()
+
+	code, stdout, _ := runCLI(dir, []string{"products", "create"}, "-output", "json", "-name", "Cokelat", "-price", "9500")
+	assert.Equal(t, 0, code)
+	var product map[string]interface{}
+	json.Unmarshal([]byte(stdout), &product)
+	assert.Equal(t, 1, int(product["id"].(float64)))
+
+	code, stdout, _ = runCLI(dir, []string{"products", "update"}, "-output", "json", "-price", "10000", "1")
+	assert.Equal(t, 0, code)
+	json.Unmarshal([]byte(stdout), &product)
+	assert.Equal(t, "Cokelat", product["product_name"])
+	assert.Equal(t, map[string]interface{}{"amount": "10000.00", "currency": "IDR"}, product["price"])
+
+	code, stdout, _ = runCLI(dir, []string{"products", "update"}, "-output", "json", "-price", "12.5", "-currency", "USD", "1")
+	assert.Equal(t, 0, code)
+	json.Unmarshal([]byte(stdout), &product)
+	assert.Equal(t, map[string]interface{}{"amount": "12.50", "currency": "USD"}, product["price"])
+
+	code, _, _ = runCLI(dir, []string{"products", "delete"}, "1")
+	assert.Equal(t, 0, code)
+
+	code, _, stderr := runCLI(dir, []string{"products", "get"}, "1")
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "error: product not found\n", stderr)
+
+	code, _, stderr = runCLI(dir, []string{"products", "create"}, "-price", "9500")
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "error: product_name is required\n", stderr)
+}
+
+func TestCLIImportExport(t *testing.T) {
+	dir := t.TempDir()
+
+	file := filepath.Join(dir, "products.json")
+	os.WriteFile(file, []byte(`[{"product_name": "Cokelat", "price": 9500}, {"product_name": "", "price": 1}, {"product_name": "Susu", "price": 7000}]`), 0o600)
+
+	code, stdout, stderr := runCLI(dir, []string{"products", "import"}, file)
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "imported 2 of 3 products\n", stdout)
+	assert.Equal(t, "product 2: product_name is required\n", stderr)
+
+	code, stdout, _ = runCLI(dir, []string{"products", "export"})
+	assert.Equal(t, 0, code)
+	var products []map[string]interface{}
+	assert.Nil(t, json.Unmarshal([]byte(stdout), &products))
+	assert.Equal(t, 2, len(products))
+	assert.Equal(t, "Susu", products[1]["product_name"])
+}
+
+func TestCLIApiKey(t *testing.T) {
+	dir := t.TempDir()
+
+	code, stdout, _ := runCLI(dir, []string{"apikey", "create"}, "-output", "json", "-name", "ci", "-owner", "ops", "-scopes", "products:read")
+	assert.Equal(t, 0, code)
+	var apiKey map[string]interface{}
+	json.Unmarshal([]byte(stdout), &apiKey)
+	assert.True(t, strings.HasPrefix(apiKey["key"].(string), "bgk_"))
+
+	code, _, _ = runCLI(dir, []string{"apikey", "create"}, "-name", "ci", "-owner", "ops", "-scopes", "products:read", "-expires-at", "tomorrow")
+	assert.Equal(t, 2, code)
+
+	code, stdout, _ = runCLI(dir, []string{"apikey", "revoke"}, "1")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "revoked api key 1\n", stdout)
+}
+
+func TestCLIUsage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 2, cli.New(&stdout, &stderr).Run([]string{"unknown"}))
+	assert.Equal(t, 2, cli.New(&stdout, &stderr).Run([]string{"products"}))
+	assert.Equal(t, 2, cli.New(&stdout, &stderr).Run([]string{"products", "list", "-output", "yaml"}))
+}
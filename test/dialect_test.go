@@ -0,0 +1,20 @@
+package test
+
+import (
+	"bubblevy/restful-api/repository"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDialectRebind(t *testing.T) {
+	query := "SELECT id FROM products WHERE product_name LIKE ? ESCAPE '!' AND price >= ? LIMIT ? OFFSET ?"
+
+	assert.Equal(t, query, repository.DialectMySQL.Rebind(query))
+	assert.Equal(t, "SELECT id FROM products WHERE product_name LIKE $1 ESCAPE '!' AND price >= $2 LIMIT $3 OFFSET $4", repository.DialectPostgres.Rebind(query))
+}
+
+func TestDialectRebindSkipsQuotedPlaceholders(t *testing.T) {
+	query := "UPDATE products SET product_name = '?' WHERE id = ?"
+	assert.Equal(t, "UPDATE products SET product_name = '?' WHERE id = $1", repository.DialectPostgres.Rebind(query))
+}
@@ -0,0 +1,76 @@
+package test
+
+import (
+	"bubblevy/restful-api/app"
+	"bubblevy/restful-api/fixture"
+	"bubblevy/restful-api/model/domain"
+	"bubblevy/restful-api/money"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadFixtureFile(t *testing.T) {
+	fixtures, err := fixture.ReadFile("testdata/cokelat.yaml")
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(fixtures.Products))
+	assert.Equal(t, fixture.Product{ProductName: "Cokelat Susu", Price: 12000}, fixtures.Products[2])
+
+	file := filepath.Join(t.TempDir(), "products.json")
+	os.WriteFile(file, []byte(`{"products": [{"product_name": "Cokelat", "price": 9500}]}`), 0o600)
+	fixtures, err = fixture.ReadFile(file)
+	assert.Nil(t, err)
+	assert.Equal(t, []domain.Product{{ProductName: "Cokelat", Price: rupiah(9500)}}, fixtures.DomainProducts())
+}
+
+func TestReadFixtureFileRejectsUnknownKeys(t *testing.T) {
+	_, err := fixture.Parse([]byte("products:\n  - name: Cokelat\n    price: 9500\n"), ".yaml")
+	assert.NotNil(t, err)
+
+	_, err = fixture.Parse([]byte(`{"products": [{"name": "Cokelat"}]}`), ".json")
+	assert.NotNil(t, err)
+
+	_, err = fixture.Parse([]byte(`products = []`), ".toml")
+	assert.NotNil(t, err)
+}
+
+func TestSampleFixtures(t *testing.T) {
+	assert.Equal(t, 6, len(fixture.Sample().Products))
+}
+
+func TestGeneratorIsDeterministic(t *testing.T) {
+	products := fixture.NewGenerator(42).Products(100)
+	assert.Equal(t, products, fixture.NewGenerator(42).Products(100))
+	assert.NotEqual(t, products, fixture.NewGenerator(43).Products(100))
+
+	for _, product := range products {
+		assert.NotEmpty(t, product.ProductName)
+		assert.Equal(t, money.DefaultCurrency, product.Price.Currency)
+		assert.True(t, product.Price.Major() >= 500 && product.Price.Major() <= 45000)
+		assert.Equal(t, int64(0), product.Price.Major()%500)
+	}
+}
+
+func TestFixtureLoaderIsAllOrNothing(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+	loader := fixture.NewLoader(storage.TxManager, storage.ProductRepository, app.NewValidator())
+
+	_, err := loader.LoadProducts(context.Background(), []domain.Product{
+		{ProductName: "Cokelat", Price: rupiah(9500)},
+		{ProductName: "", Price: rupiah(5000)},
+	})
+	assert.Equal(t, "product 2: product_name is required", err.Error())
+
+	saved, err := loader.LoadProducts(context.Background(), fixture.NewGenerator(1).Products(3))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(saved))
+
+	tx, _ := storage.TxManager.BeginTx(context.Background())
+	defer tx.Rollback()
+	total, _ := storage.ProductRepository.CountByFilter(context.Background(), tx, domain.ProductFilter{})
+	assert.Equal(t, 3, total)
+}
@@ -0,0 +1,156 @@
+package test
+
+import (
+	"bubblevy/restful-api/auth"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+var testHMACSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func signToken(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	assert.Nil(t, err)
+	return signed
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":   "alice",
+		"iss":   "https://issuer.test",
+		"aud":   "bubblego",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": []string{"editor"},
+		"scope": "products:read products:write",
+	}
+}
+
+func encodeBigInt(value *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(value.Bytes())
+}
+
+func TestJWTVerifierHS256(t *testing.T) {
+	verifier, err := auth.NewJWTVerifier(auth.JWTVerifierConfig{
+		Issuer:     "https://issuer.test",
+		Audience:   "bubblego",
+		HMACSecret: testHMACSecret,
+	})
+	assert.Nil(t, err)
+
+	identity, err := verifier.Verify(signToken(t, jwt.SigningMethodHS256, testHMACSecret, "", validClaims()))
+	assert.Nil(t, err)
+	assert.Equal(t, "alice", identity.Subject)
+	assert.Equal(t, []string{"editor"}, identity.Roles)
+	assert.Equal(t, []string{"products:read", "products:write"}, identity.Scopes)
+
+	expired := validClaims()
+	expired["exp"] = time.Now().Add(-time.Hour).Unix()
+	_, err = verifier.Verify(signToken(t, jwt.SigningMethodHS256, testHMACSecret, "", expired))
+	assert.NotNil(t, err)
+
+	notYetValid := validClaims()
+	notYetValid["nbf"] = time.Now().Add(time.Hour).Unix()
+	_, err = verifier.Verify(signToken(t, jwt.SigningMethodHS256, testHMACSecret, "", notYetValid))
+	assert.NotNil(t, err)
+
+	wrongAudience := validClaims()
+	wrongAudience["aud"] = "another-service"
+	_, err = verifier.Verify(signToken(t, jwt.SigningMethodHS256, testHMACSecret, "", wrongAudience))
+	assert.NotNil(t, err)
+
+	// the subject is stored in varchar(100) columns
+	longSubject := validClaims()
+	longSubject["sub"] = strings.Repeat("a", 101)
+	_, err = verifier.Verify(signToken(t, jwt.SigningMethodHS256, testHMACSecret, "", longSubject))
+	assert.NotNil(t, err)
+	longSubject["sub"] = strings.Repeat("é", 100)
+	_, err = verifier.Verify(signToken(t, jwt.SigningMethodHS256, testHMACSecret, "", longSubject))
+	assert.Nil(t, err)
+
+	wrongIssuer := validClaims()
+	wrongIssuer["iss"] = "https://evil.test"
+	_, err = verifier.Verify(signToken(t, jwt.SigningMethodHS256, testHMACSecret, "", wrongIssuer))
+	assert.NotNil(t, err)
+
+	_, err = verifier.Verify(signToken(t, jwt.SigningMethodHS256, []byte("another secret of thirty-two bytes"), "", validClaims()))
+	assert.NotNil(t, err)
+}
+
+func TestJWTVerifierJWKS(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	jwks, _ := json.Marshal(map[string]interface{}{
+		"keys": []map[string]string{
+			{"kty": "RSA", "kid": "rsa-1", "alg": "RS256", "use": "sig", "n": encodeBigInt(rsaKey.N), "e": encodeBigInt(big.NewInt(int64(rsaKey.E)))},
+			{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": encodeBigInt(ecKey.X), "y": encodeBigInt(ecKey.Y)},
+		},
+	})
+	keys, err := auth.ParseKeySet(jwks)
+	assert.Nil(t, err)
+
+	verifier, err := auth.NewJWTVerifier(auth.JWTVerifierConfig{Audience: "bubblego", Keys: keys})
+	assert.Nil(t, err)
+
+	identity, err := verifier.Verify(signToken(t, jwt.SigningMethodRS256, rsaKey, "rsa-1", validClaims()))
+	assert.Nil(t, err)
+	assert.Equal(t, "alice", identity.Subject)
+
+	identity, err = verifier.Verify(signToken(t, jwt.SigningMethodES256, ecKey, "ec-1", validClaims()))
+	assert.Nil(t, err)
+	assert.Equal(t, "alice", identity.Subject)
+
+	// without a configured secret HS256 must not be accepted at all
+	_, err = verifier.Verify(signToken(t, jwt.SigningMethodHS256, testHMACSecret, "", validClaims()))
+	assert.NotNil(t, err)
+
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	_, err = verifier.Verify(signToken(t, jwt.SigningMethodRS256, otherKey, "rsa-1", validClaims()))
+	assert.NotNil(t, err)
+}
+
+type stubVerifier struct {
+	identity auth.Identity
+}
+
+func (verifier stubVerifier) Verify(token string) (auth.Identity, error) {
+	if token != "good-token" {
+		return auth.Identity{}, errors.New("unknown token")
+	}
+	return verifier.identity, nil
+}
+
+func TestBearerAuthentication(t *testing.T) {
+	storage := testStorage()
+	router := setupRouterWithVerifier(storage, stubVerifier{identity: auth.Identity{Subject: "alice", Scopes: []string{auth.ScopeProductsRead}}})
+
+	request := httptest.NewRequest(http.MethodGet, "http://localhost:3000/api/apikeys", nil)
+	request.Header.Add("Authorization", "Bearer bad-token")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	assert.Equal(t, 401, recorder.Result().StatusCode)
+
+	request = httptest.NewRequest(http.MethodGet, "http://localhost:3000/api/apikeys", nil)
+	request.Header.Add("Authorization", "Bearer good-token")
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	assert.Equal(t, 403, recorder.Result().StatusCode)
+}
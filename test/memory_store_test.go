@@ -0,0 +1,71 @@
+package test
+
+import (
+	"bubblevy/restful-api/model/domain"
+	"bubblevy/restful-api/repository"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStoreRollback(t *testing.T) {
+	store := repository.NewMemoryStore()
+	productRepository := repository.NewMemoryProductRepository()
+
+	tx, _ := store.BeginTx(context.Background())
+	product, _ := productRepository.Save(context.Background(), tx, domain.Product{ProductName: "Cokelat", Price: rupiah(9500)})
+	found, err := productRepository.FindById(context.Background(), tx, product.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, "Cokelat", found.ProductName)
+	tx.Rollback()
+
+	tx, _ = store.BeginTx(context.Background())
+	_, err = productRepository.FindById(context.Background(), tx, product.Id)
+	assert.Equal(t, repository.ErrProductNotFound, err)
+	tx.Commit()
+
+	assert.NotNil(t, tx.Commit())
+}
+
+func TestMemoryStoreCommit(t *testing.T) {
+	store := repository.NewMemoryStore()
+	productRepository := repository.NewMemoryProductRepository()
+
+	var wait sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			tx, _ := store.BeginTx(context.Background())
+			productRepository.Save(context.Background(), tx, domain.Product{ProductName: "Cokelat", Price: rupiah(9500)})
+			tx.Commit()
+		}()
+	}
+	wait.Wait()
+
+	tx, _ := store.BeginTx(context.Background())
+	defer tx.Rollback()
+
+	total, _ := productRepository.CountByFilter(context.Background(), tx, domain.ProductFilter{})
+	assert.Equal(t, 50, total)
+}
+
+func TestMemoryStoreBeginTxHonoursContext(t *testing.T) {
+	store := repository.NewMemoryStore()
+	tx, err := store.BeginTx(context.Background())
+	assert.Nil(t, err)
+
+	// a second transaction waits for the first until its context ends
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = store.BeginTx(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	assert.Nil(t, tx.Rollback())
+	tx, err = store.BeginTx(context.Background())
+	assert.Nil(t, err)
+	assert.Nil(t, tx.Commit())
+}
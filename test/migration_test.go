@@ -0,0 +1,211 @@
+package test
+
+import (
+	"bubblevy/restful-api/app"
+	"bubblevy/restful-api/config"
+	"bubblevy/restful-api/migration"
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func migrationDB(t *testing.T) *sql.DB {
+	cfg := testConfig()
+	cfg.Database.Driver = config.DriverSQLite
+	cfg.Database.DSN = filepath.Join(t.TempDir(), "bubble.db")
+
+	db := app.NewDB(cfg.Database)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func tableExists(db *sql.DB, table string) bool {
+	var count int
+	db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&count)
+	return count == 1
+}
+
+func TestMigrateUpAndDown(t *testing.T) {
+	db := migrationDB(t)
+	migrator, err := migration.New(db, config.DriverSQLite)
+	assert.Nil(t, err)
+
+	steps, err := migrator.Up(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, len(migrator.Migrations), len(steps))
+	assert.True(t, tableExists(db, "products"))
+	assert.True(t, tableExists(db, "api_keys"))
+	assert.True(t, tableExists(db, "idempotency_keys"))
+	assert.True(t, tableExists(db, "audit_entries"))
+	assert.True(t, tableExists(db, "product_revisions"))
+	assert.True(t, tableExists(db, "price_schedules"))
+
+	steps, err = migrator.Up(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(steps))
+
+	latest := migrator.Migrations[len(migrator.Migrations)-1]
+	steps, err = migrator.Down(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(steps))
+	assert.Equal(t, latest.Name, steps[0].Name)
+	assert.True(t, tableExists(db, "api_keys"))
+
+	steps, err = migrator.To(context.Background(), 1)
+	assert.Nil(t, err)
+	assert.Equal(t, len(migrator.Migrations)-2, len(steps))
+	assert.Equal(t, "create_api_keys", steps[len(steps)-1].Name)
+	assert.False(t, tableExists(db, "api_keys"))
+	assert.False(t, tableExists(db, "idempotency_keys"))
+	assert.False(t, tableExists(db, "audit_entries"))
+	assert.False(t, tableExists(db, "product_revisions"))
+	assert.False(t, tableExists(db, "price_schedules"))
+
+	statuses, err := migrator.Status(context.Background())
+	assert.Nil(t, err)
+	assert.True(t, statuses[0].Applied)
+	assert.False(t, statuses[1].Applied)
+	assert.False(t, statuses[2].Applied)
+
+	_, err = migrator.To(context.Background(), 0)
+	assert.Nil(t, err)
+	assert.False(t, tableExists(db, "products"))
+
+	_, err = migrator.To(context.Background(), 99)
+	assert.NotNil(t, err)
+}
+
+func TestMigratePricesToMinorUnits(t *testing.T) {
+	db := migrationDB(t)
+	migrator, err := migration.New(db, config.DriverSQLite)
+	assert.Nil(t, err)
+
+	version := migrationVersion(t, migrator, "add_price_currency")
+	_, err = migrator.To(context.Background(), version-1)
+	assert.Nil(t, err)
+	_, err = db.Exec("INSERT INTO products (product_name, price) VALUES ('Cokelat', 9500)")
+	assert.Nil(t, err)
+
+	_, err = migrator.To(context.Background(), version)
+	assert.Nil(t, err)
+	var price int64
+	var currency string
+	assert.Nil(t, db.QueryRow("SELECT price, currency FROM products").Scan(&price, &currency))
+	assert.Equal(t, int64(950000), price)
+	assert.Equal(t, "IDR", currency)
+
+	_, err = migrator.Down(context.Background())
+	assert.Nil(t, err)
+	assert.Nil(t, db.QueryRow("SELECT price FROM products").Scan(&price))
+	assert.Equal(t, int64(9500), price)
+}
+
+func TestMigrateGrantsProductsDelete(t *testing.T) {
+	db := migrationDB(t)
+	migrator, err := migration.New(db, config.DriverSQLite)
+	assert.Nil(t, err)
+
+	version := migrationVersion(t, migrator, "grant_products_delete")
+	_, err = migrator.To(context.Background(), version-1)
+	assert.Nil(t, err)
+	for i, scopes := range []string{"products:read,products:write", "products:read", "products:write,products:delete"} {
+		_, err = db.Exec("INSERT INTO api_keys (name, owner, key_prefix, key_hash, scopes, created_at) VALUES (?, 'ops', 'bk_', ?, ?, CURRENT_TIMESTAMP)", "key", strconv.Itoa(i), scopes)
+		assert.Nil(t, err)
+	}
+
+	_, err = migrator.To(context.Background(), version)
+	assert.Nil(t, err)
+	rows, err := db.Query("SELECT scopes FROM api_keys ORDER BY id")
+	assert.Nil(t, err)
+	defer rows.Close()
+	var granted []string
+	for rows.Next() {
+		var scopes string
+		assert.Nil(t, rows.Scan(&scopes))
+		granted = append(granted, scopes)
+	}
+	assert.Equal(t, []string{"products:read,products:write,products:delete", "products:read", "products:write,products:delete"}, granted)
+}
+
+func migrationVersion(t *testing.T, migrator *migration.Migrator, name string) int {
+	for _, found := range migrator.Migrations {
+		if found.Name == name {
+			return found.Version
+		}
+	}
+	t.Fatalf("no migration named %s", name)
+	return 0
+}
+
+func TestMigrateRejectsModifiedMigration(t *testing.T) {
+	db := migrationDB(t)
+	migrator, _ := migration.New(db, config.DriverSQLite)
+	migrator.Up(context.Background())
+
+	modified := append([]migration.Migration{}, migrator.Migrations...)
+	modified[0].Checksum = "changed"
+	migrator, _ = migration.NewWithMigrations(db, config.DriverSQLite, modified)
+
+	_, err := migrator.Up(context.Background())
+	assert.ErrorIs(t, err, migration.ErrChecksumMismatch)
+
+	statuses, err := migrator.Status(context.Background())
+	assert.Nil(t, err)
+	assert.True(t, statuses[0].Modified)
+}
+
+func TestMigrateConcurrentInstances(t *testing.T) {
+	db := migrationDB(t)
+
+	var wait sync.WaitGroup
+	applied := make(chan int, 5)
+	for i := 0; i < 5; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			migrator, _ := migration.New(db, config.DriverSQLite)
+			steps, err := migrator.Up(context.Background())
+			assert.Nil(t, err)
+			applied <- len(steps)
+		}()
+	}
+	wait.Wait()
+	close(applied)
+
+	migrations, _ := migration.Load(config.DriverSQLite)
+	total := 0
+	for count := range applied {
+		total += count
+	}
+	assert.Equal(t, len(migrations), total)
+}
+
+func TestLoadMigrationFiles(t *testing.T) {
+	migrations, err := migration.LoadFS(fstest.MapFS{
+		"0002_second.up.sql":   {Data: []byte("CREATE TABLE b (id int);")},
+		"0002_second.down.sql": {Data: []byte("DROP TABLE b;")},
+		"0001_first.up.sql":    {Data: []byte("CREATE TABLE a (id int);")},
+		"0001_first.down.sql":  {Data: []byte("DROP TABLE a;")},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(migrations))
+	assert.Equal(t, 1, migrations[0].Version)
+	assert.Equal(t, "first", migrations[0].Name)
+	assert.Equal(t, 64, len(migrations[0].Checksum))
+
+	_, err = migration.LoadFS(fstest.MapFS{
+		"0001_first.up.sql": {Data: []byte("CREATE TABLE a (id int);")},
+	})
+	assert.NotNil(t, err)
+
+	_, err = migration.LoadFS(fstest.MapFS{
+		"first.up.sql": {Data: []byte("CREATE TABLE a (id int);")},
+	})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,79 @@
+package test
+
+import (
+	"bubblevy/restful-api/money"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMoneyParse(t *testing.T) {
+	cases := []struct {
+		amount   string
+		currency string
+		minor    int64
+	}{
+		{"12.50", "USD", 1250},
+		{"12.5", "USD", 1250},
+		{"12", "USD", 1200},
+		{"-3.01", "USD", -301},
+		{"0.125", "USD", 12},
+		{"0.135", "USD", 14},
+		{"0.1251", "USD", 13},
+		{"-0.135", "USD", -14},
+		{"1250", "JPY", 1250},
+		{"1250.5", "JPY", 1250},
+		{"1.2345", "KWD", 1234},
+		{"9500", "IDR", 950000},
+	}
+	for _, c := range cases {
+		price, err := money.Parse(c.amount, c.currency)
+		assert.Nil(t, err, c.amount)
+		assert.Equal(t, money.Money{Amount: c.minor, Currency: c.currency}, price, c.amount)
+	}
+
+	for _, amount := range []string{"", "12.", ".5", "1,5", "1e3", "--1", "99999999999999999999"} {
+		_, err := money.Parse(amount, "USD")
+		assert.ErrorIs(t, err, money.ErrInvalidAmount, amount)
+	}
+	_, err := money.Parse("12.50", "XYZ")
+	assert.ErrorIs(t, err, money.ErrUnknownCurrency)
+	_, err = money.Parse("12.50", "usd")
+	assert.ErrorIs(t, err, money.ErrUnknownCurrency)
+}
+
+func TestMoneyFormat(t *testing.T) {
+	assert.Equal(t, "12.50", money.Money{Amount: 1250, Currency: "USD"}.Decimal())
+	assert.Equal(t, "0.05", money.Money{Amount: 5, Currency: "USD"}.Decimal())
+	assert.Equal(t, "-0.05", money.Money{Amount: -5, Currency: "USD"}.Decimal())
+	assert.Equal(t, "1250", money.Money{Amount: 1250, Currency: "JPY"}.Decimal())
+	assert.Equal(t, "1.250", money.Money{Amount: 1250, Currency: "BHD"}.Decimal())
+	assert.Equal(t, "12.50 USD", money.Money{Amount: 1250, Currency: "USD"}.String())
+
+	// whole units round half to even
+	assert.Equal(t, int64(12), money.Money{Amount: 1250, Currency: "USD"}.Major())
+	assert.Equal(t, int64(14), money.Money{Amount: 1350, Currency: "USD"}.Major())
+	assert.Equal(t, int64(13), money.Money{Amount: 1251, Currency: "USD"}.Major())
+	assert.Equal(t, int64(-14), money.Money{Amount: -1350, Currency: "USD"}.Major())
+	assert.Equal(t, int64(1250), money.Money{Amount: 1250, Currency: "JPY"}.Major())
+}
+
+func TestMoneyJSON(t *testing.T) {
+	data, err := json.Marshal(money.Money{Amount: 1250, Currency: "USD"})
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"amount": "12.50", "currency": "USD"}`, string(data))
+
+	var price money.Money
+	assert.Nil(t, json.Unmarshal([]byte(`{"amount": "12.505", "currency": "USD"}`), &price))
+	assert.Equal(t, money.Money{Amount: 1250, Currency: "USD"}, price)
+
+	// a plain integer is a v1 price in whole rupiah
+	assert.Nil(t, json.Unmarshal([]byte(`9500`), &price))
+	assert.Equal(t, money.Money{Amount: 950000, Currency: "IDR"}, price)
+
+	assert.ErrorIs(t, json.Unmarshal([]byte(`12.5`), &price), money.ErrInvalidAmount)
+	assert.NotNil(t, json.Unmarshal([]byte(`{"amount": 12.5, "currency": "USD"}`), &price))
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"currency": "USD"}`), &price), money.ErrInvalidAmount)
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"amount": "12.50", "currency": "XYZ"}`), &price), money.ErrUnknownCurrency)
+}
@@ -0,0 +1,168 @@
+package test
+
+import (
+	"bubblevy/restful-api/app"
+	"bubblevy/restful-api/auth"
+	"bubblevy/restful-api/model/domain"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func postBulk(router http.Handler, body string, authorize func(request *http.Request)) (int, map[string]interface{}) {
+	request := httptest.NewRequest(http.MethodPost, "http://localhost:3000/api/products/bulk", strings.NewReader(body))
+	request.Header.Add("Content-Type", "application/json")
+	authorize(request)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	var responseBody map[string]interface{}
+	json.Unmarshal(recorder.Body.Bytes(), &responseBody)
+	return recorder.Code, responseBody
+}
+
+func withApiKey(request *http.Request) {
+	request.Header.Add("API-Key", "BUBBLEKEY")
+}
+
+func bulkStatuses(responseBody map[string]interface{}) []int {
+	var statuses []int
+	results := responseBody["data"].(map[string]interface{})["results"].([]interface{})
+	for _, result := range results {
+		statuses = append(statuses, int(result.(map[string]interface{})["status"].(float64)))
+	}
+	return statuses
+}
+
+func countProducts(storage app.Storage) int {
+	tx, _ := storage.TxManager.BeginTx(context.Background())
+	defer tx.Rollback()
+	total, _ := storage.ProductRepository.CountByFilter(context.Background(), tx, domain.ProductFilter{})
+	return total
+}
+
+func TestBulkAllOrNothingSuccess(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+	saved := loadProducts(storage,
+		domain.Product{ProductName: "Cokelat", Price: rupiah(9500)},
+		domain.Product{ProductName: "Kentang", Price: rupiah(5000)},
+	)
+	router := setupRouter(storage)
+
+	code, responseBody := postBulk(router, `{"operations": [
+		{"op": "create", "product_name": "Susu", "price": 7000},
+		{"op": "create", "product_name": "Teh", "price": 4000},
+		{"op": "update", "id": `+strconv.Itoa(saved[0].Id)+`, "product_name": "Cokelat Susu", "price": 12000},
+		{"op": "delete", "id": `+strconv.Itoa(saved[1].Id)+`}
+	]}`, withApiKey)
+
+	assert.Equal(t, 200, code)
+	assert.Equal(t, []int{201, 201, 200, 200}, bulkStatuses(responseBody))
+	data := responseBody["data"].(map[string]interface{})
+	assert.Equal(t, true, data["committed"])
+	assert.Equal(t, "all_or_nothing", data["mode"])
+	created := data["results"].([]interface{})[1].(map[string]interface{})["data"].(map[string]interface{})
+	assert.Equal(t, "Teh", created["product_name"])
+	assert.NotZero(t, created["id"])
+	assert.Equal(t, 3, countProducts(storage))
+}
+
+func TestBulkAllOrNothingRollsBack(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+	router := setupRouter(storage)
+
+	code, responseBody := postBulk(router, `{"mode": "all_or_nothing", "operations": [
+		{"op": "create", "product_name": "Susu", "price": 7000},
+		{"op": "delete", "id": 404},
+		{"op": "create", "product_name": "Teh", "price": 4000}
+	]}`, withApiKey)
+
+	assert.Equal(t, 422, code)
+	assert.Equal(t, []int{424, 404, 424}, bulkStatuses(responseBody))
+	results := responseBody["data"].(map[string]interface{})["results"].([]interface{})
+	assert.Nil(t, results[0].(map[string]interface{})["data"])
+	assert.Equal(t, "/problems/not-found", results[1].(map[string]interface{})["error"].(map[string]interface{})["type"])
+	assert.Equal(t, 0, countProducts(storage))
+
+	code, responseBody = postBulk(router, `{"operations": [
+		{"op": "create", "product_name": "Susu", "price": 7000},
+		{"op": "create", "product_name": "", "price": 4000},
+		{"op": "rename", "id": 1}
+	]}`, withApiKey)
+
+	assert.Equal(t, 422, code)
+	assert.Equal(t, []int{424, 400, 400}, bulkStatuses(responseBody))
+	assert.Equal(t, 0, countProducts(storage))
+}
+
+func TestBulkBestEffort(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+	saved := loadProducts(storage, domain.Product{ProductName: "Cokelat", Price: rupiah(9500)})
+	router := setupRouter(storage)
+
+	code, responseBody := postBulk(router, `{"mode": "best_effort", "operations": [
+		{"op": "create", "product_name": "Susu", "price": 7000},
+		{"op": "update", "id": 404, "product_name": "Teh", "price": 4000},
+		{"op": "create", "product_name": "", "price": 4000},
+		{"op": "delete", "id": `+strconv.Itoa(saved[0].Id)+`}
+	]}`, withApiKey)
+
+	assert.Equal(t, 207, code)
+	assert.Equal(t, []int{201, 404, 400, 200}, bulkStatuses(responseBody))
+	assert.Equal(t, true, responseBody["data"].(map[string]interface{})["committed"])
+	assert.Equal(t, 1, countProducts(storage))
+}
+
+func TestBulkChecksEveryOperation(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+	saved := loadProducts(storage, domain.Product{ProductName: "Cokelat", Price: rupiah(9500)})
+	router := setupRouterWithVerifier(storage, stubVerifier{identity: auth.Identity{Subject: "bob", Roles: []string{auth.RoleEditor}}})
+	withEditor := func(request *http.Request) {
+		request.Header.Add("Authorization", "Bearer good-token")
+	}
+
+	code, responseBody := postBulk(router, `{"mode": "best_effort", "operations": [
+		{"op": "create", "product_name": "Susu", "price": 7000},
+		{"op": "delete", "id": `+strconv.Itoa(saved[0].Id)+`}
+	]}`, withEditor)
+
+	assert.Equal(t, 207, code)
+	assert.Equal(t, []int{201, 403}, bulkStatuses(responseBody))
+	assert.Equal(t, 2, countProducts(storage))
+
+	code, responseBody = postBulk(router, `{"operations": []}`, withEditor)
+	assert.Equal(t, 400, code)
+	assert.Equal(t, 400, int(responseBody["status"].(float64)))
+}
+
+func TestPostToProductIsMethodNotAllowed(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+	router := setupRouterWithVerifier(storage, stubVerifier{identity: auth.Identity{Subject: "alice", Roles: []string{auth.RoleViewer}}})
+	viewer := func(request *http.Request) {
+		request.Header.Add("Authorization", "Bearer good-token")
+	}
+
+	// the path exists for other methods whatever the caller may do, only
+	// bulk and import check for products:write
+	request := httptest.NewRequest(http.MethodPost, "http://localhost:3000/api/products/123", nil)
+	viewer(request)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	assert.Equal(t, 405, recorder.Code)
+	assert.Equal(t, "GET, PUT, PATCH, DELETE", recorder.Header().Get("Allow"))
+
+	code, _ := postBulk(router, `{"operations": []}`, viewer)
+	assert.Equal(t, 403, code)
+}
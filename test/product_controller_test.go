@@ -2,58 +2,148 @@ package test
 
 import (
 	"bubblevy/restful-api/app"
+	"bubblevy/restful-api/auth"
+	"bubblevy/restful-api/config"
 	"bubblevy/restful-api/controller"
+	"bubblevy/restful-api/fixture"
 	"bubblevy/restful-api/helper"
 	"bubblevy/restful-api/middleware"
+	"bubblevy/restful-api/migration"
 	"bubblevy/restful-api/model/domain"
-	"bubblevy/restful-api/repository"
+	"bubblevy/restful-api/money"
 	"bubblevy/restful-api/service"
 	"context"
-	"database/sql"
 	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
-	"time"
 
-	"github.com/go-playground/validator/v10"
 	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
 	"github.com/stretchr/testify/assert"
 )
 
-func testDB() *sql.DB {
-	db, err := sql.Open("mysql", "root:@tcp(localhost:3306)/db_golang_restful_api_test")
+// testConfig reads the test settings from BUBBLE_TEST_* variables or the file
+// named by BUBBLE_TEST_CONFIG. Tests run against the in-memory storage unless
+// BUBBLE_TEST_STORAGE=sql points them at the local test database; add
+// BUBBLE_TEST_DATABASE_DRIVER=postgres or sqlite and a matching
+// BUBBLE_TEST_DATABASE_DSN to run the same suite against those backends.
+func testConfig() config.Config {
+	defaults := config.Default()
+	defaults.Storage = config.StorageMemory
+	defaults.Database.DSN = "root:@tcp(localhost:3306)/db_golang_restful_api_test?parseTime=true"
+	defaults.Auth.APIKey = "BUBBLEKEY"
+	defaults.Auth.CursorSecret = "BUBBLESECRET"
+
+	loader := config.Loader{Defaults: defaults, EnvPrefix: "BUBBLE_TEST_"}
+	cfg, err := loader.Load(nil)
 	helper.PanicIfError(err)
 
-	db.SetMaxIdleConns(10)
-	db.SetMaxOpenConns(100)
-	db.SetConnMaxIdleTime(10 * time.Minute)
-	db.SetConnMaxLifetime(60 * time.Minute)
+	return cfg
+}
+
+var migrateTestDatabase sync.Once
+
+// testStorage opens the configured test storage. A SQL test database is
+// migrated to the latest schema the first time it is opened.
+func testStorage() app.Storage {
+	cfg := testConfig()
+	storage := app.NewStorage(cfg)
+	if storage.DB != nil {
+		migrateTestDatabase.Do(func() {
+			migrator, err := migration.New(storage.DB, cfg.Database.Driver)
+			helper.PanicIfError(err)
+			_, err = migrator.Up(context.Background())
+			helper.PanicIfError(err)
+		})
+	}
+	return storage
+}
 
-	return db
+func setupRouter(storage app.Storage) http.Handler {
+	return setupRouterWithVerifier(storage, nil)
 }
 
-func setupRouter(db *sql.DB) http.Handler {
-	validate := validator.New()
-	productRepository := repository.NewProductRepository()
-	productService := service.NewProductService(productRepository, db, validate)
+func setupRouterWithVerifier(storage app.Storage, verifier auth.Verifier) http.Handler {
+	cfg := testConfig()
+	validate := app.NewValidator()
+	productService := service.NewProductService(storage.ProductRepository, storage.IdempotencyRepository, storage.AuditRepository, storage.PriceScheduleRepository, storage.TxManager, validate, helper.NewCursorCodec([]byte(cfg.Auth.CursorSecret)), cfg.Server.IdempotencyTTL)
 	productController := controller.NewProductController(productService)
-	router := app.NewRouter(productController)
+	apiKeyService := service.NewApiKeyService(storage.ApiKeyRepository, storage.TxManager, validate)
+	apiKeyController := controller.NewApiKeyController(apiKeyService)
+	auditController := controller.NewAuditController(service.NewAuditService(storage.AuditRepository, storage.TxManager, validate))
+	router := app.NewRouter(productController, apiKeyController, auditController)
+
+	return middleware.NewRequestIdMiddleware(middleware.NewApiVersionMiddleware(middleware.NewAuthMiddleware(router, apiKeyService, cfg.Auth.APIKey, verifier)))
+}
 
-	return middleware.NewAuthMiddleware(router)
+// loadProducts saves products through the fixture loader and returns them
+// with their ids.
+func loadProducts(storage app.Storage, products ...domain.Product) []domain.Product {
+	loader := fixture.NewLoader(storage.TxManager, storage.ProductRepository, app.NewValidator())
+	saved, err := loader.LoadProducts(context.Background(), products)
+	helper.PanicIfError(err)
+	return saved
 }
 
-func truncateProduct(db *sql.DB) {
-	db.Exec("TRUNCATE products")
+// rupiah is a price in whole rupiah, the currency every v1 price is in.
+func rupiah(units int64) money.Money {
+	price, err := money.Legacy(units)
+	helper.PanicIfError(err)
+	return price
+}
+
+func loadProductFile(storage app.Storage, path string) []domain.Product {
+	fixtures, err := fixture.ReadFile(path)
+	helper.PanicIfError(err)
+	return loadProducts(storage, fixtures.DomainProducts()...)
+}
+
+// truncateProduct clears the revisions too, since the ids they belong to are
+// handed out again.
+func truncateProduct(storage app.Storage) {
+	truncateTable(storage, "products")
+	truncateTable(storage, "product_revisions")
+	truncateTable(storage, "price_schedules")
+}
+
+func truncateApiKey(storage app.Storage) {
+	truncateTable(storage, "api_keys")
+}
+
+func truncateIdempotencyKey(storage app.Storage) {
+	truncateTable(storage, "idempotency_keys")
+}
+
+func truncateAuditEntry(storage app.Storage) {
+	truncateTable(storage, "audit_entries")
+}
+
+func truncateTable(storage app.Storage, table string) {
+	if storage.Memory != nil {
+		storage.Memory.Truncate()
+		return
+	}
+	switch storage.Driver {
+	case config.DriverPostgres:
+		storage.DB.Exec("TRUNCATE " + table + " RESTART IDENTITY")
+	case config.DriverSQLite:
+		storage.DB.Exec("DELETE FROM " + table)
+		storage.DB.Exec("DELETE FROM sqlite_sequence WHERE name = ?", table)
+	default:
+		storage.DB.Exec("TRUNCATE " + table)
+	}
 }
 
 func TestCreateProductSuccess(t *testing.T) {
-	db := testDB()
-	truncateProduct(db)
-	router := setupRouter(db)
+	storage := testStorage()
+	truncateProduct(storage)
+	router := setupRouter(storage)
 	requestBody := strings.NewReader(`{"product_name" : "Cokelat", "price" : 9500}`)
 	request := httptest.NewRequest(http.MethodPost, "http://localhost:3000/api/products", requestBody)
 	request.Header.Add("Content-Type", "application/json")
@@ -76,9 +166,9 @@ func TestCreateProductSuccess(t *testing.T) {
 }
 
 func TestCreateProductFailed(t *testing.T) {
-	db := testDB()
-	truncateProduct(db)
-	router := setupRouter(db)
+	storage := testStorage()
+	truncateProduct(storage)
+	router := setupRouter(storage)
 	requestBody := strings.NewReader(`{"product_name" : "", "price" : 9500}`)
 	request := httptest.NewRequest(http.MethodPost, "http://localhost:3000/api/products", requestBody)
 	request.Header.Add("Content-Type", "application/json")
@@ -94,23 +184,21 @@ func TestCreateProductFailed(t *testing.T) {
 	var responseBody map[string]interface{}
 	json.Unmarshal(body, &responseBody)
 
-	assert.Equal(t, 400, int(responseBody["code"].(float64)))
-	assert.Equal(t, true, responseBody["error"])
+	assert.Equal(t, "application/problem+json", response.Header.Get("Content-Type"))
+	assert.Equal(t, 400, int(responseBody["status"].(float64)))
+
+	fieldError := responseBody["errors"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "product_name", fieldError["field"])
+	assert.Equal(t, "required", fieldError["rule"])
 }
 
 func TestUpdateProductSuccess(t *testing.T) {
-	db := testDB()
-	truncateProduct(db)
-
-	tx, _ := db.Begin()
-	productRepository := repository.NewProductRepository()
-	product := productRepository.Save(context.Background(), tx, domain.Product{
-		ProductName: "Cokelat",
-		Price:       9500,
-	})
-	tx.Commit()
-
-	router := setupRouter(db)
+	storage := testStorage()
+	truncateProduct(storage)
+
+	product := loadProducts(storage, domain.Product{ProductName: "Cokelat", Price: rupiah(9500)})[0]
+
+	router := setupRouter(storage)
 	requestBody := strings.NewReader(`{"product_name" : "Cokelat", "price" : 9500}`)
 	request := httptest.NewRequest(http.MethodPut, "http://localhost:3000/api/products/"+strconv.Itoa(product.Id), requestBody)
 	request.Header.Add("Content-Type", "application/json")
@@ -134,18 +222,12 @@ func TestUpdateProductSuccess(t *testing.T) {
 }
 
 func TestUpdateProductFailed(t *testing.T) {
-	db := testDB()
-	truncateProduct(db)
-
-	tx, _ := db.Begin()
-	productRepository := repository.NewProductRepository()
-	product := productRepository.Save(context.Background(), tx, domain.Product{
-		ProductName: "Cokelat",
-		Price:       9500,
-	})
-	tx.Commit()
-
-	router := setupRouter(db)
+	storage := testStorage()
+	truncateProduct(storage)
+
+	product := loadProducts(storage, domain.Product{ProductName: "Cokelat", Price: rupiah(9500)})[0]
+
+	router := setupRouter(storage)
 	requestBody := strings.NewReader(`{"product_name" : "", "price" : 9500}`)
 	request := httptest.NewRequest(http.MethodPut, "http://localhost:3000/api/products/"+strconv.Itoa(product.Id), requestBody)
 	request.Header.Add("Content-Type", "application/json")
@@ -161,23 +243,17 @@ func TestUpdateProductFailed(t *testing.T) {
 	var responseBody map[string]interface{}
 	json.Unmarshal(body, &responseBody)
 
-	assert.Equal(t, 400, int(responseBody["code"].(float64)))
-	assert.Equal(t, true, responseBody["error"])
+	assert.Equal(t, "application/problem+json", response.Header.Get("Content-Type"))
+	assert.Equal(t, 400, int(responseBody["status"].(float64)))
 }
 
 func TestGetProductSuccess(t *testing.T) {
-	db := testDB()
-	truncateProduct(db)
-
-	tx, _ := db.Begin()
-	productRepository := repository.NewProductRepository()
-	product := productRepository.Save(context.Background(), tx, domain.Product{
-		ProductName: "Cokelat",
-		Price:       9500,
-	})
-	tx.Commit()
-
-	router := setupRouter(db)
+	storage := testStorage()
+	truncateProduct(storage)
+
+	product := loadProducts(storage, domain.Product{ProductName: "Cokelat", Price: rupiah(9500)})[0]
+
+	router := setupRouter(storage)
 	request := httptest.NewRequest(http.MethodGet, "http://localhost:3000/api/products/"+strconv.Itoa(product.Id), nil)
 	request.Header.Add("API-Key", "BUBBLEKEY")
 
@@ -195,14 +271,14 @@ func TestGetProductSuccess(t *testing.T) {
 	assert.Equal(t, false, responseBody["error"])
 	assert.Equal(t, product.Id, int(responseBody["data"].(map[string]interface{})["id"].(float64)))
 	assert.Equal(t, product.ProductName, responseBody["data"].(map[string]interface{})["product_name"])
-	assert.Equal(t, product.Price, int(responseBody["data"].(map[string]interface{})["price"].(float64)))
+	assert.Equal(t, product.Price.Major(), int64(responseBody["data"].(map[string]interface{})["price"].(float64)))
 }
 
 func TestGetProductFailed(t *testing.T) {
-	db := testDB()
-	truncateProduct(db)
+	storage := testStorage()
+	truncateProduct(storage)
 
-	router := setupRouter(db)
+	router := setupRouter(storage)
 	request := httptest.NewRequest(http.MethodGet, "http://localhost:3000/api/products/9999", nil)
 	request.Header.Add("API-Key", "BUBBLEKEY")
 
@@ -216,23 +292,17 @@ func TestGetProductFailed(t *testing.T) {
 	var responseBody map[string]interface{}
 	json.Unmarshal(body, &responseBody)
 
-	assert.Equal(t, 404, int(responseBody["code"].(float64)))
-	assert.Equal(t, true, responseBody["error"])
+	assert.Equal(t, "application/problem+json", response.Header.Get("Content-Type"))
+	assert.Equal(t, 404, int(responseBody["status"].(float64)))
 }
 
 func TestDeleteProductSuccess(t *testing.T) {
-	db := testDB()
-	truncateProduct(db)
-
-	tx, _ := db.Begin()
-	productRepository := repository.NewProductRepository()
-	product := productRepository.Save(context.Background(), tx, domain.Product{
-		ProductName: "Cokelat",
-		Price:       9500,
-	})
-	tx.Commit()
-
-	router := setupRouter(db)
+	storage := testStorage()
+	truncateProduct(storage)
+
+	product := loadProducts(storage, domain.Product{ProductName: "Cokelat", Price: rupiah(9500)})[0]
+
+	router := setupRouter(storage)
 	request := httptest.NewRequest(http.MethodDelete, "http://localhost:3000/api/products/"+strconv.Itoa(product.Id), nil)
 	request.Header.Add("Content-Type", "application/json")
 	request.Header.Add("API-Key", "BUBBLEKEY")
@@ -252,10 +322,10 @@ func TestDeleteProductSuccess(t *testing.T) {
 }
 
 func TestDeleteProductFailed(t *testing.T) {
-	db := testDB()
-	truncateProduct(db)
+	storage := testStorage()
+	truncateProduct(storage)
 
-	router := setupRouter(db)
+	router := setupRouter(storage)
 	request := httptest.NewRequest(http.MethodDelete, "http://localhost:3000/api/products/999", nil)
 	request.Header.Add("Content-Type", "application/json")
 	request.Header.Add("API-Key", "BUBBLEKEY")
@@ -270,27 +340,21 @@ func TestDeleteProductFailed(t *testing.T) {
 	var responseBody map[string]interface{}
 	json.Unmarshal(body, &responseBody)
 
-	assert.Equal(t, 404, int(responseBody["code"].(float64)))
-	assert.Equal(t, true, responseBody["error"])
+	assert.Equal(t, "application/problem+json", response.Header.Get("Content-Type"))
+	assert.Equal(t, 404, int(responseBody["status"].(float64)))
 }
 
 func TestGetAllProductSuccess(t *testing.T) {
-	db := testDB()
-	truncateProduct(db)
-
-	tx, _ := db.Begin()
-	productRepository := repository.NewProductRepository()
-	product1 := productRepository.Save(context.Background(), tx, domain.Product{
-		ProductName: "Cokelat",
-		Price:       9500,
-	})
-	product2 := productRepository.Save(context.Background(), tx, domain.Product{
-		ProductName: "Kentang",
-		Price:       5000,
-	})
-	tx.Commit()
-
-	router := setupRouter(db)
+	storage := testStorage()
+	truncateProduct(storage)
+
+	saved := loadProducts(storage,
+		domain.Product{ProductName: "Cokelat", Price: rupiah(9500)},
+		domain.Product{ProductName: "Kentang", Price: rupiah(5000)},
+	)
+	product1, product2 := saved[0], saved[1]
+
+	router := setupRouter(storage)
 	request := httptest.NewRequest(http.MethodGet, "http://localhost:3000/api/products", nil)
 	request.Header.Add("API-Key", "BUBBLEKEY")
 
@@ -313,26 +377,211 @@ func TestGetAllProductSuccess(t *testing.T) {
 
 	assert.Equal(t, product1.Id, int(productsResponse1["id"].(float64)))
 	assert.Equal(t, product1.ProductName, productsResponse1["product_name"])
-	assert.Equal(t, product1.Price, int(productsResponse1["price"].(float64)))
+	assert.Equal(t, product1.Price.Major(), int64(productsResponse1["price"].(float64)))
 
 	assert.Equal(t, product2.Id, int(productsResponse2["id"].(float64)))
 	assert.Equal(t, product2.ProductName, productsResponse2["product_name"])
-	assert.Equal(t, product2.Price, int(productsResponse2["price"].(float64)))
+	assert.Equal(t, product2.Price.Major(), int64(productsResponse2["price"].(float64)))
 }
 
 func TestUnauthorized(t *testing.T) {
-	db := testDB()
-	truncateProduct(db)
+	storage := testStorage()
+	truncateProduct(storage)
+
+	router := setupRouter(storage)
+	request := httptest.NewRequest(http.MethodGet, "http://localhost:3000/api/products", nil)
+	request.Header.Add("API-Key", "KEYSALAH")
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	response := recorder.Result()
+	assert.Equal(t, 401, response.StatusCode)
+
+	body, _ := io.ReadAll(response.Body)
+	var responseBody map[string]interface{}
+	json.Unmarshal(body, &responseBody)
+
+	assert.Equal(t, "application/problem+json", response.Header.Get("Content-Type"))
+	assert.Equal(t, 401, int(responseBody["status"].(float64)))
+}
+
+func TestGetAllProductPagination(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+
+	var fixtures []domain.Product
+	for i := 1; i <= 5; i++ {
+		fixtures = append(fixtures, domain.Product{
+			ProductName: "Product " + strconv.Itoa(i),
+			Price:       rupiah(int64(i * 1000)),
+		})
+	}
+	loadProducts(storage, fixtures...)
+
+	router := setupRouter(storage)
+	request := httptest.NewRequest(http.MethodGet, "http://localhost:3000/api/products?page=2&per_page=2", nil)
+	request.Header.Add("API-Key", "BUBBLEKEY")
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	response := recorder.Result()
+	assert.Equal(t, 200, response.StatusCode)
+
+	body, _ := io.ReadAll(response.Body)
+	var responseBody map[string]interface{}
+	json.Unmarshal(body, &responseBody)
+
+	var products = responseBody["data"].([]interface{})
+	assert.Equal(t, 2, len(products))
+	assert.Equal(t, "Product 3", products[0].(map[string]interface{})["product_name"])
+
+	pagination := responseBody["pagination"].(map[string]interface{})
+	assert.Equal(t, 5, int(pagination["total"].(float64)))
+	assert.Equal(t, 3, int(pagination["total_pages"].(float64)))
+	assert.Equal(t, "/api/products?page=3&per_page=2", pagination["next"])
+	assert.Equal(t, "/api/products?page=1&per_page=2", pagination["prev"])
+}
+
+func TestGetAllProductFilterAndSort(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+
+	loadProductFile(storage, "testdata/cokelat.yaml")
+
+	router := setupRouter(storage)
+	request := httptest.NewRequest(http.MethodGet, "http://localhost:3000/api/products?name_contains=cokelat&min_price=2500&sort=-price", nil)
+	request.Header.Add("API-Key", "BUBBLEKEY")
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	response := recorder.Result()
+	assert.Equal(t, 200, response.StatusCode)
+
+	body, _ := io.ReadAll(response.Body)
+	var responseBody map[string]interface{}
+	json.Unmarshal(body, &responseBody)
+
+	var products = responseBody["data"].([]interface{})
+	assert.Equal(t, 2, len(products))
+	assert.Equal(t, "Cokelat Susu", products[0].(map[string]interface{})["product_name"])
+	assert.Equal(t, "Cokelat", products[1].(map[string]interface{})["product_name"])
+}
+
+func TestGetAllProductInvalidSort(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+
+	router := setupRouter(storage)
+	request := httptest.NewRequest(http.MethodGet, "http://localhost:3000/api/products?sort=price,-product_name%20DESC%3BDROP%20TABLE%20products", nil)
+	request.Header.Add("API-Key", "BUBBLEKEY")
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	response := recorder.Result()
+	assert.Equal(t, 400, response.StatusCode)
+
+	body, _ := io.ReadAll(response.Body)
+	var responseBody map[string]interface{}
+	json.Unmarshal(body, &responseBody)
+
+	assert.Equal(t, "application/problem+json", response.Header.Get("Content-Type"))
+	assert.Equal(t, 400, int(responseBody["status"].(float64)))
+}
+
+func TestGetAllProductCursorPagination(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+
+	loadProducts(storage,
+		domain.Product{ProductName: "Cokelat", Price: rupiah(9500)},
+		domain.Product{ProductName: "Kentang", Price: rupiah(5000)},
+		domain.Product{ProductName: "Keripik", Price: rupiah(9500)},
+	)
+
+	router := setupRouter(storage)
+	var names []interface{}
+	cursor := ""
+	for page := 0; page < 3; page++ {
+		request := httptest.NewRequest(http.MethodGet, "http://localhost:3000/api/products?mode=cursor&limit=2&sort=-price&cursor="+cursor, nil)
+		request.Header.Add("API-Key", "BUBBLEKEY")
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+
+		response := recorder.Result()
+		assert.Equal(t, 200, response.StatusCode)
+
+		body, _ := io.ReadAll(response.Body)
+		var responseBody map[string]interface{}
+		json.Unmarshal(body, &responseBody)
+
+		for _, product := range responseBody["data"].([]interface{}) {
+			names = append(names, product.(map[string]interface{})["product_name"])
+		}
+
+		next, ok := responseBody["next_cursor"].(string)
+		if !ok {
+			break
+		}
+		cursor = next
+	}
+
+	assert.Equal(t, []interface{}{"Cokelat", "Keripik", "Kentang"}, names)
+}
+
+func TestGetAllProductCursorRejected(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+
+	loadProducts(storage,
+		domain.Product{ProductName: "Cokelat", Price: rupiah(9500)},
+		domain.Product{ProductName: "Kentang", Price: rupiah(5000)},
+	)
+
+	router := setupRouter(storage)
+	request := httptest.NewRequest(http.MethodGet, "http://localhost:3000/api/products?mode=cursor&limit=1&sort=price", nil)
+	request.Header.Add("API-Key", "BUBBLEKEY")
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	body, _ := io.ReadAll(recorder.Result().Body)
+	var responseBody map[string]interface{}
+	json.Unmarshal(body, &responseBody)
+	cursor := responseBody["next_cursor"].(string)
+
+	for _, target := range []string{
+		"/api/products?limit=1&sort=-price&cursor=" + cursor,
+		"/api/products?limit=1&sort=price&cursor=x" + cursor,
+	} {
+		request := httptest.NewRequest(http.MethodGet, "http://localhost:3000"+target, nil)
+		request.Header.Add("API-Key", "BUBBLEKEY")
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+
+		assert.Equal(t, 400, recorder.Result().StatusCode)
+	}
+}
+
+func TestUnauthorizedLegacyEnvelope(t *testing.T) {
+	storage := testStorage()
 
-	router := setupRouter(db)
+	router := setupRouter(storage)
 	request := httptest.NewRequest(http.MethodGet, "http://localhost:3000/api/products", nil)
 	request.Header.Add("API-Key", "KEYSALAH")
+	request.Header.Add("Accept", "application/json")
 
 	recorder := httptest.NewRecorder()
 	router.ServeHTTP(recorder, request)
 
 	response := recorder.Result()
 	assert.Equal(t, 401, response.StatusCode)
+	assert.Equal(t, "application/json", response.Header.Get("Content-Type"))
 
 	body, _ := io.ReadAll(response.Body)
 	var responseBody map[string]interface{}
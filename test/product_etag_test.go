@@ -0,0 +1,183 @@
+package test
+
+import (
+	"bubblevy/restful-api/model/domain"
+	"bubblevy/restful-api/repository"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sendProductRequest(router http.Handler, method string, productId int, headers map[string]string, body string) *http.Response {
+	request := httptest.NewRequest(method, "http://localhost:3000/api/products/"+strconv.Itoa(productId), strings.NewReader(body))
+	request.Header.Add("Content-Type", "application/json")
+	request.Header.Add("API-Key", "BUBBLEKEY")
+	for key, value := range headers {
+		request.Header.Set(key, value)
+	}
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder.Result()
+}
+
+func TestProductETag(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+	router := setupRouter(storage)
+
+	request := httptest.NewRequest(http.MethodPost, "http://localhost:3000/api/products", strings.NewReader(`{"product_name": "Cokelat", "price": 9500}`))
+	request.Header.Add("Content-Type", "application/json")
+	request.Header.Add("API-Key", "BUBBLEKEY")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	assert.Equal(t, 201, recorder.Code)
+	assert.Equal(t, `"1-9500-v1"`, recorder.Header().Get("ETag"))
+	var created map[string]interface{}
+	json.Unmarshal(recorder.Body.Bytes(), &created)
+	productId := int(created["data"].(map[string]interface{})["id"].(float64))
+
+	response := sendProductRequest(router, http.MethodGet, productId, nil, "")
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, `"1-9500-v1"`, response.Header.Get("ETag"))
+
+	for _, ifNoneMatch := range []string{`"1-9500-v1"`, `W/"1-9500-v1"`, `"7-9500-v1", "1-9500-v1"`, `*`} {
+		response = sendProductRequest(router, http.MethodGet, productId, map[string]string{"If-None-Match": ifNoneMatch}, "")
+		body, _ := io.ReadAll(response.Body)
+		assert.Equal(t, 304, response.StatusCode, ifNoneMatch)
+		assert.Equal(t, `"1-9500-v1"`, response.Header.Get("ETag"))
+		assert.Empty(t, body)
+	}
+
+	response = sendProductRequest(router, http.MethodPut, productId, map[string]string{"If-Match": `"1-9500-v1"`}, `{"product_name": "Cokelat Susu", "price": 12000}`)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, `"2-12000-v1"`, response.Header.Get("ETag"))
+
+	response = sendProductRequest(router, http.MethodGet, productId, map[string]string{"If-None-Match": `"1-9500-v1"`}, "")
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, `"2-12000-v1"`, response.Header.Get("ETag"))
+}
+
+func TestProductETagFollowsPriceSchedules(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+	saved := loadProducts(storage, domain.Product{ProductName: "Cokelat", Price: rupiah(9500)})
+	router := setupRouter(storage)
+
+	response := sendProductRequest(router, http.MethodGet, saved[0].Id, nil, "")
+	assert.Equal(t, `"1-9500-v1"`, response.Header.Get("ETag"))
+
+	// a schedule falls due before the scheduler applies it: same version,
+	// new price, new tag
+	savePriceSchedule(t, storage, domain.PriceSchedule{ProductId: saved[0].Id, Price: rupiah(7500), StartsAt: time.Now().UTC().Add(-time.Minute)})
+	response = sendProductRequest(router, http.MethodGet, saved[0].Id, map[string]string{"If-None-Match": `"1-9500-v1"`}, "")
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, `"1-7500-v1"`, response.Header.Get("ETag"))
+	var product map[string]interface{}
+	json.NewDecoder(response.Body).Decode(&product)
+	assert.Equal(t, 7500, int(product["data"].(map[string]interface{})["price"].(float64)))
+
+	response = sendProductRequest(router, http.MethodGet, saved[0].Id, map[string]string{"If-None-Match": `"1-7500-v1"`}, "")
+	assert.Equal(t, 304, response.StatusCode)
+}
+
+func TestProductIfMatch(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+	saved := loadProducts(storage, domain.Product{ProductName: "Cokelat", Price: rupiah(9500)})
+	productId := saved[0].Id
+	router := setupRouter(storage)
+
+	// a second editor saves first
+	response := sendProductRequest(router, http.MethodPut, productId, nil, `{"product_name": "Cokelat", "price": 10000}`)
+	assert.Equal(t, 200, response.StatusCode)
+
+	response = sendProductRequest(router, http.MethodPut, productId, map[string]string{"If-Match": `"1"`}, `{"product_name": "Cokelat", "price": 12000}`)
+	assert.Equal(t, 412, response.StatusCode)
+	response = sendProductRequest(router, http.MethodPut, productId, map[string]string{"If-Match": `W/"2"`}, `{"product_name": "Cokelat", "price": 12000}`)
+	assert.Equal(t, 412, response.StatusCode)
+	response = sendProductRequest(router, http.MethodPut, productId, map[string]string{"If-Match": `"product-2"`}, `{"product_name": "Cokelat", "price": 12000}`)
+	assert.Equal(t, 412, response.StatusCode)
+
+	headers := map[string]string{"Content-Type": "application/merge-patch+json", "If-Match": `"1"`}
+	response = sendProductRequest(router, http.MethodPatch, productId, headers, `{"price": 12000}`)
+	assert.Equal(t, 412, response.StatusCode)
+
+	response = sendProductRequest(router, http.MethodDelete, productId, map[string]string{"If-Match": `"1"`}, "")
+	assert.Equal(t, 412, response.StatusCode)
+	assert.Equal(t, domain.Product{Id: productId, ProductName: "Cokelat", Price: rupiah(10000), Version: 2}, findProduct(storage, productId))
+
+	headers["If-Match"] = `"1", "2"`
+	response = sendProductRequest(router, http.MethodPatch, productId, headers, `{"price": 12000}`)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, `"3-12000-v1"`, response.Header.Get("ETag"))
+
+	response = sendProductRequest(router, http.MethodPut, productId, map[string]string{"If-Match": `*`}, `{"product_name": "Cokelat", "price": 13000}`)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, `"4-13000-v1"`, response.Header.Get("ETag"))
+
+	response = sendProductRequest(router, http.MethodDelete, productId, map[string]string{"If-Match": `"4"`}, "")
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, 0, countProducts(storage))
+}
+
+func TestRepositoryChecksProductVersion(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+	saved := loadProducts(storage, domain.Product{ProductName: "Cokelat", Price: rupiah(9500)})
+	assert.Equal(t, 1, saved[0].Version)
+
+	ctx := context.Background()
+	tx, err := storage.TxManager.BeginTx(ctx)
+	assert.Nil(t, err)
+	defer tx.Rollback()
+
+	stale := saved[0]
+	updated, err := storage.ProductRepository.Update(ctx, tx, domain.Product{Id: stale.Id, ProductName: "Cokelat", Price: rupiah(10000), Version: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, updated.Version)
+
+	stale.Price = rupiah(12000)
+	_, err = storage.ProductRepository.Update(ctx, tx, stale)
+	assert.ErrorIs(t, err, repository.ErrProductVersionConflict)
+	_, err = storage.ProductRepository.Delete(ctx, tx, stale, time.Now())
+	assert.ErrorIs(t, err, repository.ErrProductVersionConflict)
+	deleted, err := storage.ProductRepository.Delete(ctx, tx, updated, time.Now())
+	assert.Nil(t, err)
+	assert.Equal(t, 3, deleted.Version)
+}
+
+func TestBulkAndImportCheckVersion(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+	saved := loadProducts(storage,
+		domain.Product{ProductName: "Cokelat", Price: rupiah(9500)},
+		domain.Product{ProductName: "Kentang", Price: rupiah(5000)},
+	)
+	router := setupRouter(storage)
+
+	code, responseBody := postBulk(router, `{"mode": "best_effort", "operations": [
+		{"op": "update", "id": `+strconv.Itoa(saved[0].Id)+`, "version": 1, "product_name": "Cokelat", "price": 10000},
+		{"op": "update", "id": `+strconv.Itoa(saved[0].Id)+`, "version": 1, "product_name": "Cokelat", "price": 12000},
+		{"op": "delete", "id": `+strconv.Itoa(saved[1].Id)+`, "version": 3}
+	]}`, withApiKey)
+	assert.Equal(t, 207, code)
+	assert.Equal(t, []int{200, 412, 412}, bulkStatuses(responseBody))
+
+	body := "id,product_name,price,version\n" +
+		strconv.Itoa(saved[0].Id) + ",Cokelat,15000,1\n" +
+		strconv.Itoa(saved[1].Id) + ",Kentang,6000,1\n"
+	code, responseBody = importProducts(router, "", "text/csv", strings.NewReader(body))
+	assert.Equal(t, 207, code)
+	assert.Equal(t, [][2]int{{2, 412}}, importErrors(responseBody))
+	assert.Equal(t, domain.Product{Id: saved[0].Id, ProductName: "Cokelat", Price: rupiah(10000), Version: 2}, findProduct(storage, saved[0].Id))
+	assert.Equal(t, domain.Product{Id: saved[1].Id, ProductName: "Kentang", Price: rupiah(6000), Version: 2}, findProduct(storage, saved[1].Id))
+}
@@ -0,0 +1,184 @@
+package test
+
+import (
+	"bubblevy/restful-api/app"
+	"bubblevy/restful-api/auth"
+	"bubblevy/restful-api/config"
+	"bubblevy/restful-api/exception"
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/model/domain"
+	"bubblevy/restful-api/model/web"
+	"bubblevy/restful-api/repository"
+	"bubblevy/restful-api/service"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createWithIdempotencyKey(router http.Handler, key string, body string) (int, map[string]interface{}) {
+	request := httptest.NewRequest(http.MethodPost, "http://localhost:3000/api/products", strings.NewReader(body))
+	request.Header.Add("Content-Type", "application/json")
+	request.Header.Add("API-Key", "BUBBLEKEY")
+	if key != "" {
+		request.Header.Add("Idempotency-Key", key)
+	}
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	var responseBody map[string]interface{}
+	json.Unmarshal(recorder.Body.Bytes(), &responseBody)
+	return recorder.Code, responseBody
+}
+
+func TestCreateIdempotencyKey(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+	truncateIdempotencyKey(storage)
+	router := setupRouter(storage)
+
+	status, first := createWithIdempotencyKey(router, "order-1", `{"product_name": "Cokelat", "price": 9500}`)
+	assert.Equal(t, 201, status)
+
+	// a retry is answered from the stored response, however its JSON is laid out
+	status, replayed := createWithIdempotencyKey(router, "order-1", `{"price":9500,"product_name":"Cokelat"}`)
+	assert.Equal(t, 201, status)
+	assert.Equal(t, first, replayed)
+	assert.Equal(t, 1, countProducts(storage))
+
+	status, body := createWithIdempotencyKey(router, "order-1", `{"product_name": "Cokelat", "price": 12000}`)
+	assert.Equal(t, 422, status)
+	assert.Equal(t, "/problems/unprocessable-entity", body["type"])
+	assert.Equal(t, 1, countProducts(storage))
+
+	status, _ = createWithIdempotencyKey(router, "order-2", `{"product_name": "Cokelat", "price": 9500}`)
+	assert.Equal(t, 201, status)
+	status, _ = createWithIdempotencyKey(router, "", `{"product_name": "Cokelat", "price": 9500}`)
+	assert.Equal(t, 201, status)
+	assert.Equal(t, 3, countProducts(storage))
+
+	// a failed create stores nothing, so the key stays usable
+	status, _ = createWithIdempotencyKey(router, "order-3", `{"product_name": "", "price": 9500}`)
+	assert.Equal(t, 400, status)
+	status, _ = createWithIdempotencyKey(router, "order-3", `{"product_name": "Permen", "price": 500}`)
+	assert.Equal(t, 201, status)
+
+	status, _ = createWithIdempotencyKey(router, strings.Repeat("k", 256), `{"product_name": "Permen", "price": 500}`)
+	assert.Equal(t, 400, status)
+}
+
+func TestCreateIdempotencyKeyInFlight(t *testing.T) {
+	cfg := testConfig()
+	cfg.Storage = config.StorageMemory
+	storage := app.NewStorage(cfg)
+	router := setupRouter(storage)
+
+	// an open transaction holds both creates inside the service
+	tx, err := storage.TxManager.BeginTx(context.Background())
+	assert.Nil(t, err)
+
+	statuses := make(chan int, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			status, _ := createWithIdempotencyKey(router, "order-1", `{"product_name": "Cokelat", "price": 9500}`)
+			statuses <- status
+		}()
+	}
+
+	assert.Equal(t, 409, <-statuses)
+	tx.Rollback()
+	assert.Equal(t, 201, <-statuses)
+	assert.Equal(t, 1, countProducts(storage))
+
+	status, _ := createWithIdempotencyKey(router, "order-1", `{"product_name": "Cokelat", "price": 9500}`)
+	assert.Equal(t, 201, status)
+	assert.Equal(t, 1, countProducts(storage))
+}
+
+func TestCreateIdempotencyKeyExpires(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+	truncateIdempotencyKey(storage)
+	productService := service.NewProductService(storage.ProductRepository, storage.IdempotencyRepository, storage.AuditRepository, storage.PriceScheduleRepository, storage.TxManager, app.NewValidator(), helper.NewCursorCodec([]byte("BUBBLESECRET")), time.Nanosecond)
+	ctx := auth.WithIdentity(context.Background(), auth.Identity{Subject: "bob", Roles: []string{auth.RoleEditor}})
+
+	first, err := productService.Create(ctx, web.ProductCreateRequest{ProductName: "Cokelat", Price: rupiah(9500), IdempotencyKey: "order-1"})
+	assert.Nil(t, err)
+
+	// once expired the key starts over, even with a different body
+	second, err := productService.Create(ctx, web.ProductCreateRequest{ProductName: "Permen", Price: rupiah(500), IdempotencyKey: "order-1"})
+	assert.Nil(t, err)
+	assert.NotEqual(t, first.Id, second.Id)
+	assert.Equal(t, 2, countProducts(storage))
+
+	// keys belong to the caller that sent them
+	other := auth.WithIdentity(context.Background(), auth.Identity{Subject: "carol", Roles: []string{auth.RoleEditor}})
+	_, err = productService.Create(other, web.ProductCreateRequest{ProductName: "Permen", Price: rupiah(500), IdempotencyKey: "order-1"})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, countProducts(storage))
+}
+
+func TestIdempotencyRepositoryRejectsDuplicateKey(t *testing.T) {
+	storage := testStorage()
+	truncateIdempotencyKey(storage)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	record := domain.IdempotencyRecord{Owner: "bob", Key: "order-1", RequestHash: strings.Repeat("a", 64), Status: 201, Body: `{"id":1}`, CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+
+	tx, _ := storage.TxManager.BeginTx(context.Background())
+	err := storage.IdempotencyRepository.Save(context.Background(), tx, record)
+	assert.Nil(t, err)
+	err = storage.IdempotencyRepository.Save(context.Background(), tx, record)
+	assert.ErrorIs(t, err, repository.ErrIdempotencyKeyExists)
+
+	stored, err := storage.IdempotencyRepository.FindByKey(context.Background(), tx, "bob", "order-1")
+	assert.Nil(t, err)
+	assert.Equal(t, record.Body, stored.Body)
+	assert.True(t, record.ExpiresAt.Equal(stored.ExpiresAt))
+
+	_, err = storage.IdempotencyRepository.FindByKey(context.Background(), tx, "carol", "order-1")
+	assert.ErrorIs(t, err, repository.ErrIdempotencyRecordNotFound)
+	tx.Commit()
+}
+
+func TestIdempotencyRetentionJob(t *testing.T) {
+	storage := testStorage()
+	truncateIdempotencyKey(storage)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	ctx := context.Background()
+	tx, _ := storage.TxManager.BeginTx(ctx)
+	for _, record := range []domain.IdempotencyRecord{
+		{Owner: "bob", Key: "order-1", ExpiresAt: now.Add(-time.Hour)},
+		{Owner: "bob", Key: "order-2", ExpiresAt: now.Add(-time.Minute)},
+		{Owner: "bob", Key: "order-3", ExpiresAt: now.Add(time.Hour)},
+	} {
+		record.RequestHash = strings.Repeat("a", 64)
+		record.Status = 201
+		record.Body = `{"id":1}`
+		record.CreatedAt = now.Add(-2 * time.Hour)
+		assert.Nil(t, storage.IdempotencyRepository.Save(ctx, tx, record))
+	}
+	assert.Nil(t, tx.Commit())
+
+	productService := service.NewProductService(storage.ProductRepository, storage.IdempotencyRepository, storage.AuditRepository, storage.PriceScheduleRepository, storage.TxManager, app.NewValidator(), helper.NewCursorCodec([]byte("BUBBLESECRET")), time.Hour)
+	job := app.NewIdempotencyRetentionJob(productService, config.ServerConfig{IdempotencyPurgeInterval: time.Hour})
+	assert.Nil(t, job.Task(ctx))
+
+	tx, _ = storage.TxManager.BeginTx(ctx)
+	defer tx.Rollback()
+	for key, kept := range map[string]bool{"order-1": false, "order-2": false, "order-3": true} {
+		_, err := storage.IdempotencyRepository.FindByKey(ctx, tx, "bob", key)
+		assert.Equal(t, kept, err == nil, key)
+	}
+
+	// outside the job PurgeIdempotencyKeys is guarded like any other operation
+	_, err := productService.PurgeIdempotencyKeys(ctx, now)
+	assert.IsType(t, exception.UnauthorizedError{}, err)
+}
@@ -0,0 +1,147 @@
+package test
+
+import (
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/model/domain"
+	"bubblevy/restful-api/repository"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func patchProduct(router http.Handler, productId int, contentType string, body string) (*http.Response, map[string]interface{}) {
+	request := httptest.NewRequest(http.MethodPatch, "http://localhost:3000/api/products/"+strconv.Itoa(productId), strings.NewReader(body))
+	request.Header.Add("Content-Type", contentType)
+	request.Header.Add("API-Key", "BUBBLEKEY")
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	var responseBody map[string]interface{}
+	json.Unmarshal(recorder.Body.Bytes(), &responseBody)
+	return recorder.Result(), responseBody
+}
+
+func TestMergePatchProduct(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+	saved := loadProducts(storage, domain.Product{ProductName: "Cokelat", Price: rupiah(9500)})
+	router := setupRouter(storage)
+
+	response, responseBody := patchProduct(router, saved[0].Id, "application/merge-patch+json", `{"price": 12000}`)
+	assert.Equal(t, 200, response.StatusCode)
+	data := responseBody["data"].(map[string]interface{})
+	assert.Equal(t, "Cokelat", data["product_name"])
+	assert.Equal(t, 12000, int(data["price"].(float64)))
+	assert.Equal(t, domain.Product{Id: saved[0].Id, ProductName: "Cokelat", Price: rupiah(12000), Version: 2}, findProduct(storage, saved[0].Id))
+
+	response, responseBody = patchProduct(router, saved[0].Id, "application/merge-patch+json", `{"product_name": null}`)
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Equal(t, "product_name", responseBody["errors"].([]interface{})[0].(map[string]interface{})["field"])
+
+	response, _ = patchProduct(router, saved[0].Id, "application/merge-patch+json", `{"id": 99}`)
+	assert.Equal(t, 400, response.StatusCode)
+
+	response, responseBody = patchProduct(router, saved[0].Id, "application/merge-patch+json", `{"stock": 3}`)
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Contains(t, responseBody["detail"], `unknown field "stock"`)
+
+	response, _ = patchProduct(router, saved[0].Id+1, "application/merge-patch+json", `{"price": 1}`)
+	assert.Equal(t, 404, response.StatusCode)
+	assert.Equal(t, domain.Product{Id: saved[0].Id, ProductName: "Cokelat", Price: rupiah(12000), Version: 2}, findProduct(storage, saved[0].Id))
+}
+
+func TestJSONPatchProduct(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+	saved := loadProducts(storage, domain.Product{ProductName: "Cokelat", Price: rupiah(9500)})
+	router := setupRouter(storage)
+
+	response, responseBody := patchProduct(router, saved[0].Id, "application/json-patch+json", `[
+		{"op": "test", "path": "/price", "value": 9500.0},
+		{"op": "replace", "path": "/price", "value": 10000},
+		{"op": "copy", "from": "/product_name", "path": "/product_name"}
+	]`)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, 10000, int(responseBody["data"].(map[string]interface{})["price"].(float64)))
+
+	response, responseBody = patchProduct(router, saved[0].Id, "application/json-patch+json", `[
+		{"op": "replace", "path": "/product_name", "value": "Cokelat Susu"},
+		{"op": "test", "path": "/price", "value": 9500}
+	]`)
+	assert.Equal(t, 409, response.StatusCode)
+	assert.Equal(t, "operation 1 (test): patch test failed: /price is 10000", responseBody["detail"])
+	assert.Equal(t, domain.Product{Id: saved[0].Id, ProductName: "Cokelat", Price: rupiah(10000), Version: 2}, findProduct(storage, saved[0].Id))
+
+	response, _ = patchProduct(router, saved[0].Id, "application/json-patch+json", `[{"op": "remove", "path": "/stock"}]`)
+	assert.Equal(t, 400, response.StatusCode)
+
+	response, _ = patchProduct(router, saved[0].Id, "application/json-patch+json", `{"op": "remove", "path": "/price"}`)
+	assert.Equal(t, 400, response.StatusCode)
+
+	response, _ = patchProduct(router, saved[0].Id, "application/json", `{"price": 1}`)
+	assert.Equal(t, 415, response.StatusCode)
+	assert.Equal(t, "application/merge-patch+json, application/json-patch+json", response.Header.Get("Accept-Patch"))
+}
+
+func TestUpdateColumnsWritesOnlyNamedColumns(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+	saved := loadProducts(storage, domain.Product{ProductName: "Cokelat", Price: rupiah(9500)})
+
+	ctx := context.Background()
+	tx, err := storage.TxManager.BeginTx(ctx)
+	assert.Nil(t, err)
+	_, err = storage.ProductRepository.UpdateColumns(ctx, tx, domain.Product{Id: saved[0].Id, ProductName: "Stale", Price: rupiah(12000), Version: 1}, []string{"price"})
+	assert.Nil(t, err)
+	_, err = storage.ProductRepository.UpdateColumns(ctx, tx, domain.Product{Id: saved[0].Id, Price: rupiah(13000), Version: 1}, []string{"price"})
+	assert.ErrorIs(t, err, repository.ErrProductVersionConflict)
+	_, err = storage.ProductRepository.UpdateColumns(ctx, tx, saved[0], []string{"id; DROP TABLE products"})
+	assert.NotNil(t, err)
+	assert.Nil(t, tx.Commit())
+
+	assert.Equal(t, domain.Product{Id: saved[0].Id, ProductName: "Cokelat", Price: rupiah(12000), Version: 2}, findProduct(storage, saved[0].Id))
+}
+
+func TestJSONPatch(t *testing.T) {
+	cases := []struct {
+		document string
+		patch    string
+		expected string
+	}{
+		{`{"foo": ["bar", "baz"]}`, `[{"op": "add", "path": "/foo/1", "value": "qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{`{"foo": ["bar"]}`, `[{"op": "add", "path": "/foo/-", "value": ["abc"]}]`, `{"foo":["bar",["abc"]]}`},
+		{`{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`, `[{"op": "move", "from": "/foo/waldo", "path": "/qux/thud"}]`, `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{`{"foo": ["all", "grass", "cows", "eat"]}`, `[{"op": "move", "from": "/foo/1", "path": "/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{`{"/": 9, "~1": 10}`, `[{"op": "test", "path": "/~01", "value": 10}, {"op": "remove", "path": "/~1"}]`, `{"~1":10}`},
+		{`{"foo": null}`, `[{"op": "test", "path": "/foo", "value": null}]`, `{"foo":null}`},
+		{`{"foo": "bar"}`, `[{"op": "replace", "path": "", "value": [1]}]`, `[1]`},
+	}
+	for _, c := range cases {
+		patched, err := helper.JSONPatch([]byte(c.document), []byte(c.patch))
+		assert.Nil(t, err, c.patch)
+		assert.JSONEq(t, c.expected, string(patched), c.patch)
+	}
+
+	_, err := helper.JSONPatch([]byte(`{"foo": {"bar": 1}}`), []byte(`[{"op": "move", "from": "/foo", "path": "/foo/bar/baz"}]`))
+	assert.ErrorIs(t, err, helper.ErrInvalidPatch)
+	_, err = helper.JSONPatch([]byte(`{"foo": [1]}`), []byte(`[{"op": "add", "path": "/foo/2", "value": 2}]`))
+	assert.ErrorIs(t, err, helper.ErrInvalidPatch)
+	_, err = helper.JSONPatch([]byte(`{"foo": "bar"}`), []byte(`[{"op": "test", "path": "/foo", "value": "baz"}]`))
+	assert.ErrorIs(t, err, helper.ErrPatchTestFailed)
+}
+
+func TestMergePatch(t *testing.T) {
+	patched, err := helper.MergePatch(
+		[]byte(`{"title": "Goodbye!", "author": {"givenName": "John", "familyName": "Doe"}, "tags": ["example", "sample"]}`),
+		[]byte(`{"title": "Hello!", "author": {"familyName": null}, "phoneNumber": "+01-123-456-7890", "tags": ["example"]}`),
+	)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"title": "Hello!", "author": {"givenName": "John"}, "tags": ["example"], "phoneNumber": "+01-123-456-7890"}`, string(patched))
+}
@@ -0,0 +1,293 @@
+package test
+
+import (
+	"bubblevy/restful-api/app"
+	"bubblevy/restful-api/auth"
+	"bubblevy/restful-api/config"
+	"bubblevy/restful-api/exception"
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/model/domain"
+	"bubblevy/restful-api/service"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sendPriceRequest(router http.Handler, method string, path string, body string) (int, map[string]interface{}) {
+	request := httptest.NewRequest(method, "http://localhost:3000/api/products"+path, strings.NewReader(body))
+	request.Header.Add("Content-Type", "application/json")
+	request.Header.Add("API-Key", "BUBBLEKEY")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	var responseBody map[string]interface{}
+	json.Unmarshal(recorder.Body.Bytes(), &responseBody)
+	return recorder.Code, responseBody
+}
+
+func priceScheduleBody(price int, startsAt time.Time, endsAt time.Time) string {
+	return `{"price": ` + strconv.Itoa(price) + `, "starts_at": "` + startsAt.Format(time.RFC3339) + `", "ends_at": "` + endsAt.Format(time.RFC3339) + `"}`
+}
+
+func savePriceSchedule(t *testing.T, storage app.Storage, schedule domain.PriceSchedule) domain.PriceSchedule {
+	ctx := context.Background()
+	tx, _ := storage.TxManager.BeginTx(ctx)
+	schedule.Status = domain.PriceScheduleScheduled
+	schedule.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	schedule, err := storage.PriceScheduleRepository.Save(ctx, tx, schedule)
+	assert.Nil(t, err)
+	assert.Nil(t, tx.Commit())
+	return schedule
+}
+
+func TestPriceSchedules(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+	saved := loadProducts(storage, domain.Product{ProductName: "Cokelat", Price: rupiah(9500)})
+	router := setupRouter(storage)
+	path := "/" + strconv.Itoa(saved[0].Id) + "/prices/schedules"
+	friday := time.Now().UTC().Add(72 * time.Hour).Truncate(time.Second)
+
+	code, responseBody := sendPriceRequest(router, http.MethodPost, path, priceScheduleBody(7500, friday, friday.Add(48*time.Hour)))
+	assert.Equal(t, 201, code)
+	created := responseBody["data"].(map[string]interface{})
+	assert.Equal(t, "scheduled", created["status"])
+	assert.Equal(t, 7500, int(created["price"].(float64)))
+
+	// the promo runs into a schedule that is already there
+	code, _ = sendPriceRequest(router, http.MethodPost, path, priceScheduleBody(7000, friday.Add(24*time.Hour), friday.Add(72*time.Hour)))
+	assert.Equal(t, 409, code)
+	code, _ = sendPriceRequest(router, http.MethodPost, path, priceScheduleBody(7000, friday.Add(-96*time.Hour), friday))
+	assert.Equal(t, 400, code)
+	code, _ = sendPriceRequest(router, http.MethodPost, path, priceScheduleBody(7000, friday.Add(96*time.Hour), friday.Add(72*time.Hour)))
+	assert.Equal(t, 400, code)
+	code, _ = sendPriceRequest(router, http.MethodPost, "/999/prices/schedules", priceScheduleBody(7000, friday, friday.Add(time.Hour)))
+	assert.Equal(t, 404, code)
+
+	code, responseBody = sendPriceRequest(router, http.MethodGet, path, "")
+	assert.Equal(t, 200, code)
+	assert.Equal(t, 1, len(responseBody["data"].([]interface{})))
+
+	// nothing is due yet, so the price stays
+	response := sendProductRequest(router, http.MethodGet, saved[0].Id, nil, "")
+	assert.Equal(t, 200, response.StatusCode)
+	var product map[string]interface{}
+	json.NewDecoder(response.Body).Decode(&product)
+	assert.Equal(t, 9500, int(product["data"].(map[string]interface{})["price"].(float64)))
+
+	schedulePath := path + "/" + strconv.Itoa(int(created["id"].(float64)))
+	code, responseBody = sendPriceRequest(router, http.MethodDelete, schedulePath, "")
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "cancelled", responseBody["data"].(map[string]interface{})["status"])
+	code, _ = sendPriceRequest(router, http.MethodDelete, schedulePath, "")
+	assert.Equal(t, 409, code)
+	code, _ = sendPriceRequest(router, http.MethodDelete, path+"/999", "")
+	assert.Equal(t, 404, code)
+}
+
+func TestPriceScheduleApplied(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+	truncateAuditEntry(storage)
+	saved := loadProducts(storage,
+		domain.Product{ProductName: "Cokelat", Price: rupiah(9500)},
+		domain.Product{ProductName: "Kentang", Price: rupiah(5000)},
+	)
+	router := setupRouter(storage)
+	productService := service.NewProductService(storage.ProductRepository, storage.IdempotencyRepository, storage.AuditRepository, storage.PriceScheduleRepository, storage.TxManager, app.NewValidator(), helper.NewCursorCodec([]byte("BUBBLESECRET")), time.Hour)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	endsAt := now.Add(time.Hour)
+	savePriceSchedule(t, storage, domain.PriceSchedule{ProductId: saved[0].Id, Price: rupiah(7500), StartsAt: now.Add(-time.Minute), EndsAt: &endsAt})
+	savePriceSchedule(t, storage, domain.PriceSchedule{ProductId: saved[1].Id, Price: rupiah(5500), StartsAt: now.Add(-time.Minute)})
+
+	// reads show the price in effect before the scheduler has run
+	code, responseBody := sendPriceRequest(router, http.MethodGet, "", "")
+	assert.Equal(t, 200, code)
+	products := responseBody["data"].([]interface{})
+	assert.Equal(t, 7500, int(products[0].(map[string]interface{})["price"].(float64)))
+	assert.Equal(t, 5500, int(products[1].(map[string]interface{})["price"].(float64)))
+
+	job := app.NewPriceScheduleJob(productService, config.PricesConfig{ScheduleInterval: time.Minute})
+	assert.Nil(t, job.Task(context.Background()))
+
+	ctx := context.Background()
+	tx, _ := storage.TxManager.BeginTx(ctx)
+	promo, err := storage.ProductRepository.FindById(ctx, tx, saved[0].Id)
+	assert.Nil(t, err)
+	assert.Equal(t, rupiah(7500), promo.Price)
+	assert.Equal(t, 2, promo.Version)
+	schedules, err := storage.PriceScheduleRepository.FindByProductId(ctx, tx, saved[1].Id)
+	assert.Nil(t, err)
+	assert.Equal(t, domain.PriceScheduleCompleted, schedules[0].Status)
+	tx.Rollback()
+
+	entries := auditEntries(t, sendAuditRequest(router, "", nil))
+	assert.Equal(t, 2, len(entries))
+	assert.Equal(t, "price-scheduler", entries[0]["actor"])
+
+	// the promo ends and the price before it comes back
+	scheduler := auth.WithIdentity(ctx, auth.Identity{Subject: "price-scheduler", Scopes: []string{auth.ScopeProductsWrite}})
+	applied, err := productService.ApplyPriceSchedules(scheduler, endsAt)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, applied)
+	applied, err = productService.ApplyPriceSchedules(scheduler, endsAt)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, applied)
+
+	code, responseBody = sendPriceRequest(router, http.MethodGet, "/"+strconv.Itoa(saved[0].Id)+"/prices", "")
+	assert.Equal(t, 200, code)
+	history := responseBody["data"].([]interface{})
+	assert.Equal(t, 3, len(history))
+	assert.Equal(t, 9500, int(history[0].(map[string]interface{})["price"].(float64)))
+	assert.Equal(t, 7500, int(history[1].(map[string]interface{})["price"].(float64)))
+	assert.NotNil(t, history[1].(map[string]interface{})["to"])
+	assert.Equal(t, 9500, int(history[2].(map[string]interface{})["price"].(float64)))
+	assert.Nil(t, history[2].(map[string]interface{})["to"])
+
+	_, err = productService.ApplyPriceSchedules(ctx, now)
+	assert.IsType(t, exception.UnauthorizedError{}, err)
+}
+
+func TestCancelActivePriceSchedule(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+	saved := loadProducts(storage, domain.Product{ProductName: "Cokelat", Price: rupiah(9500)})
+	router := setupRouter(storage)
+	productService := service.NewProductService(storage.ProductRepository, storage.IdempotencyRepository, storage.AuditRepository, storage.PriceScheduleRepository, storage.TxManager, app.NewValidator(), helper.NewCursorCodec([]byte("BUBBLESECRET")), time.Hour)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	endsAt := now.Add(time.Hour)
+	schedule := savePriceSchedule(t, storage, domain.PriceSchedule{ProductId: saved[0].Id, Price: rupiah(7500), StartsAt: now.Add(-time.Minute), EndsAt: &endsAt})
+	scheduler := auth.WithIdentity(context.Background(), auth.Identity{Subject: "price-scheduler", Scopes: []string{auth.ScopeProductsWrite}})
+	_, err := productService.ApplyPriceSchedules(scheduler, now)
+	assert.Nil(t, err)
+
+	code, responseBody := sendPriceRequest(router, http.MethodDelete, "/"+strconv.Itoa(saved[0].Id)+"/prices/schedules/"+strconv.Itoa(schedule.Id), "")
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "cancelled", responseBody["data"].(map[string]interface{})["status"])
+
+	code, responseBody = sendPriceRequest(router, http.MethodGet, "/"+strconv.Itoa(saved[0].Id), "")
+	assert.Equal(t, 200, code)
+	product := responseBody["data"].(map[string]interface{})
+	assert.Equal(t, 9500, int(product["price"].(float64)))
+	assert.Equal(t, 3, int(product["version"].(float64)))
+}
+
+func TestWritesApplyDuePriceSchedules(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+	saved := loadProducts(storage,
+		domain.Product{ProductName: "Cokelat", Price: rupiah(9500)},
+		domain.Product{ProductName: "Kentang", Price: rupiah(5000)},
+	)
+	router := setupRouter(storage)
+	productService := service.NewProductService(storage.ProductRepository, storage.IdempotencyRepository, storage.AuditRepository, storage.PriceScheduleRepository, storage.TxManager, app.NewValidator(), helper.NewCursorCodec([]byte("BUBBLESECRET")), time.Hour)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	endsAt := now.Add(time.Hour)
+	savePriceSchedule(t, storage, domain.PriceSchedule{ProductId: saved[0].Id, Price: rupiah(7500), StartsAt: now.Add(-time.Minute), EndsAt: &endsAt})
+	savePriceSchedule(t, storage, domain.PriceSchedule{ProductId: saved[1].Id, Price: rupiah(4000), StartsAt: now.Add(-time.Minute), EndsAt: &endsAt})
+
+	// clients write back the promo prices they read before the scheduler ran
+	code, responseBody := sendPriceRequest(router, http.MethodGet, "/"+strconv.Itoa(saved[0].Id), "")
+	assert.Equal(t, 200, code)
+	assert.Equal(t, 7500, int(responseBody["data"].(map[string]interface{})["price"].(float64)))
+	code, _ = sendPriceRequest(router, http.MethodPut, "/"+strconv.Itoa(saved[0].Id), `{"product_name": "Cokelat Susu", "price": 7500}`)
+	assert.Equal(t, 200, code)
+	code, _ = importProducts(router, "", "text/csv", strings.NewReader("id,product_name,price\n"+strconv.Itoa(saved[1].Id)+",Kentang Goreng,4000\n"))
+	assert.Equal(t, 200, code)
+
+	ctx := context.Background()
+	tx, _ := storage.TxManager.BeginTx(ctx)
+	for _, product := range saved {
+		schedules, err := storage.PriceScheduleRepository.FindByProductId(ctx, tx, product.Id)
+		assert.Nil(t, err)
+		assert.Equal(t, domain.PriceScheduleActive, schedules[0].Status)
+	}
+	tx.Rollback()
+
+	// the promos still end, bringing back the prices they replaced
+	scheduler := auth.WithIdentity(ctx, auth.Identity{Subject: "price-scheduler", Scopes: []string{auth.ScopeProductsWrite}})
+	applied, err := productService.ApplyPriceSchedules(scheduler, endsAt)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, applied)
+
+	cokelat := findProduct(storage, saved[0].Id)
+	assert.Equal(t, "Cokelat Susu", cokelat.ProductName)
+	assert.Equal(t, rupiah(9500), cokelat.Price)
+	kentang := findProduct(storage, saved[1].Id)
+	assert.Equal(t, "Kentang Goreng", kentang.ProductName)
+	assert.Equal(t, rupiah(5000), kentang.Price)
+}
+
+func TestPriceFilterFollowsAppliedSchedules(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+	saved := loadProducts(storage,
+		domain.Product{ProductName: "Cokelat", Price: rupiah(9500)},
+		domain.Product{ProductName: "Kentang", Price: rupiah(8000)},
+	)
+	router := setupRouter(storage)
+	productService := service.NewProductService(storage.ProductRepository, storage.IdempotencyRepository, storage.AuditRepository, storage.PriceScheduleRepository, storage.TxManager, app.NewValidator(), helper.NewCursorCodec([]byte("BUBBLESECRET")), time.Hour)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	savePriceSchedule(t, storage, domain.PriceSchedule{ProductId: saved[0].Id, Price: rupiah(7500), StartsAt: now.Add(-time.Minute)})
+
+	names := func(query string) []string {
+		code, responseBody := sendPriceRequest(router, http.MethodGet, "?"+query, "")
+		assert.Equal(t, 200, code, query)
+		var names []string
+		for _, product := range responseBody["data"].([]interface{}) {
+			names = append(names, product.(map[string]interface{})["product_name"].(string))
+		}
+		return names
+	}
+
+	// filters and sorting go by the stored price until the scheduler runs
+	assert.Equal(t, []string{"Kentang"}, names("max_price=8000"))
+	assert.Equal(t, []string{"Kentang", "Cokelat"}, names("sort=price"))
+
+	scheduler := auth.WithIdentity(context.Background(), auth.Identity{Subject: "price-scheduler", Scopes: []string{auth.ScopeProductsWrite}})
+	_, err := productService.ApplyPriceSchedules(scheduler, now)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Cokelat", "Kentang"}, names("max_price=8000&sort=price"))
+	assert.Equal(t, []string{"Cokelat", "Kentang"}, names("sort=price"))
+}
+
+func TestExportShowsDuePriceSchedules(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+	saved := loadProducts(storage, domain.Product{ProductName: "Cokelat", Price: rupiah(9500)})
+	router := setupRouter(storage)
+	productService := service.NewProductService(storage.ProductRepository, storage.IdempotencyRepository, storage.AuditRepository, storage.PriceScheduleRepository, storage.TxManager, app.NewValidator(), helper.NewCursorCodec([]byte("BUBBLESECRET")), time.Hour)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	endsAt := now.Add(time.Hour)
+	savePriceSchedule(t, storage, domain.PriceSchedule{ProductId: saved[0].Id, Price: rupiah(7500), StartsAt: now.Add(-time.Minute), EndsAt: &endsAt})
+
+	// the export taken before the scheduler runs has the promo price
+	export := exportProducts(router, "?format=csv")
+	assert.Equal(t, 200, export.StatusCode)
+	body, err := io.ReadAll(export.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, "id,product_name,price,version\n"+strconv.Itoa(saved[0].Id)+",Cokelat,7500,1\n", string(body))
+
+	// importing it back keeps the promo, which still ends on time
+	code, _ := importProducts(router, "", "text/csv", bytes.NewReader(body))
+	assert.Equal(t, 200, code)
+	assert.Equal(t, rupiah(7500), findProduct(storage, saved[0].Id).Price)
+	scheduler := auth.WithIdentity(context.Background(), auth.Identity{Subject: "price-scheduler", Scopes: []string{auth.ScopeProductsWrite}})
+	_, err = productService.ApplyPriceSchedules(scheduler, endsAt)
+	assert.Nil(t, err)
+	assert.Equal(t, rupiah(9500), findProduct(storage, saved[0].Id).Price)
+}
@@ -0,0 +1,121 @@
+package test
+
+import (
+	"bubblevy/restful-api/model/domain"
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProductRevisions(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+	truncateAuditEntry(storage)
+	saved := loadProducts(storage, domain.Product{ProductName: "Cokelat", Price: rupiah(9500)})
+	router := setupRouter(storage)
+	path := "/" + strconv.Itoa(saved[0].Id)
+
+	response := sendProductRequest(router, http.MethodPut, saved[0].Id, nil, `{"product_name": "Cokelat Susu", "price": 12000}`)
+	assert.Equal(t, 200, response.StatusCode)
+	response = sendProductRequest(router, http.MethodPatch, saved[0].Id, map[string]string{"Content-Type": "application/merge-patch+json"}, `{"price": 15000}`)
+	assert.Equal(t, 200, response.StatusCode)
+
+	code, responseBody := sendTrashRequest(router, http.MethodGet, path+"/revisions")
+	assert.Equal(t, 200, code)
+	revisions := responseBody["data"].([]interface{})
+	assert.Equal(t, 3, len(revisions))
+	assert.Equal(t, 3, int(responseBody["pagination"].(map[string]interface{})["total"].(float64)))
+	latest := revisions[0].(map[string]interface{})
+	assert.Equal(t, 3, int(latest["version"].(float64)))
+	assert.Equal(t, "Cokelat Susu", latest["product_name"])
+	assert.Equal(t, 15000, int(latest["price"].(float64)))
+	assert.NotEmpty(t, latest["recorded_at"])
+
+	code, responseBody = sendTrashRequest(router, http.MethodGet, path+"/revisions/1")
+	assert.Equal(t, 200, code)
+	first := responseBody["data"].(map[string]interface{})
+	assert.Equal(t, "Cokelat", first["product_name"])
+	assert.Equal(t, 9500, int(first["price"].(float64)))
+
+	code, _ = sendTrashRequest(router, http.MethodGet, path+"/revisions/9")
+	assert.Equal(t, 404, code)
+	code, _ = sendTrashRequest(router, http.MethodGet, "/999/revisions")
+	assert.Equal(t, 404, code)
+
+	code, responseBody = sendTrashRequest(router, http.MethodPost, path+"/revert/1")
+	assert.Equal(t, 200, code)
+	reverted := responseBody["data"].(map[string]interface{})
+	assert.Equal(t, 4, int(reverted["version"].(float64)))
+	assert.Equal(t, "Cokelat", reverted["product_name"])
+	assert.Equal(t, 9500, int(reverted["price"].(float64)))
+
+	code, _ = sendTrashRequest(router, http.MethodPost, path+"/revert/9")
+	assert.Equal(t, 404, code)
+
+	// the revert is audited like any other change
+	entries := auditEntries(t, sendAuditRequest(router, "", url.Values{"product_id": {strconv.Itoa(saved[0].Id)}}))
+	assert.Equal(t, "revert", entries[0]["action"])
+
+	// deleting is a revision too, and the history outlives it
+	code, _ = sendTrashRequest(router, http.MethodDelete, path)
+	assert.Equal(t, 200, code)
+	code, responseBody = sendTrashRequest(router, http.MethodGet, path+"/revisions")
+	assert.Equal(t, 200, code)
+	deleted := responseBody["data"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, 5, int(deleted["version"].(float64)))
+	assert.NotEmpty(t, deleted["deleted_at"])
+	code, _ = sendTrashRequest(router, http.MethodPost, path+"/revert/1")
+	assert.Equal(t, 404, code)
+
+	code, _ = sendTrashRequest(router, http.MethodPost, path+"/purge")
+	assert.Equal(t, 200, code)
+	code, _ = sendTrashRequest(router, http.MethodGet, path+"/revisions")
+	assert.Equal(t, 404, code)
+}
+
+func TestProductAsOf(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+	saved := loadProducts(storage, domain.Product{ProductName: "Cokelat", Price: rupiah(9500)})
+	router := setupRouter(storage)
+	path := "/" + strconv.Itoa(saved[0].Id)
+
+	// revisions are recorded as the writes happen, so the test writes the
+	// later ones itself with times it controls
+	ctx := context.Background()
+	created, _ := time.Parse(time.RFC3339, "2030-01-01T00:00:00Z")
+	tx, _ := storage.TxManager.BeginTx(ctx)
+	product := saved[0]
+	product.Price = rupiah(12000)
+	product, err := storage.ProductRepository.Update(ctx, tx, product)
+	assert.Nil(t, err)
+	_, err = storage.ProductRepository.Delete(ctx, tx, product, created.Add(24*time.Hour))
+	assert.Nil(t, err)
+	assert.Nil(t, tx.Commit())
+
+	asOf := func(at string) (int, map[string]interface{}) {
+		return sendTrashRequest(router, http.MethodGet, path+"?as_of="+url.QueryEscape(at))
+	}
+
+	code, responseBody := asOf(time.Now().UTC().Add(time.Hour).Format(time.RFC3339))
+	assert.Equal(t, 200, code)
+	assert.Equal(t, 12000, int(responseBody["data"].(map[string]interface{})["price"].(float64)))
+	assert.Equal(t, 2, int(responseBody["data"].(map[string]interface{})["version"].(float64)))
+
+	// deleted on the second day of 2030
+	code, _ = asOf("2030-01-03T00:00:00Z")
+	assert.Equal(t, 404, code)
+	code, _ = asOf("2000-01-01T00:00:00Z")
+	assert.Equal(t, 404, code)
+	code, _ = asOf("yesterday")
+	assert.Equal(t, 400, code)
+
+	// without as_of only the live product is found
+	code, _ = sendTrashRequest(router, http.MethodGet, path)
+	assert.Equal(t, 404, code)
+}
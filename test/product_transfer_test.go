@@ -0,0 +1,227 @@
+package test
+
+import (
+	"bubblevy/restful-api/app"
+	"bubblevy/restful-api/fixture"
+	"bubblevy/restful-api/model/domain"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func exportProducts(router http.Handler, query string) *http.Response {
+	request := httptest.NewRequest(http.MethodGet, "http://localhost:3000/api/products/export"+query, nil)
+	request.Header.Add("API-Key", "BUBBLEKEY")
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder.Result()
+}
+
+func importProducts(router http.Handler, query string, contentType string, body io.Reader) (int, map[string]interface{}) {
+	request := httptest.NewRequest(http.MethodPost, "http://localhost:3000/api/products/import"+query, body)
+	request.Header.Add("Content-Type", contentType)
+	request.Header.Add("API-Key", "BUBBLEKEY")
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	var responseBody map[string]interface{}
+	json.Unmarshal(recorder.Body.Bytes(), &responseBody)
+	return recorder.Code, responseBody
+}
+
+func findProduct(storage app.Storage, productId int) domain.Product {
+	tx, _ := storage.TxManager.BeginTx(context.Background())
+	defer tx.Rollback()
+	product, _ := storage.ProductRepository.FindById(context.Background(), tx, productId)
+	return product
+}
+
+// importErrors returns the line and status of every row error of a report.
+func importErrors(responseBody map[string]interface{}) [][2]int {
+	var rowErrors [][2]int
+	for _, rowError := range responseBody["data"].(map[string]interface{})["errors"].([]interface{}) {
+		rowError := rowError.(map[string]interface{})
+		rowErrors = append(rowErrors, [2]int{int(rowError["line"].(float64)), int(rowError["status"].(float64))})
+	}
+	return rowErrors
+}
+
+func TestExportProductsCSV(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+	saved := loadProducts(storage,
+		domain.Product{ProductName: "Cokelat", Price: rupiah(9500)},
+		domain.Product{ProductName: "Kentang, Balado", Price: rupiah(5000)},
+	)
+	router := setupRouter(storage)
+
+	response := exportProducts(router, "")
+	body, _ := io.ReadAll(response.Body)
+
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "text/csv; charset=utf-8", response.Header.Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="products.csv"`, response.Header.Get("Content-Disposition"))
+	assert.Equal(t, "id,product_name,price,version\n"+
+		strconv.Itoa(saved[0].Id)+",Cokelat,9500,1\n"+
+		strconv.Itoa(saved[1].Id)+",\"Kentang, Balado\",5000,1\n", string(body))
+}
+
+func TestExportProductsNDJSON(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+	router := setupRouter(storage)
+
+	response := exportProducts(router, "?format=ndjson")
+	body, _ := io.ReadAll(response.Body)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "application/x-ndjson", response.Header.Get("Content-Type"))
+	assert.Empty(t, body)
+
+	// more products than fit one page of the export
+	saved := loadProducts(storage, fixture.NewGenerator(7).Products(1203)...)
+	response = exportProducts(router, "?format=ndjson")
+	body, _ = io.ReadAll(response.Body)
+
+	lines := strings.Split(strings.TrimSuffix(string(body), "\n"), "\n")
+	assert.Len(t, lines, 1203)
+	for _, i := range []int{0, 499, 500, 1202} {
+		var product map[string]interface{}
+		assert.Nil(t, json.Unmarshal([]byte(lines[i]), &product))
+		assert.Equal(t, saved[i].Id, int(product["id"].(float64)))
+		assert.Equal(t, saved[i].ProductName, product["product_name"])
+	}
+
+	response = exportProducts(router, "?format=xlsx")
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Equal(t, "application/problem+json", response.Header.Get("Content-Type"))
+	assert.Empty(t, response.Header.Get("Content-Disposition"))
+}
+
+func TestImportProductsCSV(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+	saved := loadProducts(storage, domain.Product{ProductName: "Cokelat", Price: rupiah(9500)})
+	router := setupRouter(storage)
+
+	body := "\uFEFFprice,product_name,id\n" +
+		"12000,Cokelat Susu," + strconv.Itoa(saved[0].Id) + "\n" +
+		"7000,Susu,\n" +
+		"mahal,Teh,\n" +
+		"4000,,\n" +
+		"4000,Kopi,404\n" +
+		"3000,\"Roti\n Tawar\",\n" +
+		"1000,Permen\n"
+	code, responseBody := importProducts(router, "", "text/csv", strings.NewReader(body))
+
+	assert.Equal(t, 207, code)
+	data := responseBody["data"].(map[string]interface{})
+	assert.Equal(t, "csv", data["format"])
+	assert.Equal(t, "id", data["key"])
+	assert.Equal(t, 7, int(data["rows"].(float64)))
+	assert.Equal(t, 2, int(data["created"].(float64)))
+	assert.Equal(t, 1, int(data["updated"].(float64)))
+	assert.Equal(t, 4, int(data["failed"].(float64)))
+	assert.Equal(t, [][2]int{{4, 400}, {5, 400}, {6, 404}, {9, 400}}, importErrors(responseBody))
+
+	product := findProduct(storage, saved[0].Id)
+	assert.Equal(t, "Cokelat Susu", product.ProductName)
+	assert.Equal(t, rupiah(12000), product.Price)
+	assert.Equal(t, 3, countProducts(storage))
+}
+
+func TestImportProductsByName(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+	loadProducts(storage,
+		domain.Product{ProductName: "Cokelat", Price: rupiah(9500)},
+		domain.Product{ProductName: "Kentang", Price: rupiah(5000)},
+		domain.Product{ProductName: "Kentang", Price: rupiah(5500)},
+	)
+	router := setupRouter(storage)
+
+	body := `{"id": 1, "product_name": "Cokelat", "price": 10000}
+{"product_name": "Susu", "price": 7000}
+
+{"product_name": "Susu", "price": 7500}
+{"product_name": "Kentang", "price": 6000}
+{"product_name": "Teh", "price": 4000, "color": "green"}
+`
+	code, responseBody := importProducts(router, "?key=product_name", "application/x-ndjson", strings.NewReader(body))
+
+	assert.Equal(t, 207, code)
+	data := responseBody["data"].(map[string]interface{})
+	assert.Equal(t, "ndjson", data["format"])
+	assert.Equal(t, 5, int(data["rows"].(float64)))
+	assert.Equal(t, 1, int(data["created"].(float64)))
+	assert.Equal(t, 2, int(data["updated"].(float64)))
+	assert.Equal(t, [][2]int{{5, 409}, {6, 400}}, importErrors(responseBody))
+	assert.Equal(t, 4, countProducts(storage))
+}
+
+func TestImportProductsInChunks(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+	router := setupRouter(storage)
+
+	var body bytes.Buffer
+	body.WriteString("product_name,price\n")
+	for i, product := range fixture.NewGenerator(11).Products(1234) {
+		if i == 700 {
+			product.Price = rupiah(0)
+		}
+		body.WriteString(product.ProductName + "," + product.Price.Decimal() + "\n")
+	}
+
+	// an upload of a multipart form, as a browser sends it
+	var form bytes.Buffer
+	multipartWriter := multipart.NewWriter(&form)
+	multipartWriter.WriteField("note", "weekly prices")
+	file, _ := multipartWriter.CreateFormFile("file", "prices.CSV")
+	file.Write(body.Bytes())
+	multipartWriter.Close()
+
+	code, responseBody := importProducts(router, "", multipartWriter.FormDataContentType(), &form)
+
+	assert.Equal(t, 207, code)
+	data := responseBody["data"].(map[string]interface{})
+	assert.Equal(t, "csv", data["format"])
+	assert.Equal(t, 1233, int(data["created"].(float64)))
+	assert.Equal(t, [][2]int{{702, 400}}, importErrors(responseBody))
+	assert.Equal(t, 1233, countProducts(storage))
+
+	// an export imports back cleanly
+	export := exportProducts(router, "")
+	code, responseBody = importProducts(router, "", "text/csv", export.Body)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, 1233, int(responseBody["data"].(map[string]interface{})["updated"].(float64)))
+	assert.Equal(t, 1233, countProducts(storage))
+}
+
+func TestImportProductsRejectsUpload(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+	router := setupRouter(storage)
+
+	code, responseBody := importProducts(router, "", "text/plain", strings.NewReader("product_name,price\n"))
+	assert.Equal(t, 400, code)
+	assert.Equal(t, "format", responseBody["errors"].([]interface{})[0].(map[string]interface{})["field"])
+
+	code, responseBody = importProducts(router, "?format=csv", "text/plain", strings.NewReader("name,price\nCokelat,9500\n"))
+	assert.Equal(t, 400, code)
+	assert.Equal(t, `unknown CSV column "name", expected: id product_name price currency version`, responseBody["detail"])
+
+	code, _ = importProducts(router, "?format=csv&key=sku", "text/csv", strings.NewReader("product_name,price\n"))
+	assert.Equal(t, 400, code)
+	assert.Equal(t, 0, countProducts(storage))
+}
@@ -0,0 +1,178 @@
+package test
+
+import (
+	"bubblevy/restful-api/app"
+	"bubblevy/restful-api/auth"
+	"bubblevy/restful-api/config"
+	"bubblevy/restful-api/exception"
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/model/domain"
+	"bubblevy/restful-api/model/web"
+	"bubblevy/restful-api/service"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sendTrashRequest(router http.Handler, method string, path string) (int, map[string]interface{}) {
+	request := httptest.NewRequest(method, "http://localhost:3000/api/products"+path, nil)
+	request.Header.Add("API-Key", "BUBBLEKEY")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	var responseBody map[string]interface{}
+	body, _ := io.ReadAll(recorder.Result().Body)
+	json.Unmarshal(body, &responseBody)
+	return recorder.Code, responseBody
+}
+
+func TestProductTrashAndRestore(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+	saved := loadProducts(storage,
+		domain.Product{ProductName: "Cokelat", Price: rupiah(9500)},
+		domain.Product{ProductName: "Kentang", Price: rupiah(5000)},
+	)
+	router := setupRouter(storage)
+	deletedId := strconv.Itoa(saved[0].Id)
+
+	code, _ := sendTrashRequest(router, http.MethodDelete, "/"+deletedId)
+	assert.Equal(t, 200, code)
+
+	code, _ = sendTrashRequest(router, http.MethodGet, "/"+deletedId)
+	assert.Equal(t, 404, code)
+	code, responseBody := sendTrashRequest(router, http.MethodGet, "")
+	assert.Equal(t, 200, code)
+	assert.Equal(t, 1, len(responseBody["data"].([]interface{})))
+	assert.Equal(t, 1, int(responseBody["pagination"].(map[string]interface{})["total"].(float64)))
+
+	code, responseBody = sendTrashRequest(router, http.MethodGet, "/trash")
+	assert.Equal(t, 200, code)
+	trash := responseBody["data"].([]interface{})
+	assert.Equal(t, 1, len(trash))
+	deleted := trash[0].(map[string]interface{})
+	assert.Equal(t, "Cokelat", deleted["product_name"])
+	assert.Equal(t, 2, int(deleted["version"].(float64)))
+	assert.NotEmpty(t, deleted["deleted_at"])
+
+	// a deleted product cannot be changed or deleted again
+	code, _ = sendTrashRequest(router, http.MethodDelete, "/"+deletedId)
+	assert.Equal(t, 404, code)
+	response := sendProductRequest(router, http.MethodPut, saved[0].Id, nil, `{"product_name": "Cokelat", "price": 12000}`)
+	assert.Equal(t, 404, response.StatusCode)
+
+	code, _ = sendTrashRequest(router, http.MethodPost, "/"+strconv.Itoa(saved[1].Id)+"/restore")
+	assert.Equal(t, 409, code)
+	code, _ = sendTrashRequest(router, http.MethodPost, "/999/restore")
+	assert.Equal(t, 404, code)
+
+	code, responseBody = sendTrashRequest(router, http.MethodPost, "/"+deletedId+"/restore")
+	assert.Equal(t, 200, code)
+	restored := responseBody["data"].(map[string]interface{})
+	assert.Equal(t, 3, int(restored["version"].(float64)))
+	assert.Nil(t, restored["deleted_at"])
+
+	code, _ = sendTrashRequest(router, http.MethodGet, "/"+deletedId)
+	assert.Equal(t, 200, code)
+	code, responseBody = sendTrashRequest(router, http.MethodGet, "/trash")
+	assert.Equal(t, 200, code)
+	assert.Nil(t, responseBody["data"])
+
+	// the routes sharing a segment with /restore still work
+	code, _ = sendTrashRequest(router, http.MethodPost, "/"+deletedId)
+	assert.Equal(t, 405, code)
+	code, _ = postBulk(router, `{"operations": [{"op": "create", "product_name": "Permen", "price": 500}]}`, withApiKey)
+	assert.Equal(t, 200, code)
+}
+
+func TestProductPurge(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+	saved := loadProducts(storage,
+		domain.Product{ProductName: "Cokelat", Price: rupiah(9500)},
+		domain.Product{ProductName: "Kentang", Price: rupiah(5000)},
+	)
+	router := setupRouter(storage)
+	productService := service.NewProductService(storage.ProductRepository, storage.IdempotencyRepository, storage.AuditRepository, storage.PriceScheduleRepository, storage.TxManager, app.NewValidator(), helper.NewCursorCodec([]byte("BUBBLESECRET")), time.Hour)
+
+	code, _ := sendTrashRequest(router, http.MethodPost, "/"+strconv.Itoa(saved[1].Id)+"/purge")
+	assert.Equal(t, 409, code)
+
+	code, _ = sendTrashRequest(router, http.MethodDelete, "/"+strconv.Itoa(saved[0].Id))
+	assert.Equal(t, 200, code)
+
+	// deleting and restoring is for editors with delete rights, purging is not
+	editor := auth.WithIdentity(context.Background(), auth.Identity{Subject: "bob", Scopes: []string{auth.ScopeProductsRead, auth.ScopeProductsWrite, auth.ScopeProductsDelete}})
+	err := productService.Purge(editor, web.ProductPurgeRequest{Id: saved[0].Id})
+	assert.IsType(t, exception.ForbiddenError{}, err)
+	admin := auth.WithIdentity(context.Background(), auth.Identity{Subject: "carol", Roles: []string{auth.RoleAdmin}})
+	err = productService.Purge(admin, web.ProductPurgeRequest{Id: saved[0].Id, ExpectedVersions: []int{1}})
+	assert.IsType(t, exception.PreconditionFailedError{}, err)
+
+	code, _ = sendTrashRequest(router, http.MethodPost, "/"+strconv.Itoa(saved[0].Id)+"/purge")
+	assert.Equal(t, 200, code)
+	code, responseBody := sendTrashRequest(router, http.MethodGet, "/trash")
+	assert.Equal(t, 200, code)
+	assert.Nil(t, responseBody["data"])
+	code, _ = sendTrashRequest(router, http.MethodPost, "/"+strconv.Itoa(saved[0].Id)+"/restore")
+	assert.Equal(t, 404, code)
+}
+
+func TestTrashRetentionJob(t *testing.T) {
+	storage := testStorage()
+	truncateProduct(storage)
+	saved := loadProducts(storage,
+		domain.Product{ProductName: "Cokelat", Price: rupiah(9500)},
+		domain.Product{ProductName: "Kentang", Price: rupiah(5000)},
+		domain.Product{ProductName: "Permen", Price: rupiah(500)},
+	)
+
+	now := time.Now().UTC()
+	ctx := context.Background()
+	tx, _ := storage.TxManager.BeginTx(ctx)
+	_, err := storage.ProductRepository.Delete(ctx, tx, saved[0], now.Add(-31*24*time.Hour))
+	assert.Nil(t, err)
+	_, err = storage.ProductRepository.Delete(ctx, tx, saved[1], now.Add(-time.Hour))
+	assert.Nil(t, err)
+	assert.Nil(t, tx.Commit())
+
+	productService := service.NewProductService(storage.ProductRepository, storage.IdempotencyRepository, storage.AuditRepository, storage.PriceScheduleRepository, storage.TxManager, app.NewValidator(), helper.NewCursorCodec([]byte("BUBBLESECRET")), time.Hour)
+	job := app.NewTrashRetentionJob(productService, config.TrashConfig{Retention: 30 * 24 * time.Hour, PurgeInterval: time.Hour})
+	assert.Nil(t, job.Task(ctx))
+
+	tx, _ = storage.TxManager.BeginTx(ctx)
+	defer tx.Rollback()
+	trash, err := storage.ProductRepository.FindAllByQuery(ctx, tx, domain.ProductQuery{Filter: domain.ProductFilter{Deleted: true}, Limit: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(trash))
+	assert.Equal(t, saved[1].Id, trash[0].Id)
+	_, err = storage.ProductRepository.FindById(ctx, tx, saved[2].Id)
+	assert.Nil(t, err)
+
+	// outside the job PurgeTrash is guarded like any other operation
+	_, err = productService.PurgeTrash(ctx, now)
+	assert.IsType(t, exception.UnauthorizedError{}, err)
+}
+
+func TestJobRunsUntilStopped(t *testing.T) {
+	runs := make(chan struct{}, 10)
+	job := app.NewJob("test", time.Millisecond, func(ctx context.Context) error {
+		runs <- struct{}{}
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	job.Start()
+	<-runs
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.Nil(t, job.Stop(ctx))
+	assert.Equal(t, 0, len(runs))
+}
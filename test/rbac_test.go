@@ -0,0 +1,62 @@
+package test
+
+import (
+	"bubblevy/restful-api/app"
+	"bubblevy/restful-api/auth"
+	"bubblevy/restful-api/exception"
+	"bubblevy/restful-api/helper"
+	"bubblevy/restful-api/model/web"
+	"bubblevy/restful-api/service"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdentityPermissions(t *testing.T) {
+	viewer := auth.Identity{Subject: "alice", Roles: []string{auth.RoleViewer}}
+	assert.True(t, viewer.Can(auth.ScopeProductsRead))
+	assert.False(t, viewer.Can(auth.ScopeProductsWrite))
+
+	editor := auth.Identity{Subject: "bob", Roles: []string{auth.RoleEditor}}
+	assert.True(t, editor.Can(auth.ScopeProductsWrite))
+	assert.False(t, editor.Can(auth.ScopeProductsDelete))
+
+	admin := auth.Identity{Subject: "carol", Roles: []string{auth.RoleAdmin}}
+	assert.True(t, admin.Can(auth.ScopeProductsDelete))
+
+	scoped := auth.Identity{Subject: "reporting", Scopes: []string{auth.ScopeProductsDelete}}
+	assert.True(t, scoped.Can(auth.ScopeProductsDelete))
+	assert.False(t, scoped.Can(auth.ScopeProductsRead))
+
+	unknown := auth.Identity{Subject: "dave", Roles: []string{"superuser"}}
+	assert.False(t, unknown.Can(auth.ScopeProductsRead))
+}
+
+func TestProductServiceAuthorization(t *testing.T) {
+	storage := testStorage()
+	productService := service.NewProductService(storage.ProductRepository, storage.IdempotencyRepository, storage.AuditRepository, storage.PriceScheduleRepository, storage.TxManager, app.NewValidator(), helper.NewCursorCodec([]byte("BUBBLESECRET")), time.Hour)
+
+	err := productService.Delete(context.Background(), web.ProductDeleteRequest{Id: 1})
+	assert.IsType(t, exception.UnauthorizedError{}, err)
+
+	ctx := auth.WithIdentity(context.Background(), auth.Identity{Subject: "bob", Roles: []string{auth.RoleEditor}})
+	err = productService.Delete(ctx, web.ProductDeleteRequest{Id: 1})
+	assert.IsType(t, exception.ForbiddenError{}, err)
+}
+
+func TestDeleteProductForbidden(t *testing.T) {
+	storage := testStorage()
+	router := setupRouterWithVerifier(storage, stubVerifier{identity: auth.Identity{Subject: "alice", Roles: []string{auth.RoleViewer}}})
+
+	request := httptest.NewRequest(http.MethodDelete, "http://localhost:3000/api/products/1", nil)
+	request.Header.Add("Authorization", "Bearer good-token")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	assert.Equal(t, 403, recorder.Result().StatusCode)
+	assert.Equal(t, "application/problem+json", recorder.Result().Header.Get("Content-Type"))
+}
@@ -0,0 +1,121 @@
+package test
+
+import (
+	"bubblevy/restful-api/app"
+	"bubblevy/restful-api/config"
+	"bubblevy/restful-api/model/domain"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sqliteStorage(t *testing.T) app.Storage {
+	cfg := testConfig()
+	cfg.Storage = config.StorageSQL
+	cfg.Database.Driver = config.DriverSQLite
+	cfg.Database.DSN = filepath.Join(t.TempDir(), "bubble.db")
+
+	storage := app.NewStorage(cfg)
+	t.Cleanup(func() { storage.Close() })
+	return storage
+}
+
+func TestSQLiteCreatesSchema(t *testing.T) {
+	storage := sqliteStorage(t)
+	router := setupRouter(storage)
+
+	request := httptest.NewRequest(http.MethodPost, "http://localhost:3000/api/products", strings.NewReader(`{"product_name" : "Cokelat", "price" : 9500}`))
+	request.Header.Add("Content-Type", "application/json")
+	request.Header.Add("API-Key", "BUBBLEKEY")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	assert.Equal(t, 201, recorder.Code)
+
+	request = httptest.NewRequest(http.MethodGet, "http://localhost:3000/api/products?name_contains=COKE", nil)
+	request.Header.Add("API-Key", "BUBBLEKEY")
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	assert.True(t, strings.Contains(recorder.Body.String(), `"product_name":"Cokelat"`))
+}
+
+func TestSQLiteConcurrentWrites(t *testing.T) {
+	storage := sqliteStorage(t)
+	router := setupRouter(storage)
+
+	var wait sync.WaitGroup
+	codes := make(chan int, 20)
+	for i := 0; i < 20; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			request := httptest.NewRequest(http.MethodPost, "http://localhost:3000/api/products", strings.NewReader(`{"product_name" : "Cokelat", "price" : 9500}`))
+			request.Header.Add("Content-Type", "application/json")
+			request.Header.Add("API-Key", "BUBBLEKEY")
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+			codes <- recorder.Code
+		}()
+	}
+	wait.Wait()
+	close(codes)
+
+	for code := range codes {
+		assert.Equal(t, 201, code)
+	}
+
+	var total int
+	storage.DB.QueryRow("SELECT COUNT(*) FROM products").Scan(&total)
+	assert.Equal(t, 20, total)
+}
+
+func TestSQLiteSaveAllMatchesIdsToRows(t *testing.T) {
+	storage := sqliteStorage(t)
+	ctx := context.Background()
+
+	// more rows than one INSERT carries, some of them alike
+	var products []domain.Product
+	var entries []domain.AuditEntry
+	for i := 0; i < 600; i++ {
+		products = append(products, domain.Product{ProductName: "Produk " + strconv.Itoa(i%550), Price: rupiah(int64(1000 + i%550))})
+		entries = append(entries, domain.AuditEntry{Actor: "bob", Action: domain.AuditActionCreate, ProductId: i + 1, After: json.RawMessage(`{"id":` + strconv.Itoa(i+1) + `}`), RequestId: "r1", CreatedAt: time.Now().UTC()})
+	}
+
+	tx, _ := storage.TxManager.BeginTx(ctx)
+	saved, err := storage.ProductRepository.SaveAll(ctx, tx, products)
+	assert.Nil(t, err)
+	savedEntries, err := storage.AuditRepository.SaveAll(ctx, tx, entries)
+	assert.Nil(t, err)
+	assert.Nil(t, tx.Commit())
+
+	tx, _ = storage.TxManager.BeginTx(ctx)
+	defer tx.Rollback()
+	ids := map[int]bool{}
+	for _, product := range saved {
+		ids[product.Id] = true
+		found, err := storage.ProductRepository.FindById(ctx, tx, product.Id)
+		assert.Nil(t, err)
+		assert.Equal(t, product.ProductName, found.ProductName)
+		assert.Equal(t, product.Price, found.Price)
+	}
+	assert.Equal(t, len(products), len(ids))
+
+	stored, err := storage.AuditRepository.FindAllByQuery(ctx, tx, domain.AuditQuery{Limit: len(entries)})
+	assert.Nil(t, err)
+	productIds := map[int]int{}
+	for _, entry := range stored {
+		productIds[entry.Id] = entry.ProductId
+	}
+	for _, entry := range savedEntries {
+		assert.Equal(t, entry.ProductId, productIds[entry.Id])
+	}
+}